@@ -1,9 +1,12 @@
 package agent
 
 import (
+	"context"
 	"sync"
+	"time"
 
 	"github.com/observiq/stanza/database"
+	"github.com/observiq/stanza/operator"
 	"github.com/observiq/stanza/pipeline"
 	"go.uber.org/zap"
 )
@@ -13,6 +16,11 @@ type LogAgent struct {
 	database database.Database
 	pipeline pipeline.Pipeline
 
+	// statusInterval is how often the status of the operators is logged. Zero disables it.
+	statusInterval time.Duration
+	cancel         context.CancelFunc
+	wg             sync.WaitGroup
+
 	startOnce sync.Once
 	stopOnce  sync.Once
 
@@ -26,6 +34,10 @@ func (a *LogAgent) Start() (err error) {
 		if err != nil {
 			return
 		}
+
+		if a.statusInterval > 0 {
+			a.startStatusLogger()
+		}
 	})
 	return
 }
@@ -33,6 +45,11 @@ func (a *LogAgent) Start() (err error) {
 // Stop will stop the log monitoring process
 func (a *LogAgent) Stop() (err error) {
 	a.stopOnce.Do(func() {
+		if a.cancel != nil {
+			a.cancel()
+			a.wg.Wait()
+		}
+
 		err = a.pipeline.Stop()
 		if err != nil {
 			return
@@ -45,3 +62,38 @@ func (a *LogAgent) Stop() (err error) {
 	})
 	return
 }
+
+// Status returns the status of each operator that reports one, keyed by operator id
+func (a *LogAgent) Status() map[string]map[string]interface{} {
+	statuses := make(map[string]map[string]interface{})
+	for _, op := range a.pipeline.Operators() {
+		if reporter, ok := op.(operator.StatusReporter); ok {
+			statuses[op.ID()] = reporter.Status()
+		}
+	}
+	return statuses
+}
+
+// startStatusLogger kicks off a goroutine that logs the status of the operators every status interval
+func (a *LogAgent) startStatusLogger() {
+	ctx, cancel := context.WithCancel(context.Background())
+	a.cancel = cancel
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		ticker := time.NewTicker(a.statusInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				for id, status := range a.Status() {
+					a.Infow("Operator status", "operator_id", id, "status", status)
+				}
+			}
+		}
+	}()
+}
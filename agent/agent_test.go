@@ -3,10 +3,13 @@ package agent
 import (
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/observiq/stanza/operator"
 	"github.com/observiq/stanza/testutil"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestStartAgentSuccess(t *testing.T) {
@@ -93,3 +96,58 @@ func TestStopAgentDatabaseFailure(t *testing.T) {
 	pipeline.AssertCalled(t, "Stop")
 	database.AssertCalled(t, "Close")
 }
+
+type statusOperator struct {
+	testutil.Operator
+	status map[string]interface{}
+}
+
+func (o *statusOperator) Status() map[string]interface{} {
+	return o.status
+}
+
+func TestAgentStatus(t *testing.T) {
+	reporter := &statusOperator{status: map[string]interface{}{"circuit_breaker": "open"}}
+	reporter.On("ID").Return("$.reporter")
+	silent := &testutil.Operator{}
+	silent.On("ID").Return("$.silent")
+
+	pipeline := &testutil.Pipeline{}
+	pipeline.On("Operators").Return([]operator.Operator{reporter, silent})
+
+	agent := LogAgent{
+		SugaredLogger: zap.NewNop().Sugar(),
+		pipeline:      pipeline,
+	}
+	require.Equal(t, map[string]map[string]interface{}{
+		"$.reporter": {"circuit_breaker": "open"},
+	}, agent.Status())
+}
+
+func TestAgentStatusLogger(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	reporter := &statusOperator{status: map[string]interface{}{"circuit_breaker": "open"}}
+	reporter.On("ID").Return("$.reporter")
+
+	pipeline := &testutil.Pipeline{}
+	pipeline.On("Start").Return(nil)
+	pipeline.On("Stop").Return(nil)
+	pipeline.On("Operators").Return([]operator.Operator{reporter})
+	database := &testutil.Database{}
+	database.On("Close").Return(nil)
+
+	agent := LogAgent{
+		SugaredLogger:  zap.New(core).Sugar(),
+		pipeline:       pipeline,
+		database:       database,
+		statusInterval: 10 * time.Millisecond,
+	}
+	require.NoError(t, agent.Start())
+	require.Eventually(t, func() bool {
+		return logs.FilterMessage("Operator status").Len() > 0
+	}, time.Second, 10*time.Millisecond)
+	require.NoError(t, agent.Stop())
+
+	entry := logs.FilterMessage("Operator status").All()[0]
+	require.Equal(t, "$.reporter", entry.ContextMap()["operator_id"])
+}
@@ -13,12 +13,13 @@ import (
 
 // LogAgentBuilder is a construct used to build a log agent
 type LogAgentBuilder struct {
-	configFiles   []string
-	config        *Config
-	logger        *zap.SugaredLogger
-	pluginDir     string
-	databaseFile  string
-	defaultOutput operator.Operator
+	configFiles    []string
+	config         *Config
+	logger         *zap.SugaredLogger
+	pluginDir      string
+	databaseFile   string
+	defaultOutput  operator.Operator
+	statusInterval time.Duration
 }
 
 // NewBuilder creates a new LogAgentBuilder
@@ -58,6 +59,12 @@ func (b *LogAgentBuilder) WithDefaultOutput(defaultOutput operator.Operator) *Lo
 	return b
 }
 
+// WithStatusInterval logs the status of the operators at the specified interval when building a log agent
+func (b *LogAgentBuilder) WithStatusInterval(statusInterval time.Duration) *LogAgentBuilder {
+	b.statusInterval = statusInterval
+	return b
+}
+
 // Build will build a new log agent using the values defined on the builder
 func (b *LogAgentBuilder) Build() (*LogAgent, error) {
 	db, err := database.OpenDatabase(b.databaseFile)
@@ -95,8 +102,9 @@ func (b *LogAgentBuilder) Build() (*LogAgent, error) {
 	}
 
 	return &LogAgent{
-		pipeline:      pipeline,
-		database:      db,
-		SugaredLogger: b.logger,
+		pipeline:       pipeline,
+		database:       db,
+		statusInterval: b.statusInterval,
+		SugaredLogger:  b.logger,
 	}, nil
 }
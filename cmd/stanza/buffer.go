@@ -0,0 +1,273 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/observiq/stanza/agent"
+	"github.com/observiq/stanza/database"
+	"github.com/observiq/stanza/entry"
+	"github.com/observiq/stanza/operator"
+	"github.com/observiq/stanza/operator/buffer"
+	"github.com/observiq/stanza/plugin"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap/zapcore"
+)
+
+// bufferReadSize is the number of entries read from a disk buffer at a time
+// by the buffer subcommands
+const bufferReadSize = 1000
+
+// NewBufferCmd returns the root command for managing disk buffers
+func NewBufferCmd(rootFlags *RootFlags) *cobra.Command {
+	bufferCmd := &cobra.Command{
+		Use:   "buffer",
+		Short: "Inspect and manage disk buffers while the agent is stopped",
+		Args:  cobra.NoArgs,
+		Run: func(command *cobra.Command, args []string) {
+			stdout.Write([]byte("No buffer subcommand specified. See `stanza buffer help` for details\n"))
+		},
+	}
+
+	bufferCmd.AddCommand(NewBufferInspectCmd())
+	bufferCmd.AddCommand(NewBufferDumpCmd())
+	bufferCmd.AddCommand(NewBufferPurgeCmd())
+	bufferCmd.AddCommand(NewBufferDrainCmd(rootFlags))
+
+	return bufferCmd
+}
+
+// NewBufferInspectCmd returns the command for printing a summary of a disk buffer
+func NewBufferInspectCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "inspect <path>",
+		Short: "Print the entry count, unread and dead ranges, and size of a disk buffer",
+		Args:  cobra.ExactArgs(1),
+		Run: func(command *cobra.Command, args []string) {
+			info, err := buffer.InspectDiskBuffer(args[0])
+			exitOnErr("Failed to inspect buffer", err)
+
+			fmt.Fprintf(stdout, "entries:             %d\n", info.EntryCount())
+			fmt.Fprintf(stdout, "unread entries:      %d\n", info.UnreadCount)
+			fmt.Fprintf(stdout, "unread start offset: %d\n", info.UnreadStartOffset)
+			fmt.Fprintf(stdout, "read entries:        %d\n", info.ReadCount)
+			fmt.Fprintf(stdout, "flushed entries:     %d\n", info.FlushedCount)
+			fmt.Fprintf(stdout, "dead range start:    %d\n", info.DeadRangeStart)
+			fmt.Fprintf(stdout, "dead range length:   %d\n", info.DeadRangeLength)
+			fmt.Fprintf(stdout, "data size:           %d\n", info.DataSize)
+		},
+	}
+}
+
+// NewBufferDumpCmd returns the command for printing the entries in a disk buffer
+func NewBufferDumpCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "dump <path>",
+		Short: "Print the unflushed entries in a disk buffer as JSON lines",
+		Args:  cobra.ExactArgs(1),
+		Run: func(command *cobra.Command, args []string) {
+			if _, err := os.Stat(args[0]); err != nil {
+				exitOnErr("Failed to open buffer", err)
+			}
+
+			// The buffer is read without opening it, since opening compacts it
+			enc := json.NewEncoder(stdout)
+			err := buffer.DumpDiskBuffer(args[0], func(e *entry.Entry) error {
+				return enc.Encode(e)
+			})
+			exitOnErr("Failed to dump buffer", err)
+		},
+	}
+}
+
+// NewBufferPurgeCmd returns the command for removing all entries from a disk buffer
+func NewBufferPurgeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "purge <path>",
+		Short: "Remove all entries from a disk buffer",
+		Args:  cobra.ExactArgs(1),
+		Run: func(command *cobra.Command, args []string) {
+			b := openDiskBuffer(args[0])
+			defer func() { exitOnErr("Failed to close buffer", b.Close()) }()
+
+			exitOnErr("Failed to purge buffer", b.Purge())
+		},
+	}
+}
+
+// NewBufferDrainCmd returns the command for replaying the entries of a disk buffer into an output
+func NewBufferDrainCmd(rootFlags *RootFlags) *cobra.Command {
+	var outputID string
+	var wait time.Duration
+
+	bufferDrain := &cobra.Command{
+		Use:   "drain [flags] <path>",
+		Short: "Replay the entries in a disk buffer into an output from the config",
+		Long: "Replay the entries in a disk buffer into an output from the config.\n" +
+			"The output must not be configured with a disk buffer at the same path.\n" +
+			"Entries are removed from the buffer once the output has stopped, which either sends\n" +
+			"them or saves them in its own buffer. Outputs with a memory buffer require --database.",
+		Args: cobra.ExactArgs(1),
+		Run: func(command *cobra.Command, args []string) {
+			if outputID == "" {
+				stdout.Write([]byte("Must specify an output with the --to flag\n"))
+				os.Exit(1)
+			}
+
+			var logger = newDefaultLoggerAt(zapcore.InfoLevel, rootFlags.LogFile)
+			if rootFlags.Debug {
+				logger = newDefaultLoggerAt(zapcore.DebugLevel, rootFlags.LogFile)
+			}
+			defer func() { _ = logger.Sync() }()
+
+			cfg, err := agent.NewConfigFromGlobs(rootFlags.ConfigFiles)
+			exitOnErr("Failed to read configs from glob", err)
+
+			if errs := plugin.RegisterPlugins(rootFlags.PluginDir, operator.DefaultRegistry); len(errs) != 0 {
+				logger.Errorw("Got errors parsing plugins", "errors", errs)
+			}
+
+			opCfg, err := findDrainOutput(cfg, outputID)
+			exitOnErr("Failed to build output", err)
+
+			// Entries the output has not sent when it stops are only kept if its buffer persists them
+			if rootFlags.DatabaseFile == "" && hasMemoryBuffer(opCfg) {
+				exitOnErr("Failed to build output", fmt.Errorf("output '%s' has a memory buffer, which is only persisted with --database", outputID))
+			}
+
+			db, err := database.OpenDatabase(rootFlags.DatabaseFile)
+			exitOnErr("Failed to open database", err)
+			defer db.Close()
+
+			output, err := buildDrainOutput(opCfg, operator.NewBuildContext(db, logger), outputID)
+			exitOnErr("Failed to build output", err)
+
+			b := openDiskBuffer(args[0])
+			defer func() { exitOnErr("Failed to close buffer", b.Close()) }()
+
+			exitOnErr("Failed to start output", output.Start())
+
+			count := 0
+			ctx := command.Context()
+			var clearers []buffer.Clearer
+			err = readDiskBuffer(b, func(entries []*entry.Entry, clearer buffer.Clearer) error {
+				for _, e := range entries {
+					if err := output.Process(ctx, e); err != nil {
+						return err
+					}
+				}
+				count += len(entries)
+				clearers = append(clearers, clearer)
+				return nil
+			})
+
+			// Give the output a chance to flush what it has buffered. Anything
+			// left over is persisted by the output's own buffer when it stops.
+			if err == nil {
+				select {
+				case <-ctx.Done():
+				case <-time.After(wait):
+				}
+			}
+			exitOnErr("Failed to stop output", output.Stop())
+			exitOnErr("Failed to drain buffer", err)
+
+			// Once the output has stopped, every entry has either been sent or saved by
+			// the output's buffer, so they can be removed from the drained buffer
+			for _, clearer := range clearers {
+				exitOnErr("Failed to mark entries as flushed", clearer.MarkAllAsFlushed())
+			}
+
+			fmt.Fprintf(stdout, "Drained %d entries into %s\n", count, output.ID())
+		},
+	}
+
+	bufferDrain.Flags().StringVar(&outputID, "to", "", "id of the output to replay entries into")
+	bufferDrain.Flags().DurationVar(&wait, "wait", 5*time.Second, "time to wait for the output to flush before stopping")
+
+	return bufferDrain
+}
+
+// findDrainOutput returns the config of the operator with the given id
+func findDrainOutput(cfg *agent.Config, outputID string) (operator.Config, error) {
+	for _, opCfg := range cfg.Pipeline {
+		if opCfg.ID() == outputID {
+			return opCfg, nil
+		}
+	}
+	return operator.Config{}, fmt.Errorf("operator '%s' not found in config", outputID)
+}
+
+// hasMemoryBuffer returns whether an operator is configured with a memory buffer,
+// including outputs that use the default buffer
+func hasMemoryBuffer(opCfg operator.Config) bool {
+	raw, err := json.Marshal(opCfg)
+	if err != nil {
+		return false
+	}
+
+	var bufferCfg struct {
+		Buffer *struct {
+			Type string `json:"type"`
+		} `json:"buffer"`
+	}
+	if err := json.Unmarshal(raw, &bufferCfg); err != nil {
+		return false
+	}
+	return bufferCfg.Buffer != nil && bufferCfg.Buffer.Type == "memory"
+}
+
+// buildDrainOutput builds the operator with the given id from its config.
+// Only that operator is built, so the rest of the pipeline is not started.
+func buildDrainOutput(opCfg operator.Config, bc operator.BuildContext, outputID string) (operator.Operator, error) {
+	id := bc.PrependNamespace(outputID)
+	operators, err := opCfg.Build(bc)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range operators {
+		if op.ID() != id {
+			continue
+		}
+		if !op.CanProcess() {
+			return nil, fmt.Errorf("operator '%s' can not process entries", outputID)
+		}
+		return op, nil
+	}
+
+	return nil, fmt.Errorf("operator '%s' not found in config", outputID)
+}
+
+// openDiskBuffer opens the disk buffer at path, exiting if it fails. The maximum
+// size is not enforced, since the buffer is never written to by these commands.
+func openDiskBuffer(path string) *buffer.DiskBuffer {
+	if _, err := os.Stat(path); err != nil {
+		exitOnErr("Failed to open buffer", err)
+	}
+
+	b := buffer.NewDiskBuffer(1<<63 - 1)
+	exitOnErr("Failed to open buffer", b.Open(path, true))
+	return b
+}
+
+// readDiskBuffer reads every unread entry in a disk buffer, in chunks, and
+// calls handle for each chunk.
+func readDiskBuffer(b *buffer.DiskBuffer, handle func([]*entry.Entry, buffer.Clearer) error) error {
+	dst := make([]*entry.Entry, bufferReadSize)
+	for {
+		clearer, n, err := b.Read(dst)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+
+		if err := handle(dst[:n], clearer); err != nil {
+			return err
+		}
+	}
+}
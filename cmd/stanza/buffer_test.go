@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/observiq/stanza/agent"
+	"github.com/observiq/stanza/entry"
+	"github.com/observiq/stanza/operator/buffer"
+	"github.com/stretchr/testify/require"
+)
+
+func writeBufferEntries(t *testing.T, path string, n int) {
+	b := buffer.NewDiskBuffer(1 << 20)
+	require.NoError(t, b.Open(path, false))
+	for i := 0; i < n; i++ {
+		e := entry.New()
+		e.Timestamp = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		e.Record = fmt.Sprintf("message %d", i)
+		require.NoError(t, b.Add(context.Background(), e))
+	}
+	require.NoError(t, b.Close())
+}
+
+func TestBuffer(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	bufferPath := filepath.Join(tempDir, "buffer")
+	require.NoError(t, os.Mkdir(bufferPath, 0755))
+	writeBufferEntries(t, bufferPath, 3)
+
+	// capture stdout
+	buf := bytes.NewBuffer([]byte{})
+	stdout = buf
+
+	inspect := NewRootCmd()
+	inspect.SetArgs([]string{"buffer", "inspect", bufferPath})
+	require.NoError(t, inspect.Execute())
+	require.Contains(t, buf.String(), "entries:             3\n")
+
+	buf.Reset()
+	dump := NewRootCmd()
+	dump.SetArgs([]string{"buffer", "dump", bufferPath})
+	require.NoError(t, dump.Execute())
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	require.Contains(t, lines[0], `"record":"message 0"`)
+	require.Contains(t, lines[2], `"record":"message 2"`)
+
+	// Dumping does not consume the entries
+	buf.Reset()
+	require.NoError(t, inspect.Execute())
+	require.Contains(t, buf.String(), "entries:             3\n")
+
+	purge := NewRootCmd()
+	purge.SetArgs([]string{"buffer", "purge", bufferPath})
+	require.NoError(t, purge.Execute())
+
+	buf.Reset()
+	require.NoError(t, inspect.Execute())
+	require.Contains(t, buf.String(), "entries:             0\n")
+	require.Contains(t, buf.String(), "data size:           0\n")
+}
+
+func TestBufferDrain(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	bufferPath := filepath.Join(tempDir, "buffer")
+	require.NoError(t, os.Mkdir(bufferPath, 0755))
+	writeBufferEntries(t, bufferPath, 3)
+
+	outputPath := filepath.Join(tempDir, "output.log")
+	configPath := filepath.Join(tempDir, "config.yaml")
+	config := fmt.Sprintf("pipeline:\n  - type: file_output\n    id: replay\n    path: %s\n", outputPath)
+	require.NoError(t, ioutil.WriteFile(configPath, []byte(config), 0666))
+
+	buf := bytes.NewBuffer([]byte{})
+	stdout = buf
+
+	drain := NewRootCmd()
+	drain.SetArgs([]string{
+		"buffer", "drain", bufferPath,
+		"--config", configPath,
+		"--database", filepath.Join(tempDir, "stanza.db"),
+		"--to", "replay",
+		"--wait", "0s",
+	})
+	require.NoError(t, drain.Execute())
+	require.Equal(t, "Drained 3 entries into $.replay\n", buf.String())
+
+	contents, err := ioutil.ReadFile(outputPath)
+	require.NoError(t, err)
+	require.Len(t, strings.Split(strings.TrimSpace(string(contents)), "\n"), 3)
+
+	// The drained entries are flushed from the buffer
+	info, err := buffer.InspectDiskBuffer(bufferPath)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), info.EntryCount())
+}
+
+func TestHasMemoryBuffer(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	configPath := filepath.Join(tempDir, "config.yaml")
+	config := fmt.Sprintf(`pipeline:
+  - type: file_output
+    id: file
+    path: %s
+  - type: forward_output
+    id: default_buffer
+    address: http://localhost:1234
+  - type: forward_output
+    id: disk_buffer
+    address: http://localhost:1234
+    buffer:
+      type: disk
+      path: %s
+`, filepath.Join(tempDir, "output.log"), filepath.Join(tempDir, "output_buffer"))
+	require.NoError(t, ioutil.WriteFile(configPath, []byte(config), 0666))
+
+	cfg, err := agent.NewConfigFromGlobs([]string{configPath})
+	require.NoError(t, err)
+
+	expected := map[string]bool{"file": false, "default_buffer": true, "disk_buffer": false}
+	for id, memory := range expected {
+		opCfg, err := findDrainOutput(cfg, id)
+		require.NoError(t, err)
+		require.Equal(t, memory, hasMemoryBuffer(opCfg), id)
+	}
+
+	_, err = findDrainOutput(cfg, "missing")
+	require.Error(t, err)
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"os"
+	"regexp"
 	"runtime"
 	"strings"
 	"sync"
@@ -74,7 +75,9 @@ func TestTomcatExample(t *testing.T) {
 		case <-time.After(100 * time.Millisecond):
 			if len(strings.Split(buf.String(), "\n")) == len(strings.Split(expected, "\n")) {
 				defer cancel()
-				require.Equal(t, expected, buf.String())
+				// Observed timestamps are the time the file was read, so they are checked separately
+				require.Equal(t, len(strings.Split(expected, "\n"))-1, len(observedTimestampRegex.FindAllString(buf.String(), -1)))
+				require.Equal(t, expected, observedTimestampRegex.ReplaceAllString(buf.String(), ""))
 				return
 			}
 		case <-timeout:
@@ -83,6 +86,8 @@ func TestTomcatExample(t *testing.T) {
 	}
 }
 
+var observedTimestampRegex = regexp.MustCompile(`"observed_timestamp":"[0-9]{4}-[0-9]{2}-[0-9]{2}T[^"]+Z",`)
+
 func TestSimplePluginsExample(t *testing.T) {
 	if runtime.GOOS == "windows" {
 		t.Skip("Skipping on windows because of service failures")
@@ -111,11 +116,11 @@ func TestSimplePluginsExample(t *testing.T) {
 	}()
 	defer func() { <-done }()
 
-	expected := `{"timestamp":"2006-01-02T15:04:05Z","severity":0,"labels":{"decorated":"my_decorated_value"},"record":"test record"}
-{"timestamp":"2006-01-02T15:04:05Z","severity":0,"labels":{"decorated":"my_decorated_value"},"record":"test record"}
-{"timestamp":"2006-01-02T15:04:05Z","severity":0,"labels":{"decorated":"my_decorated_value"},"record":"test record"}
-{"timestamp":"2006-01-02T15:04:05Z","severity":0,"labels":{"decorated":"my_decorated_value"},"record":"test record"}
-{"timestamp":"2006-01-02T15:04:05Z","severity":0,"labels":{"decorated":"my_decorated_value"},"record":"test record"}
+	expected := `{"timestamp":"2006-01-02T15:04:05Z","observed_timestamp":"2006-01-02T15:04:05Z","severity":0,"labels":{"decorated":"my_decorated_value"},"record":"test record"}
+{"timestamp":"2006-01-02T15:04:05Z","observed_timestamp":"2006-01-02T15:04:05Z","severity":0,"labels":{"decorated":"my_decorated_value"},"record":"test record"}
+{"timestamp":"2006-01-02T15:04:05Z","observed_timestamp":"2006-01-02T15:04:05Z","severity":0,"labels":{"decorated":"my_decorated_value"},"record":"test record"}
+{"timestamp":"2006-01-02T15:04:05Z","observed_timestamp":"2006-01-02T15:04:05Z","severity":0,"labels":{"decorated":"my_decorated_value"},"record":"test record"}
+{"timestamp":"2006-01-02T15:04:05Z","observed_timestamp":"2006-01-02T15:04:05Z","severity":0,"labels":{"decorated":"my_decorated_value"},"record":"test record"}
 `
 
 	timeout := time.After(5 * time.Second)
@@ -31,6 +31,8 @@ type RootFlags struct {
 	MemProfile         string
 	MemProfileDelay    time.Duration
 
+	StatusInterval time.Duration
+
 	LogFile string
 	Debug   bool
 }
@@ -53,6 +55,7 @@ func NewRootCmd() *cobra.Command {
 	rootFlagSet.StringVar(&rootFlags.PluginDir, "plugin_dir", defaultPluginDir(), "path to the plugin directory")
 	rootFlagSet.StringVar(&rootFlags.DatabaseFile, "database", "", "path to the stanza offset database")
 	rootFlagSet.BoolVar(&rootFlags.Debug, "debug", false, "debug logging")
+	rootFlagSet.DurationVar(&rootFlags.StatusInterval, "status_interval", 0, "interval to log the status of operators, such as outputs' circuit breakers. 0 disables it")
 
 	// Profiling flags
 	rootFlagSet.IntVar(&rootFlags.PprofPort, "pprof_port", 0, "listen port for pprof profiling")
@@ -74,6 +77,7 @@ func NewRootCmd() *cobra.Command {
 	root.AddCommand(NewGraphCommand(rootFlags))
 	root.AddCommand(NewVersionCommand())
 	root.AddCommand(NewOffsetsCmd(rootFlags))
+	root.AddCommand(NewBufferCmd(rootFlags))
 
 	return root
 }
@@ -93,6 +97,7 @@ func runRoot(command *cobra.Command, _ []string, flags *RootFlags) {
 		WithConfigFiles(flags.ConfigFiles).
 		WithPluginDir(flags.PluginDir).
 		WithDatabaseFile(flags.DatabaseFile).
+		WithStatusInterval(flags.StatusInterval).
 		Build()
 	if err != nil {
 		logger.Errorw("Failed to build agent", zap.Any("error", err))
@@ -0,0 +1,107 @@
+package entry
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// NormalizeAttribute checks that a value can be used as a label or resource value,
+// and returns it in a standard form. Strings, booleans and numbers are returned as
+// they are. Slices and maps are converted to []interface{} and map[string]interface{}
+// with normalized values, so maps decoded from YAML are also supported.
+func NormalizeAttribute(val interface{}) (interface{}, error) {
+	switch typed := val.(type) {
+	case string, bool,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64,
+		float32, float64:
+		return typed, nil
+	case []byte:
+		return string(typed), nil
+	case []string:
+		arr := make([]interface{}, 0, len(typed))
+		for _, v := range typed {
+			arr = append(arr, v)
+		}
+		return arr, nil
+	case []int:
+		arr := make([]interface{}, 0, len(typed))
+		for _, v := range typed {
+			arr = append(arr, v)
+		}
+		return arr, nil
+	case []interface{}:
+		arr := make([]interface{}, 0, len(typed))
+		for i, v := range typed {
+			normalized, err := NormalizeAttribute(v)
+			if err != nil {
+				return nil, fmt.Errorf("index %d: %s", i, err)
+			}
+			arr = append(arr, normalized)
+		}
+		return arr, nil
+	case map[string]string:
+		m := make(map[string]interface{}, len(typed))
+		for k, v := range typed {
+			m[k] = v
+		}
+		return m, nil
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(typed))
+		for k, v := range typed {
+			normalized, err := NormalizeAttribute(v)
+			if err != nil {
+				return nil, fmt.Errorf("key '%s': %s", k, err)
+			}
+			m[k] = normalized
+		}
+		return m, nil
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(typed))
+		for k, v := range typed {
+			key, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("map key of type '%T' is not a string", k)
+			}
+			normalized, err := NormalizeAttribute(v)
+			if err != nil {
+				return nil, fmt.Errorf("key '%s': %s", key, err)
+			}
+			m[key] = normalized
+		}
+		return m, nil
+	default:
+		return nil, fmt.Errorf("value of type '%T' is not supported", val)
+	}
+}
+
+// normalizeAttributes normalizes every value of a label or resource map
+func normalizeAttributes(m map[string]interface{}) (map[string]interface{}, error) {
+	if m == nil {
+		return nil, nil
+	}
+
+	normalized := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		val, err := NormalizeAttribute(v)
+		if err != nil {
+			return nil, fmt.Errorf("key '%s': %s", k, err)
+		}
+		normalized[k] = val
+	}
+	return normalized, nil
+}
+
+// AttributeString returns the string form of a label or resource value, for
+// destinations that only support string values. Slices and maps are encoded as JSON.
+func AttributeString(val interface{}) string {
+	switch typed := val.(type) {
+	case string:
+		return typed
+	case []interface{}, map[string]interface{}:
+		if b, err := json.Marshal(typed); err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprintf("%v", val)
+}
@@ -0,0 +1,59 @@
+package entry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeAttribute(t *testing.T) {
+	cases := []struct {
+		name        string
+		input       interface{}
+		expected    interface{}
+		expectedErr bool
+	}{
+		{"String", "val", "val", false},
+		{"Bool", true, true, false},
+		{"Int", 200, 200, false},
+		{"Int64", int64(200), int64(200), false},
+		{"Float", 1.5, 1.5, false},
+		{"Bytes", []byte("val"), "val", false},
+		{"StringSlice", []string{"a", "b"}, []interface{}{"a", "b"}, false},
+		{"IntSlice", []int{1, 2}, []interface{}{1, 2}, false},
+		{"InterfaceSlice", []interface{}{"a", 1, true}, []interface{}{"a", 1, true}, false},
+		{"StringMap", map[string]string{"a": "b"}, map[string]interface{}{"a": "b"}, false},
+		{
+			"NestedMap",
+			map[string]interface{}{"a": map[interface{}]interface{}{"b": 1}},
+			map[string]interface{}{"a": map[string]interface{}{"b": 1}},
+			false,
+		},
+		{"YAMLMap", map[interface{}]interface{}{"a": "b"}, map[string]interface{}{"a": "b"}, false},
+		{"NonStringKey", map[interface{}]interface{}{1: "b"}, nil, true},
+		{"Nil", nil, nil, true},
+		{"Struct", struct{}{}, nil, true},
+		{"NestedStruct", map[string]interface{}{"a": struct{}{}}, nil, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			val, err := NormalizeAttribute(tc.input)
+			if tc.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, val)
+		})
+	}
+}
+
+func TestAttributeString(t *testing.T) {
+	require.Equal(t, "val", AttributeString("val"))
+	require.Equal(t, "200", AttributeString(200))
+	require.Equal(t, "true", AttributeString(true))
+	require.Equal(t, "1.5", AttributeString(1.5))
+	require.Equal(t, `["a",1]`, AttributeString([]interface{}{"a", 1}))
+	require.Equal(t, `{"a":"b"}`, AttributeString(map[string]interface{}{"a": "b"}))
+}
@@ -0,0 +1,559 @@
+package entry
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// The binary entry format is a compact alternative to JSON used to store and send entries.
+//
+// An encoded entry is laid out as follows:
+// - 1 byte BinaryMagic
+// - 1 byte format version
+// - uvarint length of the rest of the entry
+// - timestamp and observed timestamp, as length-prefixed time.Time binary encodings
+// - varint severity
+// - length-prefixed severity text
+// - labels and resource, as maps of values
+// - record, as a value
+// - length-prefixed trace ID, span ID and trace flags
+//
+// Values are a one byte type tag followed by the contents of the value. Values with
+// types that the format doesn't know are stored as JSON.
+const (
+	// BinaryMagic is the first byte of an entry in the binary entry format. It can't
+	// be the first byte of an entry encoded as JSON.
+	BinaryMagic byte = 0xb5
+
+	// BinaryVersion is the version of the binary entry format that is written
+	BinaryVersion byte = 1
+
+	// BinaryContentType is the media type of a sequence of entries in the binary entry format
+	BinaryContentType = "application/x-stanza-entries"
+
+	// MaxBinaryEntrySize is the largest encoded entry that is written or read, not counting
+	// the magic byte, version and length. Longer lengths are rejected instead of being allocated.
+	MaxBinaryEntrySize = 64 << 20
+)
+
+// UnsupportedVersionError is returned when decoding an entry written by a newer
+// version of the binary entry format
+type UnsupportedVersionError struct {
+	Version byte
+}
+
+func (e UnsupportedVersionError) Error() string {
+	return fmt.Sprintf("unsupported binary entry version %d", e.Version)
+}
+
+const (
+	tagNil byte = iota
+	tagFalse
+	tagTrue
+	tagString
+	tagBytes
+	tagInt
+	tagUint
+	tagFloat
+	tagArray
+	tagMap
+	tagStringMap
+	tagJSON
+)
+
+// IsBinary returns whether data starts with an entry in the binary entry format
+func IsBinary(data []byte) bool {
+	return len(data) > 0 && data[0] == BinaryMagic
+}
+
+// MarshalBinary encodes the entry in the binary entry format
+func (entry *Entry) MarshalBinary() ([]byte, error) {
+	return entry.AppendBinary(nil)
+}
+
+// AppendBinary appends the entry in the binary entry format to b
+func (entry *Entry) AppendBinary(b []byte) ([]byte, error) {
+	body, err := entry.appendBinaryBody(make([]byte, 0, 128))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > MaxBinaryEntrySize {
+		return nil, fmt.Errorf("entry size %d exceeds the maximum of %d", len(body), MaxBinaryEntrySize)
+	}
+
+	b = append(b, BinaryMagic, BinaryVersion)
+	b = appendUvarint(b, uint64(len(body)))
+	return append(b, body...), nil
+}
+
+func (entry *Entry) appendBinaryBody(b []byte) ([]byte, error) {
+	var err error
+	if b, err = appendTime(b, entry.Timestamp); err != nil {
+		return nil, err
+	}
+	if b, err = appendTime(b, entry.ObservedTimestamp); err != nil {
+		return nil, err
+	}
+
+	b = appendVarint(b, int64(entry.Severity))
+	b = appendString(b, entry.SeverityText)
+
+	if b, err = appendMap(b, entry.Labels); err != nil {
+		return nil, fmt.Errorf("labels: %s", err)
+	}
+	if b, err = appendMap(b, entry.Resource); err != nil {
+		return nil, fmt.Errorf("resource: %s", err)
+	}
+	if b, err = appendValue(b, entry.Record); err != nil {
+		return nil, fmt.Errorf("record: %s", err)
+	}
+
+	b = appendBytes(b, entry.TraceID)
+	b = appendBytes(b, entry.SpanID)
+	b = appendBytes(b, entry.TraceFlags)
+	return b, nil
+}
+
+// UnmarshalBinary decodes an entry from the binary entry format
+func (entry *Entry) UnmarshalBinary(data []byte) error {
+	if !IsBinary(data) {
+		return fmt.Errorf("data is not in the binary entry format")
+	}
+	if len(data) < 2 {
+		return io.ErrUnexpectedEOF
+	}
+	if data[1] > BinaryVersion {
+		return UnsupportedVersionError{data[1]}
+	}
+
+	length, n := binary.Uvarint(data[2:])
+	if n <= 0 {
+		return fmt.Errorf("invalid entry length")
+	}
+	if length > MaxBinaryEntrySize {
+		return fmt.Errorf("entry length %d exceeds the maximum of %d", length, MaxBinaryEntrySize)
+	}
+	body := data[2+n:]
+	if uint64(len(body)) < length {
+		return io.ErrUnexpectedEOF
+	}
+
+	d := binaryDecoder{data: body[:length]}
+	return d.entry(entry)
+}
+
+// ReadBinary reads a single entry in the binary entry format from r. It returns
+// the entry and the number of bytes read.
+func ReadBinary(r *bufio.Reader) (*Entry, int64, error) {
+	header, err := r.Peek(2)
+	if err != nil {
+		return nil, 0, err
+	}
+	if header[0] != BinaryMagic {
+		return nil, 0, fmt.Errorf("data is not in the binary entry format")
+	}
+	if header[1] > BinaryVersion {
+		return nil, 0, UnsupportedVersionError{header[1]}
+	}
+	if _, err := r.Discard(2); err != nil {
+		return nil, 0, err
+	}
+
+	length, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, 0, fmt.Errorf("read entry length: %s", err)
+	}
+
+	if length > MaxBinaryEntrySize {
+		return nil, 0, fmt.Errorf("entry length %d exceeds the maximum of %d", length, MaxBinaryEntrySize)
+	}
+
+	// The buffer grows as the body is read, so a length that is longer than the rest of
+	// the stream fails without allocating all of it
+	var body bytes.Buffer
+	if _, err := io.CopyN(&body, r, int64(length)); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, 0, err
+	}
+
+	var e Entry
+	d := binaryDecoder{data: body.Bytes()}
+	if err := d.entry(&e); err != nil {
+		return nil, 0, err
+	}
+	return &e, int64(2+uvarintLen(length)) + int64(length), nil
+}
+
+/***********
+  Encoding
+***********/
+
+func appendUvarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	return append(b, buf[:n]...)
+}
+
+func appendVarint(b []byte, v int64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(buf[:], v)
+	return append(b, buf[:n]...)
+}
+
+func appendString(b []byte, s string) []byte {
+	b = appendUvarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+func appendBytes(b []byte, v []byte) []byte {
+	b = appendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+func appendTime(b []byte, t time.Time) ([]byte, error) {
+	if t.IsZero() {
+		return appendUvarint(b, 0), nil
+	}
+
+	encoded, err := t.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return appendBytes(b, encoded), nil
+}
+
+func appendMap(b []byte, m map[string]interface{}) ([]byte, error) {
+	if m == nil {
+		return append(b, tagNil), nil
+	}
+	return appendValue(b, m)
+}
+
+func appendValue(b []byte, v interface{}) ([]byte, error) {
+	var err error
+	switch typed := v.(type) {
+	case nil:
+		return append(b, tagNil), nil
+	case bool:
+		if typed {
+			return append(b, tagTrue), nil
+		}
+		return append(b, tagFalse), nil
+	case string:
+		return appendString(append(b, tagString), typed), nil
+	case []byte:
+		return appendBytes(append(b, tagBytes), typed), nil
+	case int:
+		return appendVarint(append(b, tagInt), int64(typed)), nil
+	case int8:
+		return appendVarint(append(b, tagInt), int64(typed)), nil
+	case int16:
+		return appendVarint(append(b, tagInt), int64(typed)), nil
+	case int32:
+		return appendVarint(append(b, tagInt), int64(typed)), nil
+	case int64:
+		return appendVarint(append(b, tagInt), typed), nil
+	case uint:
+		return appendUvarint(append(b, tagUint), uint64(typed)), nil
+	case uint8:
+		return appendUvarint(append(b, tagUint), uint64(typed)), nil
+	case uint16:
+		return appendUvarint(append(b, tagUint), uint64(typed)), nil
+	case uint32:
+		return appendUvarint(append(b, tagUint), uint64(typed)), nil
+	case uint64:
+		return appendUvarint(append(b, tagUint), typed), nil
+	case float32:
+		return appendFloat(append(b, tagFloat), float64(typed)), nil
+	case float64:
+		return appendFloat(append(b, tagFloat), typed), nil
+	case []interface{}:
+		b = appendUvarint(append(b, tagArray), uint64(len(typed)))
+		for _, element := range typed {
+			if b, err = appendValue(b, element); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case []string:
+		b = appendUvarint(append(b, tagArray), uint64(len(typed)))
+		for _, element := range typed {
+			b = appendString(append(b, tagString), element)
+		}
+		return b, nil
+	case map[string]interface{}:
+		b = appendUvarint(append(b, tagMap), uint64(len(typed)))
+		for k, element := range typed {
+			b = appendString(b, k)
+			if b, err = appendValue(b, element); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case map[string]string:
+		b = appendUvarint(append(b, tagStringMap), uint64(len(typed)))
+		for k, element := range typed {
+			b = appendString(b, k)
+			b = appendString(b, element)
+		}
+		return b, nil
+	default:
+		encoded, err := json.Marshal(typed)
+		if err != nil {
+			return nil, err
+		}
+		return appendBytes(append(b, tagJSON), encoded), nil
+	}
+}
+
+func appendFloat(b []byte, f float64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], math.Float64bits(f))
+	return append(b, buf[:]...)
+}
+
+func uvarintLen(v uint64) int {
+	n := 1
+	for v >= 0x80 {
+		v >>= 7
+		n++
+	}
+	return n
+}
+
+/***********
+  Decoding
+***********/
+
+// binaryDecoder decodes the body of an entry in the binary entry format
+type binaryDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *binaryDecoder) entry(e *Entry) error {
+	var err error
+	if e.Timestamp, err = d.time(); err != nil {
+		return fmt.Errorf("timestamp: %s", err)
+	}
+	if e.ObservedTimestamp, err = d.time(); err != nil {
+		return fmt.Errorf("observed timestamp: %s", err)
+	}
+
+	severity, err := d.varint()
+	if err != nil {
+		return fmt.Errorf("severity: %s", err)
+	}
+	e.Severity = Severity(severity)
+
+	if e.SeverityText, err = d.string(); err != nil {
+		return fmt.Errorf("severity text: %s", err)
+	}
+	if e.Labels, err = d.attributeMap(); err != nil {
+		return fmt.Errorf("labels: %s", err)
+	}
+	if e.Resource, err = d.attributeMap(); err != nil {
+		return fmt.Errorf("resource: %s", err)
+	}
+	if e.Record, err = d.value(); err != nil {
+		return fmt.Errorf("record: %s", err)
+	}
+
+	var b []byte
+	if b, err = d.bytes(); err != nil {
+		return fmt.Errorf("trace id: %s", err)
+	}
+	e.TraceID = TraceID(b)
+	if b, err = d.bytes(); err != nil {
+		return fmt.Errorf("span id: %s", err)
+	}
+	e.SpanID = SpanID(b)
+	if b, err = d.bytes(); err != nil {
+		return fmt.Errorf("trace flags: %s", err)
+	}
+	e.TraceFlags = TraceFlags(b)
+
+	// Fields added in later versions are appended, so any remaining data is ignored
+	return nil
+}
+
+func (d *binaryDecoder) uvarint() (uint64, error) {
+	v, n := binary.Uvarint(d.data[d.pos:])
+	if n <= 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	d.pos += n
+	return v, nil
+}
+
+func (d *binaryDecoder) varint() (int64, error) {
+	v, n := binary.Varint(d.data[d.pos:])
+	if n <= 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	d.pos += n
+	return v, nil
+}
+
+func (d *binaryDecoder) next(n uint64) ([]byte, error) {
+	if uint64(len(d.data)-d.pos) < n {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := d.data[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+	return b, nil
+}
+
+func (d *binaryDecoder) string() (string, error) {
+	length, err := d.uvarint()
+	if err != nil {
+		return "", err
+	}
+	b, err := d.next(length)
+	return string(b), err
+}
+
+// bytes returns a copy of a length-prefixed byte slice, or nil if it is empty
+func (d *binaryDecoder) bytes() ([]byte, error) {
+	length, err := d.uvarint()
+	if err != nil || length == 0 {
+		return nil, err
+	}
+	b, err := d.next(length)
+	if err != nil {
+		return nil, err
+	}
+	return copyByteArray(b), nil
+}
+
+func (d *binaryDecoder) time() (time.Time, error) {
+	b, err := d.bytes()
+	if err != nil || b == nil {
+		return time.Time{}, err
+	}
+
+	var t time.Time
+	err = t.UnmarshalBinary(b)
+	return t, err
+}
+
+func (d *binaryDecoder) attributeMap() (map[string]interface{}, error) {
+	v, err := d.value()
+	if err != nil || v == nil {
+		return nil, err
+	}
+
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected a map, got %T", v)
+	}
+	return m, nil
+}
+
+func (d *binaryDecoder) value() (interface{}, error) {
+	tag, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+
+	switch tag[0] {
+	case tagNil:
+		return nil, nil
+	case tagFalse:
+		return false, nil
+	case tagTrue:
+		return true, nil
+	case tagString:
+		return d.string()
+	case tagBytes:
+		b, err := d.bytes()
+		if b == nil && err == nil {
+			b = []byte{}
+		}
+		return b, err
+	case tagInt:
+		return d.varint()
+	case tagUint:
+		return d.uvarint()
+	case tagFloat:
+		b, err := d.next(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b)), nil
+	case tagArray:
+		length, err := d.uvarint()
+		if err != nil {
+			return nil, err
+		}
+		array := make([]interface{}, 0, d.capacity(length))
+		for i := uint64(0); i < length; i++ {
+			element, err := d.value()
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, element)
+		}
+		return array, nil
+	case tagMap:
+		length, err := d.uvarint()
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[string]interface{}, d.capacity(length))
+		for i := uint64(0); i < length; i++ {
+			k, err := d.string()
+			if err != nil {
+				return nil, err
+			}
+			if m[k], err = d.value(); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	case tagStringMap:
+		length, err := d.uvarint()
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[string]string, d.capacity(length))
+		for i := uint64(0); i < length; i++ {
+			k, err := d.string()
+			if err != nil {
+				return nil, err
+			}
+			if m[k], err = d.string(); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	case tagJSON:
+		b, err := d.bytes()
+		if err != nil {
+			return nil, err
+		}
+		var v interface{}
+		err = json.Unmarshal(b, &v)
+		return v, err
+	default:
+		return nil, fmt.Errorf("unknown value type %d", tag[0])
+	}
+}
+
+// capacity limits the capacity allocated for a collection to the bytes remaining,
+// so that corrupt lengths can't cause huge allocations
+func (d *binaryDecoder) capacity(length uint64) int {
+	remaining := uint64(len(d.data) - d.pos)
+	if length > remaining {
+		return int(remaining)
+	}
+	return int(length)
+}
@@ -0,0 +1,252 @@
+package entry
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newBinaryTestEntry() *Entry {
+	return &Entry{
+		Timestamp:         time.Date(2020, 6, 1, 20, 21, 5, 123456789, time.UTC),
+		ObservedTimestamp: time.Date(2020, 6, 1, 20, 21, 6, 0, time.UTC),
+		Severity:          Error,
+		SeverityText:      "ERR",
+		Labels: map[string]interface{}{
+			"label":  "value",
+			"code":   int64(200),
+			"sample": 0.5,
+		},
+		Resource: map[string]interface{}{
+			"host.name": "test",
+		},
+		Record: map[string]interface{}{
+			"message": "test message",
+			"bytes":   []byte("raw"),
+			"true":    true,
+			"false":   false,
+			"nil":     nil,
+			"int":     int64(-42),
+			"uint":    uint64(42),
+			"float":   3.25,
+			"list":    []interface{}{"a", int64(1), nil},
+			"nested": map[string]interface{}{
+				"key": "value",
+			},
+			"strings": map[string]string{
+				"key": "value",
+			},
+		},
+		TraceID:    TraceID{0x48, 0x01, 0x40, 0xf3, 0xd7, 0x70, 0xa5, 0xae, 0x32, 0xf0, 0xa2, 0x2b, 0x6a, 0x81, 0x2c, 0xff},
+		SpanID:     SpanID{0x32, 0xf0, 0xa2, 0x2b, 0x6a, 0x81, 0x2c, 0xff},
+		TraceFlags: TraceFlags{0x01},
+	}
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	cases := []struct {
+		name     string
+		entry    *Entry
+		expected *Entry
+	}{
+		{
+			"Full",
+			newBinaryTestEntry(),
+			newBinaryTestEntry(),
+		},
+		{
+			"Empty",
+			&Entry{},
+			&Entry{},
+		},
+		{
+			"StringRecord",
+			&Entry{Record: "test"},
+			&Entry{Record: "test"},
+		},
+		{
+			"EmptyMaps",
+			&Entry{Labels: map[string]interface{}{}, Record: map[string]interface{}{}},
+			&Entry{Labels: map[string]interface{}{}, Record: map[string]interface{}{}},
+		},
+		{
+			"NarrowNumbers",
+			&Entry{Record: []interface{}{1, int32(-2), uint8(3), float32(1.5)}},
+			&Entry{Record: []interface{}{int64(1), int64(-2), uint64(3), 1.5}},
+		},
+		{
+			"StringSlice",
+			&Entry{Record: []string{"a", "b"}},
+			&Entry{Record: []interface{}{"a", "b"}},
+		},
+		{
+			"UnknownType",
+			&Entry{Record: struct {
+				Name string `json:"name"`
+			}{"test"}},
+			&Entry{Record: map[string]interface{}{"name": "test"}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := tc.entry.MarshalBinary()
+			require.NoError(t, err)
+			require.True(t, IsBinary(data))
+
+			var decoded Entry
+			require.NoError(t, decoded.UnmarshalBinary(data))
+			require.Equal(t, tc.expected, &decoded)
+		})
+	}
+}
+
+func TestBinaryTimestampLocation(t *testing.T) {
+	location := time.FixedZone("test", -5*60*60)
+	e := &Entry{Timestamp: time.Date(2020, 6, 1, 20, 21, 5, 0, location)}
+
+	data, err := e.MarshalBinary()
+	require.NoError(t, err)
+
+	var decoded Entry
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	require.True(t, e.Timestamp.Equal(decoded.Timestamp))
+	_, offset := decoded.Timestamp.Zone()
+	require.Equal(t, -5*60*60, offset)
+}
+
+func TestUnmarshalBinaryErrors(t *testing.T) {
+	data, err := newBinaryTestEntry().MarshalBinary()
+	require.NoError(t, err)
+
+	t.Run("NotBinary", func(t *testing.T) {
+		var e Entry
+		require.Error(t, e.UnmarshalBinary([]byte(`{"record":"test"}`)))
+	})
+
+	t.Run("NewerVersion", func(t *testing.T) {
+		newer := append([]byte{}, data...)
+		newer[1] = BinaryVersion + 1
+
+		var e Entry
+		err := e.UnmarshalBinary(newer)
+		require.Equal(t, UnsupportedVersionError{BinaryVersion + 1}, err)
+	})
+
+	t.Run("Truncated", func(t *testing.T) {
+		for i := 1; i < len(data); i++ {
+			var e Entry
+			require.Error(t, e.UnmarshalBinary(data[:i]), "length %d", i)
+		}
+	})
+}
+
+func TestReadBinary(t *testing.T) {
+	first := newBinaryTestEntry()
+	second := &Entry{Record: "second"}
+
+	data, err := first.MarshalBinary()
+	require.NoError(t, err)
+	data, err = second.AppendBinary(data)
+	require.NoError(t, err)
+
+	r := bufio.NewReader(bytes.NewReader(data))
+	e, n1, err := ReadBinary(r)
+	require.NoError(t, err)
+	require.Equal(t, first, e)
+
+	e, n2, err := ReadBinary(r)
+	require.NoError(t, err)
+	require.Equal(t, second, e)
+	require.Equal(t, int64(len(data)), n1+n2)
+
+	_, _, err = ReadBinary(r)
+	require.Equal(t, io.EOF, err)
+}
+
+func TestReadBinaryTruncated(t *testing.T) {
+	data, err := newBinaryTestEntry().MarshalBinary()
+	require.NoError(t, err)
+
+	r := bufio.NewReader(bytes.NewReader(data[:len(data)-1]))
+	_, _, err = ReadBinary(r)
+	require.Error(t, err)
+}
+
+func TestReadBinaryMaliciousLength(t *testing.T) {
+	cases := []struct {
+		name   string
+		length uint64
+	}{
+		{"OverMax", MaxBinaryEntrySize + 1},
+		{"MaxUint64", math.MaxUint64},
+		{"LongerThanStream", MaxBinaryEntrySize},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			data := appendUvarint([]byte{BinaryMagic, BinaryVersion}, tc.length)
+			data = append(data, "short"...)
+
+			_, _, err := ReadBinary(bufio.NewReader(bytes.NewReader(data)))
+			require.Error(t, err)
+
+			var e Entry
+			require.Error(t, e.UnmarshalBinary(data))
+		})
+	}
+}
+
+func BenchmarkEntryCodec(b *testing.B) {
+	e := newBinaryTestEntry()
+	binaryData, err := e.MarshalBinary()
+	require.NoError(b, err)
+	jsonData, err := json.Marshal(e)
+	require.NoError(b, err)
+
+	b.Run("MarshalJSON", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := json.Marshal(e); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("MarshalBinary", func(b *testing.B) {
+		b.ReportAllocs()
+		var buf []byte
+		for i := 0; i < b.N; i++ {
+			var err error
+			if buf, err = e.AppendBinary(buf[:0]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("UnmarshalJSON", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var decoded Entry
+			if err := json.Unmarshal(jsonData, &decoded); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("UnmarshalBinary", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var decoded Entry
+			if err := decoded.UnmarshalBinary(binaryData); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
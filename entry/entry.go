@@ -1,6 +1,7 @@
 package entry
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"time"
@@ -27,15 +28,72 @@ func getNow() func() time.Time {
 var now = getNow()
 
 // Entry is a flexible representation of log data associated with a timestamp.
+//
+// Timestamp is the time of the event described by the log, and is often replaced by
+// parsers. ObservedTimestamp is the time the log was read by an input operator, and
+// is not changed after that.
 type Entry struct {
-	Timestamp    time.Time         `json:"timestamp"               yaml:"timestamp"`
-	Severity     Severity          `json:"severity"                yaml:"severity"`
-	SeverityText string            `json:"severity_text,omitempty" yaml:"severity_text,omitempty"`
-	Labels       map[string]string `json:"labels,omitempty"        yaml:"labels,omitempty"`
-	Resource     map[string]string `json:"resource,omitempty"      yaml:"resource,omitempty"`
-	Record       interface{}       `json:"record"                  yaml:"record"`
+	Timestamp         time.Time              `json:"timestamp"               yaml:"timestamp"`
+	ObservedTimestamp time.Time              `json:"observed_timestamp"      yaml:"observed_timestamp,omitempty"`
+	Severity          Severity               `json:"severity"                yaml:"severity"`
+	SeverityText      string                 `json:"severity_text,omitempty" yaml:"severity_text,omitempty"`
+	Labels            map[string]interface{} `json:"labels,omitempty"        yaml:"labels,omitempty"`
+	Resource          map[string]interface{} `json:"resource,omitempty"      yaml:"resource,omitempty"`
+	Record            interface{}            `json:"record"                  yaml:"record"`
+	TraceID           TraceID                `json:"trace_id,omitempty"      yaml:"trace_id,omitempty"`
+	SpanID            SpanID                 `json:"span_id,omitempty"       yaml:"span_id,omitempty"`
+	TraceFlags        TraceFlags             `json:"trace_flags,omitempty"   yaml:"trace_flags,omitempty"`
+
+	// shared marks the parts of the entry that may be shared with other entries
+	shared sharedParts
+}
+
+// entryJSON is the JSON encoding of an entry. It leaves out a zero observed timestamp,
+// which encoding/json can't omit from a time.Time field.
+type entryJSON struct {
+	Timestamp         time.Time              `json:"timestamp"`
+	ObservedTimestamp *time.Time             `json:"observed_timestamp,omitempty"`
+	Severity          Severity               `json:"severity"`
+	SeverityText      string                 `json:"severity_text,omitempty"`
+	Labels            map[string]interface{} `json:"labels,omitempty"`
+	Resource          map[string]interface{} `json:"resource,omitempty"`
+	Record            interface{}            `json:"record"`
+	TraceID           TraceID                `json:"trace_id,omitempty"`
+	SpanID            SpanID                 `json:"span_id,omitempty"`
+	TraceFlags        TraceFlags             `json:"trace_flags,omitempty"`
+}
+
+// MarshalJSON will marshal the entry as JSON, leaving out the observed timestamp if it is not set
+func (entry Entry) MarshalJSON() ([]byte, error) {
+	encoded := entryJSON{
+		Timestamp:    entry.Timestamp,
+		Severity:     entry.Severity,
+		SeverityText: entry.SeverityText,
+		Labels:       entry.Labels,
+		Resource:     entry.Resource,
+		Record:       entry.Record,
+		TraceID:      entry.TraceID,
+		SpanID:       entry.SpanID,
+		TraceFlags:   entry.TraceFlags,
+	}
+	if !entry.ObservedTimestamp.IsZero() {
+		encoded.ObservedTimestamp = &entry.ObservedTimestamp
+	}
+	return json.Marshal(encoded)
 }
 
+// sharedParts is a set of the parts of an entry that are shared with other entries,
+// and must be copied before they are modified
+type sharedParts uint8
+
+const (
+	sharedLabels sharedParts = 1 << iota
+	sharedResource
+	sharedRecord
+
+	sharedAll = sharedLabels | sharedResource | sharedRecord
+)
+
 // New will create a new log entry with current timestamp and an empty record.
 func New() *Entry {
 	return &Entry{
@@ -43,22 +101,50 @@ func New() *Entry {
 	}
 }
 
-// AddLabel will add a key/value pair to the entry's labels.
-func (entry *Entry) AddLabel(key, value string) {
+// AddLabel will add a key/value pair to the entry's labels. The value should be
+// a string, bool, number, or a slice or map of those.
+func (entry *Entry) AddLabel(key string, value interface{}) {
+	entry.ownLabels()
 	if entry.Labels == nil {
-		entry.Labels = make(map[string]string)
+		entry.Labels = make(map[string]interface{})
 	}
 	entry.Labels[key] = value
 }
 
-// AddResourceKey wil add a key/value pair to the entry's resource.
-func (entry *Entry) AddResourceKey(key, value string) {
+// AddResourceKey wil add a key/value pair to the entry's resource. The value should be
+// a string, bool, number, or a slice or map of those.
+func (entry *Entry) AddResourceKey(key string, value interface{}) {
+	entry.ownResource()
 	if entry.Resource == nil {
-		entry.Resource = make(map[string]string)
+		entry.Resource = make(map[string]interface{})
 	}
 	entry.Resource[key] = value
 }
 
+// UnmarshalYAML will unmarshal an entry from YAML, converting maps nested
+// in its labels and resource to map[string]interface{}.
+func (entry *Entry) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type rawEntry Entry
+	var raw rawEntry
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+
+	labels, err := normalizeAttributes(raw.Labels)
+	if err != nil {
+		return fmt.Errorf("labels: %s", err)
+	}
+	resource, err := normalizeAttributes(raw.Resource)
+	if err != nil {
+		return fmt.Errorf("resource: %s", err)
+	}
+
+	*entry = Entry(raw)
+	entry.Labels = labels
+	entry.Resource = resource
+	return nil
+}
+
 // Get will return the value of a field on the entry, including a boolean indicating if the field exists.
 func (entry *Entry) Get(field FieldInterface) (interface{}, bool) {
 	return field.Get(entry)
@@ -176,11 +262,56 @@ func (entry *Entry) readToStringMap(field FieldInterface, dest *map[string]strin
 // Copy will return a deep copy of the entry.
 func (entry *Entry) Copy() *Entry {
 	return &Entry{
-		Timestamp:    entry.Timestamp,
-		Severity:     entry.Severity,
-		SeverityText: entry.SeverityText,
-		Labels:       copyStringMap(entry.Labels),
-		Resource:     copyStringMap(entry.Resource),
-		Record:       copyValue(entry.Record),
+		Timestamp:         entry.Timestamp,
+		ObservedTimestamp: entry.ObservedTimestamp,
+		Severity:          entry.Severity,
+		SeverityText:      entry.SeverityText,
+		Labels:            copyInterfaceMap(entry.Labels),
+		Resource:          copyInterfaceMap(entry.Resource),
+		Record:            copyValue(entry.Record),
+		TraceID:           copyBytes(entry.TraceID),
+		SpanID:            copyBytes(entry.SpanID),
+		TraceFlags:        copyBytes(entry.TraceFlags),
+	}
+}
+
+// Share returns a new entry that shares the labels, resource and record of the entry.
+// Modifying either entry through its fields, AddLabel or AddResourceKey first copies
+// the part being modified, so changes are never seen by the other entry. This makes
+// Share much cheaper than Copy when an entry is sent to more than one operator.
+//
+// Values read from a shared entry, such as the maps returned by Get, must not be
+// modified in place.
+func (entry *Entry) Share() *Entry {
+	entry.shared = sharedAll
+	shared := *entry
+	return &shared
+}
+
+// ownLabels copies the labels of the entry if they are shared with another entry
+func (entry *Entry) ownLabels() {
+	if entry.shared&sharedLabels != 0 {
+		if entry.Labels != nil {
+			entry.Labels = copyInterfaceMap(entry.Labels)
+		}
+		entry.shared &^= sharedLabels
+	}
+}
+
+// ownResource copies the resource of the entry if it is shared with another entry
+func (entry *Entry) ownResource() {
+	if entry.shared&sharedResource != 0 {
+		if entry.Resource != nil {
+			entry.Resource = copyInterfaceMap(entry.Resource)
+		}
+		entry.shared &^= sharedResource
+	}
+}
+
+// ownRecord copies the record of the entry if it is shared with another entry
+func (entry *Entry) ownRecord() {
+	if entry.shared&sharedRecord != 0 {
+		entry.Record = copyValue(entry.Record)
+		entry.shared &^= sharedRecord
 	}
 }
@@ -1,11 +1,13 @@
 package entry
 
 import (
+	"encoding/json"
 	"os"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
 )
 
 func TestRead(t *testing.T) {
@@ -124,26 +126,181 @@ func TestCopy(t *testing.T) {
 	entry.Severity = Severity(0)
 	entry.SeverityText = "ok"
 	entry.Timestamp = time.Time{}
+	entry.ObservedTimestamp = time.Unix(1591042865, 0)
 	entry.Record = "test"
-	entry.Labels = map[string]string{"label": "value"}
-	entry.Resource = map[string]string{"resource": "value"}
+	entry.Labels = map[string]interface{}{"label": "value"}
+	entry.Resource = map[string]interface{}{"resource": "value"}
 	copy := entry.Copy()
 
 	entry.Severity = Severity(1)
 	entry.SeverityText = "1"
 	entry.Timestamp = time.Now()
 	entry.Record = "new"
-	entry.Labels = map[string]string{"label": "new value"}
-	entry.Resource = map[string]string{"resource": "new value"}
+	entry.Labels = map[string]interface{}{"label": "new value"}
+	entry.Resource = map[string]interface{}{"resource": "new value"}
 
 	require.Equal(t, time.Time{}, copy.Timestamp)
+	require.Equal(t, time.Unix(1591042865, 0), copy.ObservedTimestamp)
 	require.Equal(t, Severity(0), copy.Severity)
 	require.Equal(t, "ok", copy.SeverityText)
-	require.Equal(t, map[string]string{"label": "value"}, copy.Labels)
-	require.Equal(t, map[string]string{"resource": "value"}, copy.Resource)
+	require.Equal(t, map[string]interface{}{"label": "value"}, copy.Labels)
+	require.Equal(t, map[string]interface{}{"resource": "value"}, copy.Resource)
 	require.Equal(t, "test", copy.Record)
 }
 
+func TestShare(t *testing.T) {
+	newEntry := func() *Entry {
+		e := New()
+		e.Labels = map[string]interface{}{"label": "value"}
+		e.Resource = map[string]interface{}{"resource": "value"}
+		e.Record = map[string]interface{}{
+			"message": "test",
+			"nested":  map[string]interface{}{"key": "value"},
+		}
+		return e
+	}
+
+	cases := []struct {
+		name   string
+		modify func(*Entry)
+	}{
+		{
+			"SetRecord",
+			func(e *Entry) { require.NoError(t, e.Set(NewRecordField("nested", "key"), "new")) },
+		},
+		{
+			"MergeRecord",
+			func(e *Entry) {
+				require.NoError(t, e.Set(NewRecordField("nested"), map[string]interface{}{"other": "new"}))
+			},
+		},
+		{
+			"DeleteRecord",
+			func(e *Entry) { e.Delete(NewRecordField("nested", "key")) },
+		},
+		{
+			"SetRecordWildcard",
+			func(e *Entry) {
+				field, err := fieldFromString("nested.*")
+				require.NoError(t, err)
+				require.NoError(t, e.Set(field, "new"))
+			},
+		},
+		{
+			"SetLabel",
+			func(e *Entry) { require.NoError(t, e.Set(NewLabelField("label"), "new")) },
+		},
+		{
+			"DeleteLabel",
+			func(e *Entry) { e.Delete(NewLabelField("label")) },
+		},
+		{
+			"AddLabel",
+			func(e *Entry) { e.AddLabel("other", "new") },
+		},
+		{
+			"SetResource",
+			func(e *Entry) { require.NoError(t, e.Set(NewResourceField("resource"), "new")) },
+		},
+		{
+			"DeleteResource",
+			func(e *Entry) { e.Delete(NewResourceField("resource")) },
+		},
+		{
+			"AddResourceKey",
+			func(e *Entry) { e.AddResourceKey("other", "new") },
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Run("Original", func(t *testing.T) {
+				original := newEntry()
+				shared := original.Share()
+				tc.modify(original)
+				require.Equal(t, newEntry().Labels, shared.Labels)
+				require.Equal(t, newEntry().Resource, shared.Resource)
+				require.Equal(t, newEntry().Record, shared.Record)
+			})
+
+			t.Run("Shared", func(t *testing.T) {
+				original := newEntry()
+				shared := original.Share()
+				tc.modify(shared)
+				require.Equal(t, newEntry().Labels, original.Labels)
+				require.Equal(t, newEntry().Resource, original.Resource)
+				require.Equal(t, newEntry().Record, original.Record)
+			})
+		})
+	}
+}
+
+func TestShareUnmodified(t *testing.T) {
+	e := New()
+	e.Labels = map[string]interface{}{"label": "value"}
+	e.Record = map[string]interface{}{"message": "test"}
+	shared := e.Share()
+
+	// Reading a shared entry does not copy it
+	_, ok := shared.Get(NewRecordField("message"))
+	require.True(t, ok)
+	shared.Labels["check"] = "same map"
+	require.Equal(t, "same map", e.Labels["check"])
+}
+
+func TestCopyTypedAttributes(t *testing.T) {
+	entry := New()
+	entry.Labels = map[string]interface{}{"list": []interface{}{"a"}, "code": int64(200)}
+	copy := entry.Copy()
+
+	entry.Labels["list"].([]interface{})[0] = "b"
+
+	require.Equal(t, map[string]interface{}{"list": []interface{}{"a"}, "code": int64(200)}, copy.Labels)
+}
+
+func TestUnmarshalTypedAttributes(t *testing.T) {
+	expected := map[string]interface{}{
+		"code":  200,
+		"flag":  true,
+		"list":  []interface{}{"a", "b"},
+		"group": map[string]interface{}{"name": "val"},
+	}
+
+	t.Run("YAML", func(t *testing.T) {
+		raw := `
+labels:
+  code: 200
+  flag: true
+  list: [a, b]
+  group:
+    name: val
+resource:
+  host.name: test
+record: test
+`
+		var entry Entry
+		require.NoError(t, yaml.Unmarshal([]byte(raw), &entry))
+		require.Equal(t, expected, entry.Labels)
+		require.Equal(t, map[string]interface{}{"host.name": "test"}, entry.Resource)
+		require.Equal(t, "test", entry.Record)
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		raw := `{"labels":{"code":200,"flag":true,"list":["a","b"],"group":{"name":"val"}}}`
+		var entry Entry
+		require.NoError(t, json.Unmarshal([]byte(raw), &entry))
+		require.Equal(t, 200.0, entry.Labels["code"])
+		require.Equal(t, expected["list"], entry.Labels["list"])
+		require.Equal(t, expected["group"], entry.Labels["group"])
+	})
+
+	t.Run("StringLabels", func(t *testing.T) {
+		var entry Entry
+		require.NoError(t, yaml.Unmarshal([]byte("labels:\n  key: value\n"), &entry))
+		require.Equal(t, map[string]interface{}{"key": "value"}, entry.Labels)
+	})
+}
+
 func TestFieldFromString(t *testing.T) {
 	cases := []struct {
 		name          string
@@ -154,19 +311,19 @@ func TestFieldFromString(t *testing.T) {
 		{
 			"SimpleRecord",
 			"test",
-			Field{RecordField{[]string{"test"}}},
+			Field{RecordField{Keys: []string{"test"}}},
 			false,
 		},
 		{
 			"PrefixedRecord",
 			"$.test",
-			Field{RecordField{[]string{"test"}}},
+			Field{RecordField{Keys: []string{"test"}}},
 			false,
 		},
 		{
 			"FullPrefixedRecord",
 			"$record.test",
-			Field{RecordField{[]string{"test"}}},
+			Field{RecordField{Keys: []string{"test"}}},
 			false,
 		},
 		{
@@ -199,14 +356,14 @@ func TestFieldFromString(t *testing.T) {
 func TestAddLabel(t *testing.T) {
 	entry := Entry{}
 	entry.AddLabel("label", "value")
-	expected := map[string]string{"label": "value"}
+	expected := map[string]interface{}{"label": "value"}
 	require.Equal(t, expected, entry.Labels)
 }
 
 func TestAddResourceKey(t *testing.T) {
 	entry := Entry{}
 	entry.AddResourceKey("key", "value")
-	expected := map[string]string{"key": "value"}
+	expected := map[string]interface{}{"key": "value"}
 	require.Equal(t, expected, entry.Resource)
 }
 
@@ -237,6 +394,41 @@ func TestReadToInterfaceMissingField(t *testing.T) {
 	require.Contains(t, err.Error(), "can not be read as a interface{}")
 }
 
+func BenchmarkFanOut(b *testing.B) {
+	e := New()
+	e.Labels = map[string]interface{}{"label": "value"}
+	e.Record = map[string]interface{}{
+		"message": "a log message",
+		"status":  200,
+		"request": map[string]interface{}{
+			"method": "GET",
+			"path":   "/index.html",
+			"headers": map[string]interface{}{
+				"user_agent": "test",
+				"accept":     "*/*",
+			},
+		},
+	}
+
+	b.Run("Copy", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for j := 0; j < 3; j++ {
+				_ = e.Copy()
+			}
+		}
+	})
+
+	b.Run("Share", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for j := 0; j < 3; j++ {
+				_ = e.Share()
+			}
+		}
+	})
+}
+
 func TestDefaultTimestamp(t *testing.T) {
 	os.Setenv(defaultTimestampEnv, "2019-10-12T07:20:50.52Z")
 	now = getNow()
@@ -248,3 +440,35 @@ func TestDefaultTimestamp(t *testing.T) {
 	require.Equal(t, expected, e.Timestamp)
 	require.True(t, e.Timestamp.Equal(expected))
 }
+
+func TestMarshalObservedTimestamp(t *testing.T) {
+	observed := time.Date(2020, 6, 1, 20, 21, 5, 0, time.UTC)
+
+	t.Run("JSONSet", func(t *testing.T) {
+		entry := &Entry{Timestamp: observed, ObservedTimestamp: observed, Record: "test"}
+		raw, err := json.Marshal(entry)
+		require.NoError(t, err)
+		require.Equal(t, `{"timestamp":"2020-06-01T20:21:05Z","observed_timestamp":"2020-06-01T20:21:05Z","severity":0,"record":"test"}`, string(raw))
+
+		var decoded Entry
+		require.NoError(t, json.Unmarshal(raw, &decoded))
+		require.Equal(t, entry, &decoded)
+	})
+
+	t.Run("JSONZero", func(t *testing.T) {
+		entry := Entry{Timestamp: observed, Record: "test"}
+		raw, err := json.Marshal(entry)
+		require.NoError(t, err)
+		require.Equal(t, `{"timestamp":"2020-06-01T20:21:05Z","severity":0,"record":"test"}`, string(raw))
+
+		var decoded Entry
+		require.NoError(t, json.Unmarshal(raw, &decoded))
+		require.True(t, decoded.ObservedTimestamp.IsZero())
+	})
+
+	t.Run("YAMLZero", func(t *testing.T) {
+		raw, err := yaml.Marshal(&Entry{Timestamp: observed, Record: "test"})
+		require.NoError(t, err)
+		require.NotContains(t, string(raw), "observed_timestamp")
+	})
+}
@@ -3,6 +3,7 @@ package entry
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
 )
 
 const (
@@ -49,11 +50,18 @@ func (f *Field) UnmarshalYAML(unmarshal func(interface{}) error) error {
 }
 
 func fieldFromString(s string) (Field, error) {
-	split, err := splitField(s)
+	split, selectors, err := splitField(s)
 	if err != nil {
 		return Field{}, fmt.Errorf("splitting field: %s", err)
 	}
 
+	if selectors != nil && selectors[0] == selectKey {
+		switch split[0] {
+		case labelsPrefix, resourcePrefix, traceIDField, spanIDField, traceFlagsField:
+			return Field{}, fmt.Errorf("only record fields can use indexes and wildcards")
+		}
+	}
+
 	switch split[0] {
 	case labelsPrefix:
 		if len(split) != 2 {
@@ -65,10 +73,18 @@ func fieldFromString(s string) (Field, error) {
 			return Field{}, fmt.Errorf("resource fields cannot be nested")
 		}
 		return Field{ResourceField{split[1]}}, nil
+	case traceIDField, spanIDField, traceFlagsField:
+		if len(split) != 1 {
+			return Field{}, fmt.Errorf("trace fields cannot be nested")
+		}
+		return Field{TraceField{split[0]}}, nil
 	case recordPrefix, "$":
-		return Field{RecordField{split[1:]}}, nil
+		if selectors != nil {
+			selectors = selectors[1:]
+		}
+		return Field{newRecordField(split[1:], selectors)}, nil
 	default:
-		return Field{RecordField{split}}, nil
+		return Field{newRecordField(split, selectors)}, nil
 	}
 }
 
@@ -97,10 +113,31 @@ const (
 	OutBracket
 	// InUnbracketedToken is the state field split on any token outside brackets
 	InUnbracketedToken
+	// InSelector is the state of a field split inside an unquoted index or wildcard
+	InSelector
 )
 
-func splitField(s string) ([]string, error) {
+// splitField splits a field into its keys. If any of the keys are array indexes or
+// wildcards, it also returns the kind of selector for each key.
+func splitField(s string) ([]string, []selector, error) {
 	fields := make([]string, 0, 1)
+	var selectors []selector
+	addField := func(key string, sel selector) {
+		if sel != selectKey && selectors == nil {
+			selectors = make([]selector, len(fields), len(fields)+1)
+		}
+		fields = append(fields, key)
+		if selectors != nil {
+			selectors = append(selectors, sel)
+		}
+	}
+	addUnbracketed := func(key string) {
+		if key == wildcardKey {
+			addField(key, selectWildcard)
+			return
+		}
+		addField(key, selectKey)
+	}
 
 	state := Begin
 	var quoteChar rune
@@ -116,22 +153,37 @@ func splitField(s string) ([]string, error) {
 			tokenStart = i
 			state = InUnbracketedToken
 		case InBracket:
+			if c == '*' || c == '-' || (c >= '0' && c <= '9') {
+				state = InSelector
+				tokenStart = i
+				continue
+			}
 			if !(c == '\'' || c == '"') {
-				return nil, fmt.Errorf("strings in brackets must be surrounded by quotes")
+				return nil, nil, fmt.Errorf("strings in brackets must be surrounded by quotes")
 			}
 			state = InQuote
 			quoteChar = c
 			tokenStart = i + 1
 		case InQuote:
 			if c == quoteChar {
-				fields = append(fields, s[tokenStart:i])
+				addField(s[tokenStart:i], selectKey)
 				state = OutQuote
 			}
 		case OutQuote:
 			if c != ']' {
-				return nil, fmt.Errorf("found characters between closed quote and closing bracket")
+				return nil, nil, fmt.Errorf("found characters between closed quote and closing bracket")
 			}
 			state = OutBracket
+		case InSelector:
+			if c != ']' {
+				continue
+			}
+			key, sel, err := parseSelector(s[tokenStart:i])
+			if err != nil {
+				return nil, nil, err
+			}
+			addField(key, sel)
+			state = OutBracket
 		case OutBracket:
 			switch c {
 			case '.':
@@ -140,30 +192,43 @@ func splitField(s string) ([]string, error) {
 			case '[':
 				state = InBracket
 			default:
-				return nil, fmt.Errorf("bracketed access must be followed by a dot or another bracketed access")
+				return nil, nil, fmt.Errorf("bracketed access must be followed by a dot or another bracketed access")
 			}
 		case InUnbracketedToken:
 			if c == '.' {
-				fields = append(fields, s[tokenStart:i])
+				addUnbracketed(s[tokenStart:i])
 				tokenStart = i + 1
 			} else if c == '[' {
-				fields = append(fields, s[tokenStart:i])
+				addUnbracketed(s[tokenStart:i])
 				state = InBracket
 			}
 		}
 	}
 
 	switch state {
-	case InBracket, OutQuote:
-		return nil, fmt.Errorf("found unclosed left bracket")
+	case InBracket, OutQuote, InSelector:
+		return nil, nil, fmt.Errorf("found unclosed left bracket")
 	case InQuote:
 		if quoteChar == '"' {
-			return nil, fmt.Errorf("found unclosed double quote")
+			return nil, nil, fmt.Errorf("found unclosed double quote")
 		}
-		return nil, fmt.Errorf("found unclosed single quote")
+		return nil, nil, fmt.Errorf("found unclosed single quote")
 	case InUnbracketedToken:
-		fields = append(fields, s[tokenStart:])
+		addUnbracketed(s[tokenStart:])
 	}
 
-	return fields, nil
+	return fields, selectors, nil
+}
+
+// parseSelector parses the contents of an unquoted bracket as an array index or a wildcard
+func parseSelector(s string) (string, selector, error) {
+	if s == wildcardKey {
+		return s, selectWildcard, nil
+	}
+
+	index, err := strconv.Atoi(s)
+	if err != nil {
+		return "", selectKey, fmt.Errorf("invalid index '%s': brackets must contain a quoted key, an integer or '*'", s)
+	}
+	return strconv.Itoa(index), selectIndex, nil
 }
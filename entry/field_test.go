@@ -226,11 +226,18 @@ func TestSplitField(t *testing.T) {
 		{"BracketMissingQuotes", `$record[test]`, nil, true},
 		{"CharacterBetweenBracketAndQuote", `$record["test"a]`, nil, true},
 		{"CharacterOutsideBracket", `$record["test"]a`, nil, true},
+		{"Index", `$record.items[0]`, []string{"$record", "items", "0"}, false},
+		{"NegativeIndex", `items[-1].id`, []string{"items", "-1", "id"}, false},
+		{"BracketWildcard", `items[*].id`, []string{"items", "*", "id"}, false},
+		{"DotWildcard", `items.*.id`, []string{"items", "*", "id"}, false},
+		{"IndexThenBracket", `items[0]["id"]`, []string{"items", "0", "id"}, false},
+		{"InvalidIndex", `items[0a]`, nil, true},
+		{"UnclosedIndex", `items[0`, nil, true},
 	}
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			s, err := splitField(tc.input)
+			s, _, err := splitField(tc.input)
 			if tc.expectErr {
 				require.Error(t, err)
 				return
@@ -259,3 +266,40 @@ func TestFieldFromStringWithInvalidResource(t *testing.T) {
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "resource fields cannot be nested")
 }
+
+func TestFieldFromStringWithSelectors(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected string
+	}{
+		{"items[0]", "items[0]"},
+		{"$record.items[0].id", "items[0].id"},
+		{"items[*].id", "items.*.id"},
+		{"items.*.id", "items.*.id"},
+		{"[1]", "[1]"},
+		{"$record['dotted.key'][0]", "$record['dotted.key'][0]"},
+		{"$record['dotted.key'].*", "$record['dotted.key'][*]"},
+		{"$record['*']", "$record['*']"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.input, func(t *testing.T) {
+			field, err := fieldFromString(tc.input)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, field.String())
+
+			roundTrip, err := fieldFromString(field.String())
+			require.NoError(t, err)
+			require.Equal(t, field, roundTrip)
+		})
+	}
+}
+
+func TestFieldFromStringWithInvalidSelectors(t *testing.T) {
+	for _, input := range []string{"$labels.*", "$resource[0]", "$trace_id[0]"} {
+		t.Run(input, func(t *testing.T) {
+			_, err := fieldFromString(input)
+			require.Error(t, err)
+		})
+	}
+}
@@ -13,7 +13,7 @@ type LabelField struct {
 // Get will return the label value and a boolean indicating if it exists
 func (l LabelField) Get(entry *Entry) (interface{}, bool) {
 	if entry.Labels == nil {
-		return "", false
+		return nil, false
 	}
 	val, ok := entry.Labels[l.key]
 	return val, ok
@@ -21,24 +21,26 @@ func (l LabelField) Get(entry *Entry) (interface{}, bool) {
 
 // Set will set the label value on an entry
 func (l LabelField) Set(entry *Entry, val interface{}) error {
+	entry.ownLabels()
 	if entry.Labels == nil {
-		entry.Labels = make(map[string]string, 1)
+		entry.Labels = make(map[string]interface{}, 1)
 	}
 
-	str, ok := val.(string)
-	if !ok {
-		return fmt.Errorf("cannot set a label to a non-string value")
+	normalized, err := NormalizeAttribute(val)
+	if err != nil {
+		return fmt.Errorf("cannot set label '%s': %s", l.key, err)
 	}
-	entry.Labels[l.key] = str
+	entry.Labels[l.key] = normalized
 	return nil
 }
 
 // Delete will delete a label from an entry
 func (l LabelField) Delete(entry *Entry) (interface{}, bool) {
 	if entry.Labels == nil {
-		return "", false
+		return nil, false
 	}
 
+	entry.ownLabels()
 	val, ok := entry.Labels[l.key]
 	delete(entry.Labels, l.key)
 	return val, ok
@@ -9,14 +9,14 @@ import (
 func TestLabelFieldGet(t *testing.T) {
 	cases := []struct {
 		name       string
-		labels     map[string]string
+		labels     map[string]interface{}
 		field      Field
 		expected   interface{}
 		expectedOK bool
 	}{
 		{
 			"Simple",
-			map[string]string{
+			map[string]interface{}{
 				"test": "val",
 			},
 			NewLabelField("test"),
@@ -25,18 +25,18 @@ func TestLabelFieldGet(t *testing.T) {
 		},
 		{
 			"NonexistentKey",
-			map[string]string{
+			map[string]interface{}{
 				"test": "val",
 			},
 			NewLabelField("nonexistent"),
-			"",
+			nil,
 			false,
 		},
 		{
 			"NilMap",
 			nil,
 			NewLabelField("nonexistent"),
-			"",
+			nil,
 			false,
 		},
 	}
@@ -55,31 +55,31 @@ func TestLabelFieldGet(t *testing.T) {
 func TestLabelFieldDelete(t *testing.T) {
 	cases := []struct {
 		name           string
-		labels         map[string]string
+		labels         map[string]interface{}
 		field          Field
 		expected       interface{}
 		expectedOK     bool
-		expectedLabels map[string]string
+		expectedLabels map[string]interface{}
 	}{
 		{
 			"Simple",
-			map[string]string{
+			map[string]interface{}{
 				"test": "val",
 			},
 			NewLabelField("test"),
 			"val",
 			true,
-			map[string]string{},
+			map[string]interface{}{},
 		},
 		{
 			"NonexistentKey",
-			map[string]string{
+			map[string]interface{}{
 				"test": "val",
 			},
 			NewLabelField("nonexistent"),
-			"",
+			nil,
 			false,
-			map[string]string{
+			map[string]interface{}{
 				"test": "val",
 			},
 		},
@@ -87,7 +87,7 @@ func TestLabelFieldDelete(t *testing.T) {
 			"NilMap",
 			nil,
 			NewLabelField("nonexistent"),
-			"",
+			nil,
 			false,
 			nil,
 		},
@@ -107,30 +107,30 @@ func TestLabelFieldDelete(t *testing.T) {
 func TestLabelFieldSet(t *testing.T) {
 	cases := []struct {
 		name        string
-		labels      map[string]string
+		labels      map[string]interface{}
 		field       Field
 		val         interface{}
-		expected    map[string]string
+		expected    map[string]interface{}
 		expectedErr bool
 	}{
 		{
 			"Simple",
-			map[string]string{},
+			map[string]interface{}{},
 			NewLabelField("test"),
 			"val",
-			map[string]string{
+			map[string]interface{}{
 				"test": "val",
 			},
 			false,
 		},
 		{
 			"Overwrite",
-			map[string]string{
+			map[string]interface{}{
 				"test": "original",
 			},
 			NewLabelField("test"),
 			"val",
-			map[string]string{
+			map[string]interface{}{
 				"test": "val",
 			},
 			false,
@@ -140,19 +140,65 @@ func TestLabelFieldSet(t *testing.T) {
 			nil,
 			NewLabelField("test"),
 			"val",
-			map[string]string{
+			map[string]interface{}{
 				"test": "val",
 			},
 			false,
 		},
 		{
-			"NonString",
-			map[string]string{},
+			"Int",
+			map[string]interface{}{},
 			NewLabelField("test"),
 			123,
-			map[string]string{
-				"test": "val",
+			map[string]interface{}{
+				"test": 123,
+			},
+			false,
+		},
+		{
+			"Bool",
+			map[string]interface{}{},
+			NewLabelField("test"),
+			true,
+			map[string]interface{}{
+				"test": true,
+			},
+			false,
+		},
+		{
+			"StringSlice",
+			map[string]interface{}{},
+			NewLabelField("test"),
+			[]string{"a", "b"},
+			map[string]interface{}{
+				"test": []interface{}{"a", "b"},
 			},
+			false,
+		},
+		{
+			"YAMLMap",
+			map[string]interface{}{},
+			NewLabelField("test"),
+			map[interface{}]interface{}{"a": 1},
+			map[string]interface{}{
+				"test": map[string]interface{}{"a": 1},
+			},
+			false,
+		},
+		{
+			"Unsupported",
+			map[string]interface{}{},
+			NewLabelField("test"),
+			struct{}{},
+			nil,
+			true,
+		},
+		{
+			"UnsupportedNested",
+			map[string]interface{}{},
+			NewLabelField("test"),
+			[]interface{}{"a", struct{}{}},
+			nil,
 			true,
 		},
 	}
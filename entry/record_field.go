@@ -3,12 +3,47 @@ package entry
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 )
 
 // RecordField is a field found on an entry record.
+//
+// Besides map keys, a record field may select an element of an array by its index,
+// or every element of an array or map with a wildcard. A field with wildcards gets
+// a list of every matched value, and sets or deletes every matched value.
 type RecordField struct {
 	Keys []string
+
+	// selectors holds the kind of each key. It is nil when every key is a map key.
+	selectors []selector
+}
+
+// selector is the kind of a key in a record field
+type selector uint8
+
+const (
+	// selectKey selects a value in a map by its key
+	selectKey selector = iota
+	// selectIndex selects an element of an array by its index
+	selectIndex
+	// selectWildcard selects every element of an array or map
+	selectWildcard
+)
+
+const wildcardKey = "*"
+
+func newRecordField(keys []string, selectors []selector) RecordField {
+	return RecordField{Keys: keys, selectors: selectors}
+}
+
+// selector returns the kind of the key at position i
+func (f RecordField) selector(i int) selector {
+	if f.selectors == nil {
+		return selectKey
+	}
+	return f.selectors[i]
 }
 
 // Parent returns the parent of the current field.
@@ -19,15 +54,86 @@ func (f RecordField) Parent() RecordField {
 	}
 
 	keys := f.Keys[:len(f.Keys)-1]
-	return RecordField{keys}
+	if f.selectors == nil {
+		return RecordField{Keys: keys}
+	}
+	return newRecordField(keys, f.selectors[:len(f.selectors)-1])
 }
 
 // Child returns a child of the current field using the given key.
 func (f RecordField) Child(key string) RecordField {
+	return f.child(key, selectKey)
+}
+
+// Index returns a child of the current field that selects an array element by its index.
+// Negative indexes select elements counting back from the end of the array.
+func (f RecordField) Index(index int) RecordField {
+	return f.child(strconv.Itoa(index), selectIndex)
+}
+
+func (f RecordField) child(key string, sel selector) RecordField {
 	child := make([]string, len(f.Keys), len(f.Keys)+1)
 	copy(child, f.Keys)
 	keys := append(child, key)
-	return RecordField{keys}
+
+	if f.selectors == nil && sel == selectKey {
+		return RecordField{Keys: keys}
+	}
+
+	selectors := make([]selector, len(f.Keys), len(f.Keys)+1)
+	copy(selectors, f.selectors)
+	return newRecordField(keys, append(selectors, sel))
+}
+
+// Wildcards returns the number of wildcards in the field
+func (f RecordField) Wildcards() int {
+	count := 0
+	for _, sel := range f.selectors {
+		if sel == selectWildcard {
+			count++
+		}
+	}
+	return count
+}
+
+// Expand returns a field for each value matched by the field on an entry. The returned
+// fields have their wildcards replaced by the indexes and keys that they matched.
+func (f RecordField) Expand(entry *Entry) []RecordField {
+	matches := []RecordField{}
+	f.walk(entry.Record, 0, RecordField{}, func(match RecordField, _ interface{}) {
+		matches = append(matches, match)
+	})
+	return matches
+}
+
+// Fill returns a copy of the field with its wildcards replaced, in order, by the indexes
+// and keys that the wildcards of pattern matched in match, a field expanded from pattern.
+func (f RecordField) Fill(pattern, match RecordField) (RecordField, error) {
+	if f.Wildcards() != pattern.Wildcards() {
+		return RecordField{}, fmt.Errorf("field %s has %d wildcards, but %s has %d", f, f.Wildcards(), pattern, pattern.Wildcards())
+	}
+	if len(pattern.Keys) != len(match.Keys) {
+		return RecordField{}, fmt.Errorf("field %s was not expanded from %s", match, pattern)
+	}
+
+	captured := make([]int, 0, pattern.Wildcards())
+	for i := range pattern.Keys {
+		if pattern.selector(i) == selectWildcard {
+			captured = append(captured, i)
+		}
+	}
+
+	filled := RecordField{}
+	for i, key := range f.Keys {
+		sel := f.selector(i)
+		if sel == selectWildcard {
+			j := captured[0]
+			captured = captured[1:]
+			key, sel = match.Keys[j], match.selector(j)
+		}
+		filled = filled.child(key, sel)
+	}
+	return filled, nil
 }
 
 // IsRoot returns a boolean indicating if this is a root level field.
@@ -42,7 +148,12 @@ func (f RecordField) String() string {
 
 // Get will retrieve a value from an entry's record using the field.
 // It will return the value and whether the field existed.
+// If the field has wildcards, the value is a list of every matched value.
 func (f RecordField) Get(entry *Entry) (interface{}, bool) {
+	if f.selectors != nil {
+		return f.getSelected(entry)
+	}
+
 	var currentValue interface{} = entry.Record
 
 	for _, key := range f.Keys {
@@ -63,7 +174,18 @@ func (f RecordField) Get(entry *Entry) (interface{}, bool) {
 // Set will set a value on an entry's record using the field.
 // If a key already exists, it will be overwritten.
 // If mergeMaps is set to true, map values will be merged together.
+// If the field has wildcards, the value is set on every matched value.
 func (f RecordField) Set(entry *Entry, value interface{}) error {
+	if f.selectors != nil {
+		entry.ownRecord()
+		record, err := f.setSelected(entry.Record, 0, value)
+		if err != nil {
+			return err
+		}
+		entry.Record = record
+		return nil
+	}
+
 	mapValue, isMapValue := value.(map[string]interface{})
 	if isMapValue {
 		f.Merge(entry, mapValue)
@@ -75,6 +197,7 @@ func (f RecordField) Set(entry *Entry, value interface{}) error {
 		return nil
 	}
 
+	entry.ownRecord()
 	currentMap, ok := entry.Record.(map[string]interface{})
 	if !ok {
 		currentMap = map[string]interface{}{}
@@ -93,7 +216,16 @@ func (f RecordField) Set(entry *Entry, value interface{}) error {
 
 // Merge will attempt to merge the contents of a map into an entry's record.
 // It will overwrite any intermediate values as necessary.
+// Array elements that do not exist are not created.
 func (f RecordField) Merge(entry *Entry, mapValues map[string]interface{}) {
+	entry.ownRecord()
+	if f.selectors != nil {
+		if record, err := f.setSelected(entry.Record, 0, mapValues); err == nil {
+			entry.Record = record
+		}
+		return
+	}
+
 	currentMap, ok := entry.Record.(map[string]interface{})
 	if !ok {
 		currentMap = map[string]interface{}{}
@@ -111,6 +243,7 @@ func (f RecordField) Merge(entry *Entry, mapValues map[string]interface{}) {
 
 // Delete removes a value from an entry's record using the field.
 // It will return the deleted value and whether the field existed.
+// If the field has wildcards, the value is a list of every deleted value.
 func (f RecordField) Delete(entry *Entry) (interface{}, bool) {
 	if f.isRoot() {
 		oldRecord := entry.Record
@@ -118,6 +251,11 @@ func (f RecordField) Delete(entry *Entry) (interface{}, bool) {
 		return oldRecord, true
 	}
 
+	entry.ownRecord()
+	if f.selectors != nil {
+		return f.deleteSelected(entry)
+	}
+
 	currentValue := entry.Record
 	for i, key := range f.Keys {
 		currentMap, ok := currentValue.(map[string]interface{})
@@ -156,6 +294,255 @@ func (f RecordField) getNestedMap(currentMap map[string]interface{}, key string)
 	return nextMap
 }
 
+/************
+  Selectors
+************/
+
+// getSelected gets the value of a field that has indexes or wildcards
+func (f RecordField) getSelected(entry *Entry) (interface{}, bool) {
+	values := []interface{}{}
+	f.walk(entry.Record, 0, RecordField{}, func(_ RecordField, value interface{}) {
+		values = append(values, value)
+	})
+
+	switch {
+	case len(values) == 0:
+		return nil, false
+	case f.Wildcards() == 0:
+		return values[0], true
+	default:
+		return values, true
+	}
+}
+
+// walk calls visit with every value matched by the keys of the field from depth onwards,
+// along with the path to the value with wildcards replaced by what they matched
+func (f RecordField) walk(value interface{}, depth int, path RecordField, visit func(RecordField, interface{})) {
+	if depth == len(f.Keys) {
+		visit(path, value)
+		return
+	}
+
+	key := f.Keys[depth]
+	switch f.selector(depth) {
+	case selectIndex:
+		array, ok := value.([]interface{})
+		if !ok {
+			return
+		}
+		i, ok := resolveIndex(key, len(array))
+		if !ok {
+			return
+		}
+		f.walk(array[i], depth+1, path.Index(i), visit)
+	case selectWildcard:
+		switch typed := value.(type) {
+		case []interface{}:
+			for i, element := range typed {
+				f.walk(element, depth+1, path.Index(i), visit)
+			}
+		case map[string]interface{}:
+			for _, k := range sortedKeys(typed) {
+				f.walk(typed[k], depth+1, path.Child(k), visit)
+			}
+		}
+	default:
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+		next, ok := m[key]
+		if !ok {
+			return
+		}
+		f.walk(next, depth+1, path.Child(key), visit)
+	}
+}
+
+// setSelected sets a value at the keys of the field from depth onwards, and returns the
+// updated current value. Missing maps are created, but missing array elements are not.
+func (f RecordField) setSelected(current interface{}, depth int, value interface{}) (interface{}, error) {
+	if depth == len(f.Keys) {
+		mapValue, ok := value.(map[string]interface{})
+		if !ok {
+			return value, nil
+		}
+		currentMap, ok := current.(map[string]interface{})
+		if !ok {
+			currentMap = map[string]interface{}{}
+		}
+		for k, v := range mapValue {
+			currentMap[k] = v
+		}
+		return currentMap, nil
+	}
+
+	key := f.Keys[depth]
+	switch f.selector(depth) {
+	case selectIndex:
+		array, ok := current.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("cannot set %s: %s is not an array", f, f.prefix(depth))
+		}
+		i, ok := resolveIndex(key, len(array))
+		if !ok {
+			return nil, fmt.Errorf("cannot set %s: index %s is out of range for %s", f, key, f.prefix(depth))
+		}
+		next, err := f.setSelected(array[i], depth+1, value)
+		if err != nil {
+			return nil, err
+		}
+		array[i] = next
+		return array, nil
+	case selectWildcard:
+		switch typed := current.(type) {
+		case []interface{}:
+			for i, element := range typed {
+				next, err := f.setSelected(element, depth+1, value)
+				if err != nil {
+					return nil, err
+				}
+				typed[i] = next
+			}
+		case map[string]interface{}:
+			for k, v := range typed {
+				next, err := f.setSelected(v, depth+1, value)
+				if err != nil {
+					return nil, err
+				}
+				typed[k] = next
+			}
+		}
+		return current, nil
+	default:
+		currentMap, ok := current.(map[string]interface{})
+		if !ok {
+			currentMap = map[string]interface{}{}
+		}
+		next, err := f.setSelected(currentMap[key], depth+1, value)
+		if err != nil {
+			return nil, err
+		}
+		currentMap[key] = next
+		return currentMap, nil
+	}
+}
+
+// deleteSelected deletes the value of a field that has indexes or wildcards
+func (f RecordField) deleteSelected(entry *Entry) (interface{}, bool) {
+	record, deleted := f.deleteFrom(entry.Record, 0)
+	entry.Record = record
+
+	switch {
+	case len(deleted) == 0:
+		return nil, false
+	case f.Wildcards() == 0:
+		return deleted[0], true
+	default:
+		return deleted, true
+	}
+}
+
+// deleteFrom deletes the values at the keys of the field from depth onwards, and
+// returns the updated current value along with the deleted values
+func (f RecordField) deleteFrom(current interface{}, depth int) (interface{}, []interface{}) {
+	key := f.Keys[depth]
+	last := depth == len(f.Keys)-1
+
+	switch f.selector(depth) {
+	case selectIndex:
+		array, ok := current.([]interface{})
+		if !ok {
+			return current, nil
+		}
+		i, ok := resolveIndex(key, len(array))
+		if !ok {
+			return current, nil
+		}
+		if last {
+			deleted := array[i]
+			return append(array[:i], array[i+1:]...), []interface{}{deleted}
+		}
+		next, deleted := f.deleteFrom(array[i], depth+1)
+		array[i] = next
+		return array, deleted
+	case selectWildcard:
+		deleted := []interface{}{}
+		switch typed := current.(type) {
+		case []interface{}:
+			if last {
+				return []interface{}{}, append(deleted, typed...)
+			}
+			for i, element := range typed {
+				next, d := f.deleteFrom(element, depth+1)
+				typed[i] = next
+				deleted = append(deleted, d...)
+			}
+		case map[string]interface{}:
+			for _, k := range sortedKeys(typed) {
+				if last {
+					deleted = append(deleted, typed[k])
+					delete(typed, k)
+					continue
+				}
+				next, d := f.deleteFrom(typed[k], depth+1)
+				typed[k] = next
+				deleted = append(deleted, d...)
+			}
+		}
+		return current, deleted
+	default:
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return current, nil
+		}
+		value, ok := m[key]
+		if !ok {
+			return current, nil
+		}
+		if last {
+			delete(m, key)
+			return m, []interface{}{value}
+		}
+		next, deleted := f.deleteFrom(value, depth+1)
+		m[key] = next
+		return m, deleted
+	}
+}
+
+// prefix returns the field made of the keys before depth
+func (f RecordField) prefix(depth int) RecordField {
+	if f.selectors == nil {
+		return RecordField{Keys: f.Keys[:depth]}
+	}
+	return newRecordField(f.Keys[:depth], f.selectors[:depth])
+}
+
+// resolveIndex converts an index key to a position in an array of the given length.
+// Negative indexes count back from the end of the array.
+func resolveIndex(key string, length int) (int, bool) {
+	i, err := strconv.Atoi(key)
+	if err != nil {
+		return 0, false
+	}
+	if i < 0 {
+		i += length
+	}
+	if i < 0 || i >= length {
+		return 0, false
+	}
+	return i, true
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 /****************
   Serialization
 ****************/
@@ -195,13 +582,20 @@ func (f RecordField) MarshalYAML() (interface{}, error) {
 
 // fromJSONDot creates a field from JSON dot notation.
 func fromJSONDot(value string) RecordField {
+	if keys, selectors, err := splitField(value); err == nil && selectors != nil {
+		if keys[0] == "$" || keys[0] == recordPrefix {
+			return newRecordField(keys[1:], selectors[1:])
+		}
+		return newRecordField(keys, selectors)
+	}
+
 	keys := strings.Split(value, ".")
 
 	if keys[0] == "$" || keys[0] == recordPrefix {
 		keys = keys[1:]
 	}
 
-	return RecordField{keys}
+	return RecordField{Keys: keys}
 }
 
 // toJSONDot returns the JSON dot notation for a field.
@@ -211,12 +605,17 @@ func toJSONDot(field RecordField) string {
 	}
 
 	containsDots := false
-	for _, key := range field.Keys {
-		if strings.Contains(key, ".") {
+	for i, key := range field.Keys {
+		// A key named like a wildcard must also be quoted to be read back as a key
+		if field.selector(i) == selectKey && (strings.Contains(key, ".") || key == wildcardKey) {
 			containsDots = true
 		}
 	}
 
+	if field.selectors != nil {
+		return toSelectorDot(field, containsDots)
+	}
+
 	var b strings.Builder
 	if containsDots {
 		b.WriteString(recordPrefix)
@@ -237,6 +636,34 @@ func toJSONDot(field RecordField) string {
 	return b.String()
 }
 
+// toSelectorDot returns the JSON dot notation for a field that has indexes or wildcards.
+func toSelectorDot(field RecordField, bracketKeys bool) string {
+	var b strings.Builder
+	if bracketKeys {
+		b.WriteString(recordPrefix)
+	}
+
+	for i, key := range field.Keys {
+		switch sel := field.selector(i); {
+		case sel == selectIndex || (sel == selectWildcard && bracketKeys):
+			b.WriteString("[")
+			b.WriteString(key)
+			b.WriteString("]")
+		case bracketKeys:
+			b.WriteString(`['`)
+			b.WriteString(key)
+			b.WriteString(`']`)
+		default:
+			if i != 0 {
+				b.WriteString(".")
+			}
+			b.WriteString(key)
+		}
+	}
+
+	return b.String()
+}
+
 // NewRecordField creates a new field from an ordered array of keys.
 func NewRecordField(keys ...string) Field {
 	return Field{RecordField{
@@ -275,25 +275,25 @@ func TestRecordFieldSet(t *testing.T) {
 
 func TestRecordFieldParent(t *testing.T) {
 	t.Run("Simple", func(t *testing.T) {
-		field := RecordField{[]string{"child"}}
-		require.Equal(t, RecordField{[]string{}}, field.Parent())
+		field := RecordField{Keys: []string{"child"}}
+		require.Equal(t, RecordField{Keys: []string{}}, field.Parent())
 	})
 
 	t.Run("Root", func(t *testing.T) {
-		field := RecordField{[]string{}}
-		require.Equal(t, RecordField{[]string{}}, field.Parent())
+		field := RecordField{Keys: []string{}}
+		require.Equal(t, RecordField{Keys: []string{}}, field.Parent())
 	})
 }
 
 func TestRecordFieldChild(t *testing.T) {
-	field := RecordField{[]string{"parent"}}
-	require.Equal(t, RecordField{[]string{"parent", "child"}}, field.Child("child"))
+	field := RecordField{Keys: []string{"parent"}}
+	require.Equal(t, RecordField{Keys: []string{"parent", "child"}}, field.Child("child"))
 }
 
 func TestRecordFieldMerge(t *testing.T) {
 	entry := &Entry{}
 	entry.Record = "raw_value"
-	field := RecordField{[]string{"embedded"}}
+	field := RecordField{Keys: []string{"embedded"}}
 	values := map[string]interface{}{"new": "values"}
 	field.Merge(entry, values)
 	expected := map[string]interface{}{"embedded": values}
@@ -352,3 +352,233 @@ func TestRecordFieldFromJSONDot(t *testing.T) {
 	expectedField := RecordField{Keys: []string{"test"}}
 	require.Equal(t, expectedField, recordField)
 }
+
+func testArrayRecord() map[string]interface{} {
+	return map[string]interface{}{
+		"items": []interface{}{
+			map[string]interface{}{"id": "a", "tags": []interface{}{"x", "y"}},
+			map[string]interface{}{"id": "b", "tags": []interface{}{"z"}},
+		},
+	}
+}
+
+func mustField(t *testing.T, s string) Field {
+	field, err := fieldFromString(s)
+	require.NoError(t, err)
+	return field
+}
+
+func TestRecordFieldGetSelectors(t *testing.T) {
+	cases := []struct {
+		field       string
+		expectedVal interface{}
+		expectedOk  bool
+	}{
+		{"items[0].id", "a", true},
+		{"items[-1].id", "b", true},
+		{"items[1].tags[0]", "z", true},
+		{"items[2].id", nil, false},
+		{"items[0].missing", nil, false},
+		{"items.*.id", []interface{}{"a", "b"}, true},
+		{"items.*.tags.*", []interface{}{"x", "y", "z"}, true},
+		{"items[0].*", []interface{}{"a", []interface{}{"x", "y"}}, true},
+		{"items.*.missing", nil, false},
+		{"missing[0]", nil, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.field, func(t *testing.T) {
+			entry := New()
+			entry.Record = testArrayRecord()
+
+			val, ok := entry.Get(mustField(t, tc.field))
+			require.Equal(t, tc.expectedOk, ok)
+			require.Equal(t, tc.expectedVal, val)
+		})
+	}
+}
+
+func TestRecordFieldLiteralWildcardKey(t *testing.T) {
+	newRecord := func() map[string]interface{} {
+		return map[string]interface{}{"*": "literal", "other": "value"}
+	}
+
+	for _, field := range []string{`$record['*']`, `$record["*"]`, `['*']`} {
+		t.Run(field, func(t *testing.T) {
+			entry := New()
+			entry.Record = newRecord()
+
+			val, ok := entry.Get(mustField(t, field))
+			require.True(t, ok)
+			require.Equal(t, "literal", val)
+
+			require.NoError(t, entry.Set(mustField(t, field), "changed"))
+			require.Equal(t, map[string]interface{}{"*": "changed", "other": "value"}, entry.Record)
+
+			val, ok = entry.Delete(mustField(t, field))
+			require.True(t, ok)
+			require.Equal(t, "changed", val)
+			require.Equal(t, map[string]interface{}{"other": "value"}, entry.Record)
+		})
+	}
+
+	// Unbracketed, it is a wildcard that matches every value
+	entry := New()
+	entry.Record = newRecord()
+	val, ok := entry.Get(mustField(t, `$record.*`))
+	require.True(t, ok)
+	require.ElementsMatch(t, []interface{}{"literal", "value"}, val)
+}
+
+func TestRecordFieldSetSelectors(t *testing.T) {
+	t.Run("Index", func(t *testing.T) {
+		entry := New()
+		entry.Record = testArrayRecord()
+		require.NoError(t, entry.Set(mustField(t, "items[1].id"), "c"))
+
+		val, _ := entry.Get(mustField(t, "items.*.id"))
+		require.Equal(t, []interface{}{"a", "c"}, val)
+	})
+
+	t.Run("Wildcard", func(t *testing.T) {
+		entry := New()
+		entry.Record = testArrayRecord()
+		require.NoError(t, entry.Set(mustField(t, "items.*.nested.key"), "value"))
+
+		val, _ := entry.Get(mustField(t, "items.*.nested"))
+		expected := map[string]interface{}{"key": "value"}
+		require.Equal(t, []interface{}{expected, expected}, val)
+	})
+
+	t.Run("MergeMap", func(t *testing.T) {
+		entry := New()
+		entry.Record = testArrayRecord()
+		require.NoError(t, entry.Set(mustField(t, "items[0]"), map[string]interface{}{"new": "value"}))
+
+		val, _ := entry.Get(mustField(t, "items[0]"))
+		expected := map[string]interface{}{"id": "a", "new": "value", "tags": []interface{}{"x", "y"}}
+		require.Equal(t, expected, val)
+	})
+
+	t.Run("WildcardNoMatches", func(t *testing.T) {
+		entry := New()
+		entry.Record = map[string]interface{}{"items": []interface{}{}}
+		require.NoError(t, entry.Set(mustField(t, "items.*.id"), "value"))
+		require.Equal(t, map[string]interface{}{"items": []interface{}{}}, entry.Record)
+	})
+
+	t.Run("IndexOutOfRange", func(t *testing.T) {
+		entry := New()
+		entry.Record = testArrayRecord()
+		err := entry.Set(mustField(t, "items[2].id"), "value")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "out of range")
+		require.Equal(t, testArrayRecord(), entry.Record)
+	})
+
+	t.Run("NotAnArray", func(t *testing.T) {
+		entry := New()
+		entry.Record = map[string]interface{}{"items": "value"}
+		err := entry.Set(mustField(t, "items[0]"), "value")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not an array")
+	})
+}
+
+func TestRecordFieldDeleteSelectors(t *testing.T) {
+	cases := []struct {
+		field            string
+		expectedRecord   interface{}
+		expectedReturned interface{}
+		expectedOk       bool
+	}{
+		{
+			"items[0]",
+			map[string]interface{}{
+				"items": []interface{}{
+					map[string]interface{}{"id": "b", "tags": []interface{}{"z"}},
+				},
+			},
+			map[string]interface{}{"id": "a", "tags": []interface{}{"x", "y"}},
+			true,
+		},
+		{
+			"items[-1].tags",
+			map[string]interface{}{
+				"items": []interface{}{
+					map[string]interface{}{"id": "a", "tags": []interface{}{"x", "y"}},
+					map[string]interface{}{"id": "b"},
+				},
+			},
+			[]interface{}{"z"},
+			true,
+		},
+		{
+			"items.*.tags",
+			map[string]interface{}{
+				"items": []interface{}{
+					map[string]interface{}{"id": "a"},
+					map[string]interface{}{"id": "b"},
+				},
+			},
+			[]interface{}{[]interface{}{"x", "y"}, []interface{}{"z"}},
+			true,
+		},
+		{
+			"items.*",
+			map[string]interface{}{
+				"items": []interface{}{},
+			},
+			[]interface{}{
+				map[string]interface{}{"id": "a", "tags": []interface{}{"x", "y"}},
+				map[string]interface{}{"id": "b", "tags": []interface{}{"z"}},
+			},
+			true,
+		},
+		{
+			"items[5]",
+			testArrayRecord(),
+			nil,
+			false,
+		},
+		{
+			"items.*.missing",
+			testArrayRecord(),
+			nil,
+			false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.field, func(t *testing.T) {
+			entry := New()
+			entry.Record = testArrayRecord()
+
+			val, ok := entry.Delete(mustField(t, tc.field))
+			require.Equal(t, tc.expectedOk, ok)
+			require.Equal(t, tc.expectedReturned, val)
+			require.Equal(t, tc.expectedRecord, entry.Record)
+		})
+	}
+}
+
+func TestRecordFieldExpand(t *testing.T) {
+	entry := New()
+	entry.Record = testArrayRecord()
+
+	pattern := mustField(t, "items.*.tags.*").FieldInterface.(RecordField)
+	matches := pattern.Expand(entry)
+	strs := make([]string, 0, len(matches))
+	for _, match := range matches {
+		strs = append(strs, match.String())
+	}
+	require.Equal(t, []string{"items[0].tags[0]", "items[0].tags[1]", "items[1].tags[0]"}, strs)
+
+	to := mustField(t, "copies.*.tag*.*").FieldInterface.(RecordField)
+	filled, err := to.Fill(pattern, matches[2])
+	require.NoError(t, err)
+	require.Equal(t, "copies[1].tag*[0]", filled.String())
+
+	_, err = NewRecordField("other").FieldInterface.(RecordField).Fill(pattern, matches[0])
+	require.Error(t, err)
+}
@@ -13,7 +13,7 @@ type ResourceField struct {
 // Get will return the resource value and a boolean indicating if it exists
 func (r ResourceField) Get(entry *Entry) (interface{}, bool) {
 	if entry.Resource == nil {
-		return "", false
+		return nil, false
 	}
 	val, ok := entry.Resource[r.key]
 	return val, ok
@@ -21,24 +21,26 @@ func (r ResourceField) Get(entry *Entry) (interface{}, bool) {
 
 // Set will set the resource value on an entry
 func (r ResourceField) Set(entry *Entry, val interface{}) error {
+	entry.ownResource()
 	if entry.Resource == nil {
-		entry.Resource = make(map[string]string, 1)
+		entry.Resource = make(map[string]interface{}, 1)
 	}
 
-	str, ok := val.(string)
-	if !ok {
-		return fmt.Errorf("cannot set a resource to a non-string value")
+	normalized, err := NormalizeAttribute(val)
+	if err != nil {
+		return fmt.Errorf("cannot set resource '%s': %s", r.key, err)
 	}
-	entry.Resource[r.key] = str
+	entry.Resource[r.key] = normalized
 	return nil
 }
 
 // Delete will delete a resource key from an entry
 func (r ResourceField) Delete(entry *Entry) (interface{}, bool) {
 	if entry.Resource == nil {
-		return "", false
+		return nil, false
 	}
 
+	entry.ownResource()
 	val, ok := entry.Resource[r.key]
 	delete(entry.Resource, r.key)
 	return val, ok
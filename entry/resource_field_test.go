@@ -9,14 +9,14 @@ import (
 func TestResourceFieldGet(t *testing.T) {
 	cases := []struct {
 		name       string
-		resources  map[string]string
+		resources  map[string]interface{}
 		field      Field
 		expected   interface{}
 		expectedOK bool
 	}{
 		{
 			"Simple",
-			map[string]string{
+			map[string]interface{}{
 				"test": "val",
 			},
 			NewResourceField("test"),
@@ -25,18 +25,18 @@ func TestResourceFieldGet(t *testing.T) {
 		},
 		{
 			"NonexistentKey",
-			map[string]string{
+			map[string]interface{}{
 				"test": "val",
 			},
 			NewResourceField("nonexistent"),
-			"",
+			nil,
 			false,
 		},
 		{
 			"NilMap",
 			nil,
 			NewResourceField("nonexistent"),
-			"",
+			nil,
 			false,
 		},
 	}
@@ -55,31 +55,31 @@ func TestResourceFieldGet(t *testing.T) {
 func TestResourceFieldDelete(t *testing.T) {
 	cases := []struct {
 		name              string
-		resources         map[string]string
+		resources         map[string]interface{}
 		field             Field
 		expected          interface{}
 		expectedOK        bool
-		expectedResources map[string]string
+		expectedResources map[string]interface{}
 	}{
 		{
 			"Simple",
-			map[string]string{
+			map[string]interface{}{
 				"test": "val",
 			},
 			NewResourceField("test"),
 			"val",
 			true,
-			map[string]string{},
+			map[string]interface{}{},
 		},
 		{
 			"NonexistentKey",
-			map[string]string{
+			map[string]interface{}{
 				"test": "val",
 			},
 			NewResourceField("nonexistent"),
-			"",
+			nil,
 			false,
-			map[string]string{
+			map[string]interface{}{
 				"test": "val",
 			},
 		},
@@ -87,7 +87,7 @@ func TestResourceFieldDelete(t *testing.T) {
 			"NilMap",
 			nil,
 			NewResourceField("nonexistent"),
-			"",
+			nil,
 			false,
 			nil,
 		},
@@ -107,30 +107,30 @@ func TestResourceFieldDelete(t *testing.T) {
 func TestResourceFieldSet(t *testing.T) {
 	cases := []struct {
 		name        string
-		resources   map[string]string
+		resources   map[string]interface{}
 		field       Field
 		val         interface{}
-		expected    map[string]string
+		expected    map[string]interface{}
 		expectedErr bool
 	}{
 		{
 			"Simple",
-			map[string]string{},
+			map[string]interface{}{},
 			NewResourceField("test"),
 			"val",
-			map[string]string{
+			map[string]interface{}{
 				"test": "val",
 			},
 			false,
 		},
 		{
 			"Overwrite",
-			map[string]string{
+			map[string]interface{}{
 				"test": "original",
 			},
 			NewResourceField("test"),
 			"val",
-			map[string]string{
+			map[string]interface{}{
 				"test": "val",
 			},
 			false,
@@ -140,19 +140,65 @@ func TestResourceFieldSet(t *testing.T) {
 			nil,
 			NewResourceField("test"),
 			"val",
-			map[string]string{
+			map[string]interface{}{
 				"test": "val",
 			},
 			false,
 		},
 		{
-			"NonString",
-			map[string]string{},
+			"Int",
+			map[string]interface{}{},
 			NewResourceField("test"),
 			123,
-			map[string]string{
-				"test": "val",
+			map[string]interface{}{
+				"test": 123,
+			},
+			false,
+		},
+		{
+			"Bool",
+			map[string]interface{}{},
+			NewResourceField("test"),
+			true,
+			map[string]interface{}{
+				"test": true,
+			},
+			false,
+		},
+		{
+			"StringSlice",
+			map[string]interface{}{},
+			NewResourceField("test"),
+			[]string{"a", "b"},
+			map[string]interface{}{
+				"test": []interface{}{"a", "b"},
 			},
+			false,
+		},
+		{
+			"YAMLMap",
+			map[string]interface{}{},
+			NewResourceField("test"),
+			map[interface{}]interface{}{"a": 1},
+			map[string]interface{}{
+				"test": map[string]interface{}{"a": 1},
+			},
+			false,
+		},
+		{
+			"Unsupported",
+			map[string]interface{}{},
+			NewResourceField("test"),
+			struct{}{},
+			nil,
+			true,
+		},
+		{
+			"UnsupportedNested",
+			map[string]interface{}{},
+			NewResourceField("test"),
+			[]interface{}{"a", struct{}{}},
+			nil,
 			true,
 		},
 	}
@@ -0,0 +1,112 @@
+package entry
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+// These are the lengths in bytes of the trace context of an entry
+const (
+	TraceIDLength    = 16
+	SpanIDLength     = 8
+	TraceFlagsLength = 1
+)
+
+// TraceID identifies the trace an entry belongs to. It is encoded as hex in JSON and YAML.
+type TraceID []byte
+
+// String returns the trace ID as hex
+func (t TraceID) String() string {
+	return hex.EncodeToString(t)
+}
+
+// MarshalText encodes the trace ID as hex
+func (t TraceID) MarshalText() ([]byte, error) {
+	return marshalHex(t)
+}
+
+// UnmarshalText decodes a trace ID from hex
+func (t *TraceID) UnmarshalText(text []byte) error {
+	return unmarshalHex(text, TraceIDLength, (*[]byte)(t))
+}
+
+// SpanID identifies the span an entry belongs to. It is encoded as hex in JSON and YAML.
+type SpanID []byte
+
+// String returns the span ID as hex
+func (s SpanID) String() string {
+	return hex.EncodeToString(s)
+}
+
+// MarshalText encodes the span ID as hex
+func (s SpanID) MarshalText() ([]byte, error) {
+	return marshalHex(s)
+}
+
+// UnmarshalText decodes a span ID from hex
+func (s *SpanID) UnmarshalText(text []byte) error {
+	return unmarshalHex(text, SpanIDLength, (*[]byte)(s))
+}
+
+// TraceFlags are the W3C trace flags of an entry. It is encoded as hex in JSON and YAML.
+type TraceFlags []byte
+
+// String returns the trace flags as hex
+func (f TraceFlags) String() string {
+	return hex.EncodeToString(f)
+}
+
+// Sampled returns whether the sampled flag is set
+func (f TraceFlags) Sampled() bool {
+	return len(f) > 0 && f[0]&1 == 1
+}
+
+// MarshalText encodes the trace flags as hex
+func (f TraceFlags) MarshalText() ([]byte, error) {
+	return marshalHex(f)
+}
+
+// UnmarshalText decodes trace flags from hex
+func (f *TraceFlags) UnmarshalText(text []byte) error {
+	return unmarshalHex(text, TraceFlagsLength, (*[]byte)(f))
+}
+
+func marshalHex(b []byte) ([]byte, error) {
+	dst := make([]byte, hex.EncodedLen(len(b)))
+	hex.Encode(dst, b)
+	return dst, nil
+}
+
+func unmarshalHex(text []byte, length int, dst *[]byte) error {
+	if len(text) == 0 {
+		*dst = nil
+		return nil
+	}
+
+	b, err := decodeTraceBytes(string(text), length)
+	if err != nil {
+		return err
+	}
+	*dst = b
+	return nil
+}
+
+// decodeTraceBytes decodes a hex string that must be exactly length bytes long
+func decodeTraceBytes(s string, length int) ([]byte, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex '%s': %s", s, err)
+	}
+	if len(b) != length {
+		return nil, fmt.Errorf("expected %d bytes, got %d", length, len(b))
+	}
+	return b, nil
+}
+
+// copyBytes returns a copy of a byte slice, keeping nil slices nil
+func copyBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return copyByteArray(b)
+}
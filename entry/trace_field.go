@@ -0,0 +1,99 @@
+package entry
+
+import (
+	"fmt"
+)
+
+const (
+	traceIDField    = "$trace_id"
+	spanIDField     = "$span_id"
+	traceFlagsField = "$trace_flags"
+)
+
+// TraceField is the path to a part of an entry's trace context: its trace ID, span ID or
+// trace flags. Values are read as hex strings, and may be set from hex strings or bytes.
+type TraceField struct {
+	name string
+}
+
+// Get will return the value as a hex string and a boolean indicating if it is set
+func (t TraceField) Get(entry *Entry) (interface{}, bool) {
+	b := t.bytes(entry)
+	if len(*b) == 0 {
+		return nil, false
+	}
+	return fmt.Sprintf("%x", *b), true
+}
+
+// Set will set the value on an entry from a hex string or bytes of the right length
+func (t TraceField) Set(entry *Entry, val interface{}) error {
+	length := t.length()
+
+	var b []byte
+	switch typed := val.(type) {
+	case string:
+		decoded, err := decodeTraceBytes(typed, length)
+		if err != nil {
+			return fmt.Errorf("cannot set %s: %s", t.name, err)
+		}
+		b = decoded
+	case []byte:
+		if len(typed) != length {
+			return fmt.Errorf("cannot set %s: expected %d bytes, got %d", t.name, length, len(typed))
+		}
+		b = copyByteArray(typed)
+	default:
+		return fmt.Errorf("cannot set %s to a value of type '%T'", t.name, val)
+	}
+
+	*t.bytes(entry) = b
+	return nil
+}
+
+// Delete will clear the value from an entry
+func (t TraceField) Delete(entry *Entry) (interface{}, bool) {
+	val, ok := t.Get(entry)
+	*t.bytes(entry) = nil
+	return val, ok
+}
+
+func (t TraceField) String() string {
+	return t.name
+}
+
+func (t TraceField) bytes(entry *Entry) *[]byte {
+	switch t.name {
+	case traceIDField:
+		return (*[]byte)(&entry.TraceID)
+	case spanIDField:
+		return (*[]byte)(&entry.SpanID)
+	default:
+		return (*[]byte)(&entry.TraceFlags)
+	}
+}
+
+func (t TraceField) length() int {
+	switch t.name {
+	case traceIDField:
+		return TraceIDLength
+	case spanIDField:
+		return SpanIDLength
+	default:
+		return TraceFlagsLength
+	}
+}
+
+// NewTraceIDField will create a new field for the trace ID of an entry
+func NewTraceIDField() Field {
+	return Field{TraceField{traceIDField}}
+}
+
+// NewSpanIDField will create a new field for the span ID of an entry
+func NewSpanIDField() Field {
+	return Field{TraceField{spanIDField}}
+}
+
+// NewTraceFlagsField will create a new field for the trace flags of an entry
+func NewTraceFlagsField() Field {
+	return Field{TraceField{traceFlagsField}}
+}
@@ -0,0 +1,143 @@
+package entry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTraceFieldGet(t *testing.T) {
+	entry := New()
+	_, ok := entry.Get(NewTraceIDField())
+	require.False(t, ok)
+
+	entry.TraceID = TraceID{0x0a, 0xf7, 0x65, 0x19, 0x16, 0xcd, 0x43, 0xdd, 0x84, 0x48, 0xeb, 0x21, 0x1c, 0x80, 0x31, 0x9c}
+	entry.SpanID = SpanID{0xb7, 0xad, 0x6b, 0x71, 0x69, 0x20, 0x33, 0x31}
+	entry.TraceFlags = TraceFlags{0x01}
+
+	val, ok := entry.Get(NewTraceIDField())
+	require.True(t, ok)
+	require.Equal(t, "0af7651916cd43dd8448eb211c80319c", val)
+
+	val, ok = entry.Get(NewSpanIDField())
+	require.True(t, ok)
+	require.Equal(t, "b7ad6b7169203331", val)
+
+	val, ok = entry.Get(NewTraceFlagsField())
+	require.True(t, ok)
+	require.Equal(t, "01", val)
+}
+
+func TestTraceFieldSet(t *testing.T) {
+	cases := []struct {
+		name        string
+		field       Field
+		val         interface{}
+		expectedHex string
+		expectedErr bool
+	}{
+		{
+			"TraceIDHex",
+			NewTraceIDField(),
+			"0af7651916cd43dd8448eb211c80319c",
+			"0af7651916cd43dd8448eb211c80319c",
+			false,
+		},
+		{
+			"SpanIDBytes",
+			NewSpanIDField(),
+			[]byte{0xb7, 0xad, 0x6b, 0x71, 0x69, 0x20, 0x33, 0x31},
+			"b7ad6b7169203331",
+			false,
+		},
+		{
+			"TraceFlagsHex",
+			NewTraceFlagsField(),
+			"01",
+			"01",
+			false,
+		},
+		{
+			"WrongLength",
+			NewTraceIDField(),
+			"0af765",
+			"",
+			true,
+		},
+		{
+			"InvalidHex",
+			NewSpanIDField(),
+			"zzzzzzzzzzzzzzzz",
+			"",
+			true,
+		},
+		{
+			"WrongType",
+			NewTraceFlagsField(),
+			1,
+			"",
+			true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			entry := New()
+			err := entry.Set(tc.field, tc.val)
+			if tc.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			val, ok := entry.Get(tc.field)
+			require.True(t, ok)
+			require.Equal(t, tc.expectedHex, val)
+		})
+	}
+}
+
+func TestTraceFieldDelete(t *testing.T) {
+	entry := New()
+	entry.SpanID = SpanID{0xb7, 0xad, 0x6b, 0x71, 0x69, 0x20, 0x33, 0x31}
+
+	val, ok := entry.Delete(NewSpanIDField())
+	require.True(t, ok)
+	require.Equal(t, "b7ad6b7169203331", val)
+	require.Nil(t, entry.SpanID)
+
+	_, ok = entry.Delete(NewSpanIDField())
+	require.False(t, ok)
+}
+
+func TestTraceFieldFromString(t *testing.T) {
+	f, err := fieldFromString("$trace_id")
+	require.NoError(t, err)
+	require.Equal(t, NewTraceIDField(), f)
+	require.Equal(t, "$trace_id", f.String())
+
+	f, err = fieldFromString("$span_id")
+	require.NoError(t, err)
+	require.Equal(t, NewSpanIDField(), f)
+
+	f, err = fieldFromString("$trace_flags")
+	require.NoError(t, err)
+	require.Equal(t, NewTraceFlagsField(), f)
+
+	_, err = fieldFromString("$trace_id.foo")
+	require.Error(t, err)
+}
+
+func TestTraceContextCopy(t *testing.T) {
+	entry := New()
+	entry.TraceID = TraceID{0x0a, 0xf7, 0x65, 0x19, 0x16, 0xcd, 0x43, 0xdd, 0x84, 0x48, 0xeb, 0x21, 0x1c, 0x80, 0x31, 0x9c}
+	entry.TraceFlags = TraceFlags{0x01}
+	copy := entry.Copy()
+
+	entry.TraceID[0] = 0xff
+	entry.TraceFlags[0] = 0x00
+
+	require.Equal(t, "0af7651916cd43dd8448eb211c80319c", copy.TraceID.String())
+	require.True(t, copy.TraceFlags.Sampled())
+	require.Nil(t, copy.SpanID)
+}
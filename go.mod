@@ -4,7 +4,9 @@ go 1.14
 
 require (
 	github.com/antonmedv/expr v1.8.2
+	github.com/bmatcuk/doublestar/v2 v2.0.4
 	github.com/cenkalti/backoff/v4 v4.0.2
+	github.com/fsnotify/fsnotify v1.4.9
 	github.com/json-iterator/go v1.1.10
 	github.com/kardianos/service v1.2.0 // indirect
 	github.com/kr/text v0.2.0 // indirect
@@ -18,6 +18,28 @@ type Buffer interface {
 	Close() error
 }
 
+// batchAdder is implemented by buffers that can add many entries more cheaply than
+// adding them one at a time
+type batchAdder interface {
+	AddBatch(context.Context, []*entry.Entry) error
+}
+
+// AddBatch adds a batch of entries to a buffer, blocking until they are all added or the
+// context is cancelled. Buffers that can't add a batch at once have the entries added one
+// at a time.
+func AddBatch(ctx context.Context, b Buffer, entries []*entry.Entry) error {
+	if adder, ok := b.(batchAdder); ok {
+		return adder.AddBatch(ctx, entries)
+	}
+
+	for _, e := range entries {
+		if err := b.Add(ctx, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Config is a struct that wraps a Builder
 type Config struct {
 	Builder
@@ -35,6 +57,21 @@ type Builder interface {
 	Build(context operator.BuildContext, pluginID string) (Buffer, error)
 }
 
+// BuildWithPayloadLimit builds a Buffer whose chunks are at most limit bytes, or
+// max_chunk_bytes if that is smaller. Outputs use it to declare the largest payload
+// their destination accepts. A limit of 0 means no limit.
+func (bc Config) BuildWithPayloadLimit(context operator.BuildContext, pluginID string, limit int64) (Buffer, error) {
+	b, err := bc.Build(context, pluginID)
+	if err != nil {
+		return nil, err
+	}
+
+	if limiter, ok := b.(payloadLimiter); ok {
+		limiter.limitChunkBytes(limit)
+	}
+	return b, nil
+}
+
 // UnmarshalJSON unmarshals JSON
 func (bc *Config) UnmarshalJSON(data []byte) error {
 	return bc.unmarshal(func(dst interface{}) error {
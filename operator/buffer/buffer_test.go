@@ -24,10 +24,11 @@ func TestBufferUnmarshalYAML(t *testing.T) {
 			[]byte(`{"type": "memory", "max_entries": 30}`),
 			Config{
 				Builder: &MemoryBufferConfig{
-					Type:          "memory",
-					MaxEntries:    30,
-					MaxChunkDelay: helper.NewDuration(time.Second),
-					MaxChunkSize:  1000,
+					Type:           "memory",
+					MaxEntries:     30,
+					MaxChunkDelay:  helper.NewDuration(time.Second),
+					MaxChunkSize:   1000,
+					OversizedEntry: SendAlone,
 				},
 			},
 			false,
@@ -38,12 +39,13 @@ func TestBufferUnmarshalYAML(t *testing.T) {
 			[]byte(`{"type": "disk", "max_size": 1234, "path": "/var/log/testpath"}`),
 			Config{
 				Builder: &DiskBufferConfig{
-					Type:          "disk",
-					MaxSize:       1234,
-					Path:          "/var/log/testpath",
-					Sync:          true,
-					MaxChunkDelay: helper.NewDuration(time.Second),
-					MaxChunkSize:  1000,
+					Type:           "disk",
+					MaxSize:        1234,
+					Path:           "/var/log/testpath",
+					Sync:           true,
+					MaxChunkDelay:  helper.NewDuration(time.Second),
+					MaxChunkSize:   1000,
+					OversizedEntry: SendAlone,
 				},
 			},
 			false,
@@ -54,12 +56,13 @@ func TestBufferUnmarshalYAML(t *testing.T) {
 			[]byte(`{"type": "invalid"}`),
 			Config{
 				Builder: &DiskBufferConfig{
-					Type:          "disk",
-					MaxSize:       1234,
-					Path:          "/var/log/testpath",
-					Sync:          true,
-					MaxChunkDelay: helper.NewDuration(time.Second),
-					MaxChunkSize:  1000,
+					Type:           "disk",
+					MaxSize:        1234,
+					Path:           "/var/log/testpath",
+					Sync:           true,
+					MaxChunkDelay:  helper.NewDuration(time.Second),
+					MaxChunkSize:   1000,
+					OversizedEntry: SendAlone,
 				},
 			},
 			true,
@@ -70,12 +73,13 @@ func TestBufferUnmarshalYAML(t *testing.T) {
 			[]byte(`{"type": 12}`),
 			Config{
 				Builder: &DiskBufferConfig{
-					Type:          "disk",
-					MaxSize:       1234,
-					Path:          "/var/log/testpath",
-					Sync:          true,
-					MaxChunkDelay: helper.NewDuration(time.Second),
-					MaxChunkSize:  1000,
+					Type:           "disk",
+					MaxSize:        1234,
+					Path:           "/var/log/testpath",
+					Sync:           true,
+					MaxChunkDelay:  helper.NewDuration(time.Second),
+					MaxChunkSize:   1000,
+					OversizedEntry: SendAlone,
 				},
 			},
 			true,
@@ -114,10 +118,11 @@ func TestBuffer(t *testing.T) {
 		cfg := NewConfig()
 		expected := Config{
 			Builder: &MemoryBufferConfig{
-				Type:          "memory",
-				MaxEntries:    1 << 20,
-				MaxChunkDelay: helper.NewDuration(time.Second),
-				MaxChunkSize:  1000,
+				Type:           "memory",
+				MaxEntries:     1 << 20,
+				MaxChunkDelay:  helper.NewDuration(time.Second),
+				MaxChunkSize:   1000,
+				OversizedEntry: SendAlone,
 			},
 		}
 		require.Equal(t, expected, cfg)
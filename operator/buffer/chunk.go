@@ -0,0 +1,134 @@
+package buffer
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/observiq/stanza/entry"
+)
+
+// These are the actions that can be taken when a single entry is larger than max_chunk_bytes
+const (
+	// SendAlone sends the oversized entry in a chunk by itself
+	SendAlone = "send_alone"
+
+	// Reject drops the oversized entry
+	Reject = "reject"
+
+	// Truncate shortens the record of the oversized entry so that it fits. Entries
+	// that can't be truncated are rejected.
+	Truncate = "truncate"
+)
+
+// chunkLimits bounds the size in bytes of the chunks read from a buffer
+type chunkLimits struct {
+	maxChunkBytes  int64
+	oversizedEntry string
+
+	// onReject is called for every entry that is dropped for being oversized
+	onReject func()
+}
+
+func newChunkLimits(maxChunkBytes int64, oversizedEntry string) (chunkLimits, error) {
+	switch oversizedEntry {
+	case "":
+		oversizedEntry = SendAlone
+	case SendAlone, Reject, Truncate:
+	default:
+		return chunkLimits{}, fmt.Errorf("invalid value '%s' for 'oversized_entry'", oversizedEntry)
+	}
+
+	if maxChunkBytes < 0 {
+		return chunkLimits{}, fmt.Errorf("'max_chunk_bytes' must not be negative")
+	}
+
+	return chunkLimits{
+		maxChunkBytes:  maxChunkBytes,
+		oversizedEntry: oversizedEntry,
+	}, nil
+}
+
+// limitChunkBytes lowers the max chunk size in bytes to limit, if it is smaller
+// than the configured value
+func (c *chunkLimits) limitChunkBytes(limit int64) {
+	if limit > 0 && (c.maxChunkBytes == 0 || limit < c.maxChunkBytes) {
+		c.maxChunkBytes = limit
+	}
+}
+
+// notifyReject calls the reject hook, if one is set
+func (c *chunkLimits) notifyReject() {
+	if c.onReject != nil {
+		c.onReject()
+	}
+}
+
+// setRejectHook sets a function that is called for every rejected oversized entry
+func (c *chunkLimits) setRejectHook(hook func()) {
+	c.onReject = hook
+}
+
+// oversizedAction returns the action to take for an entry of the given size, or an empty
+// string if the entry is not oversized, along with the size of the entry once the action
+// is taken. It truncates the entry if that is the configured action and it is possible.
+func (c *chunkLimits) oversizedAction(e *entry.Entry, size int64) (string, int64) {
+	if c.maxChunkBytes == 0 || size <= c.maxChunkBytes {
+		return "", size
+	}
+
+	if c.oversizedEntry == Truncate {
+		if !truncateEntry(e, size-c.maxChunkBytes) {
+			return Reject, size
+		}
+		truncated, err := entrySize(e)
+		if err != nil {
+			return Reject, size
+		}
+		return Truncate, truncated
+	}
+
+	return c.oversizedEntry, size
+}
+
+// fits returns whether an entry of the given size can be added to a chunk that
+// already contains count entries and chunkBytes bytes
+func (c *chunkLimits) fits(count int, chunkBytes, size int64) bool {
+	return c.maxChunkBytes == 0 || count == 0 || chunkBytes+size <= c.maxChunkBytes
+}
+
+// entrySize returns the size of the entry in the binary entry format. This is much cheaper
+// to compute than the size of its JSON encoding, and since a string record takes up its
+// length plus a small prefix, an oversized record can be truncated by exactly the excess.
+func entrySize(e *entry.Entry) (int64, error) {
+	b, err := e.MarshalBinary()
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(b)), nil
+}
+
+// truncateEntry removes at least excess bytes from the end of a string record. It returns
+// false if the record is not a string or is too short to be truncated.
+func truncateEntry(e *entry.Entry, excess int64) bool {
+	record, ok := e.Record.(string)
+	if !ok || int64(len(record)) <= excess {
+		return false
+	}
+
+	end := len(record) - int(excess)
+	for end > 0 && !utf8.RuneStart(record[end]) {
+		end--
+	}
+	e.Record = record[:end]
+	return true
+}
+
+// payloadLimiter is implemented by buffers that can cap the size of their chunks in bytes
+type payloadLimiter interface {
+	limitChunkBytes(int64)
+}
+
+// rejectNotifier is implemented by buffers that can report the oversized entries they reject
+type rejectNotifier interface {
+	setRejectHook(func())
+}
@@ -0,0 +1,153 @@
+package buffer
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/observiq/stanza/entry"
+	"github.com/observiq/stanza/operator/helper"
+	"github.com/observiq/stanza/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func stringEntry(s string) *entry.Entry {
+	e := entry.New()
+	e.Timestamp = time.Date(2006, 01, 02, 03, 04, 05, 06, time.UTC)
+	e.Record = s
+	return e
+}
+
+func newChunkBuffers(t *testing.T, maxChunkBytes int64, oversizedEntry string) map[string]Buffer {
+	memCfg := NewMemoryBufferConfig()
+	memCfg.MaxChunkBytes = helper.ByteSize(maxChunkBytes)
+	memCfg.OversizedEntry = oversizedEntry
+	mem, err := memCfg.Build(testutil.NewBuildContext(t), "test")
+	require.NoError(t, err)
+
+	diskCfg := NewDiskBufferConfig()
+	diskCfg.Path = testutil.NewTempDir(t)
+	diskCfg.Sync = false
+	diskCfg.MaxChunkBytes = helper.ByteSize(maxChunkBytes)
+	diskCfg.OversizedEntry = oversizedEntry
+	disk, err := diskCfg.Build(testutil.NewBuildContext(t), "test")
+	require.NoError(t, err)
+	t.Cleanup(func() { disk.Close() })
+
+	return map[string]Buffer{
+		"Memory": mem,
+		"Disk":   disk,
+	}
+}
+
+func readRecords(t *testing.T, b Buffer) []string {
+	dst := make([]*entry.Entry, 100)
+	c, n, err := b.Read(dst)
+	require.NoError(t, err)
+	require.NoError(t, c.MarkAllAsFlushed())
+
+	records := make([]string, n)
+	for i, e := range dst[:n] {
+		records[i] = e.Record.(string)
+	}
+	return records
+}
+
+func TestChunkBytes(t *testing.T) {
+	small, err := entrySize(stringEntry("a"))
+	require.NoError(t, err)
+	large := strings.Repeat("x", 300)
+
+	t.Run("LimitsChunk", func(t *testing.T) {
+		for name, b := range newChunkBuffers(t, 3*small, SendAlone) {
+			t.Run(name, func(t *testing.T) {
+				for _, r := range []string{"a", "b", "c", "d", "e"} {
+					require.NoError(t, b.Add(context.Background(), stringEntry(r)))
+				}
+				require.Equal(t, []string{"a", "b", "c"}, readRecords(t, b))
+				require.Equal(t, []string{"d", "e"}, readRecords(t, b))
+			})
+		}
+	})
+
+	t.Run("SendAlone", func(t *testing.T) {
+		for name, b := range newChunkBuffers(t, 3*small, SendAlone) {
+			t.Run(name, func(t *testing.T) {
+				for _, r := range []string{"a", large, "b"} {
+					require.NoError(t, b.Add(context.Background(), stringEntry(r)))
+				}
+				require.Equal(t, []string{"a"}, readRecords(t, b))
+				require.Equal(t, []string{large}, readRecords(t, b))
+				require.Equal(t, []string{"b"}, readRecords(t, b))
+			})
+		}
+	})
+
+	t.Run("Reject", func(t *testing.T) {
+		for name, b := range newChunkBuffers(t, 3*small, Reject) {
+			t.Run(name, func(t *testing.T) {
+				for _, r := range []string{"a", large, "b"} {
+					require.NoError(t, b.Add(context.Background(), stringEntry(r)))
+				}
+				require.Equal(t, []string{"a", "b"}, readRecords(t, b))
+				require.Empty(t, readRecords(t, b))
+			})
+		}
+	})
+
+	t.Run("Truncate", func(t *testing.T) {
+		for name, b := range newChunkBuffers(t, 2*small, Truncate) {
+			t.Run(name, func(t *testing.T) {
+				for _, r := range []string{large, "b"} {
+					require.NoError(t, b.Add(context.Background(), stringEntry(r)))
+				}
+				records := readRecords(t, b)
+				require.Len(t, records, 1)
+				require.True(t, strings.HasPrefix(large, records[0]))
+
+				size, err := entrySize(stringEntry(records[0]))
+				require.NoError(t, err)
+				require.True(t, size <= 2*small)
+
+				require.Equal(t, []string{"b"}, readRecords(t, b))
+			})
+		}
+	})
+
+	t.Run("TruncateEscapedRecord", func(t *testing.T) {
+		// Quotes take up twice their length in JSON, but are truncated to fit the limit, give or
+		// take the shorter length prefixes of the truncated record
+		quotes := strings.Repeat(`"`, 300)
+		for name, b := range newChunkBuffers(t, 2*small, Truncate) {
+			t.Run(name, func(t *testing.T) {
+				require.NoError(t, b.Add(context.Background(), stringEntry(quotes)))
+				records := readRecords(t, b)
+				require.Len(t, records, 1)
+
+				size, err := entrySize(stringEntry(records[0]))
+				require.NoError(t, err)
+				require.True(t, size <= 2*small)
+				require.True(t, size > 2*small-4)
+			})
+		}
+	})
+
+	t.Run("PayloadLimit", func(t *testing.T) {
+		cfg := NewConfig()
+		b, err := cfg.BuildWithPayloadLimit(testutil.NewBuildContext(t), "test", 2*small)
+		require.NoError(t, err)
+		for _, r := range []string{"a", "b", "c"} {
+			require.NoError(t, b.Add(context.Background(), stringEntry(r)))
+		}
+		require.Equal(t, []string{"a", "b"}, readRecords(t, b))
+		require.Equal(t, []string{"c"}, readRecords(t, b))
+	})
+
+	t.Run("InvalidOversizedEntry", func(t *testing.T) {
+		cfg := NewMemoryBufferConfig()
+		cfg.OversizedEntry = "invalid"
+		_, err := cfg.Build(testutil.NewBuildContext(t), "test")
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,86 @@
+package buffer
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+
+	"github.com/observiq/stanza/entry"
+)
+
+// These are the codecs that buffers can use to store entries
+const (
+	// BinaryCodec stores entries in the binary entry format
+	BinaryCodec = "binary"
+
+	// JSONCodec stores entries as lines of JSON
+	JSONCodec = "json"
+)
+
+// validateCodec returns the codec to use for a configured codec name. JSON is the default,
+// so that buffer files and databases can still be read after downgrading to a version of
+// stanza that does not know the binary entry format.
+func validateCodec(codec string) (string, error) {
+	switch codec {
+	case "":
+		return JSONCodec, nil
+	case BinaryCodec, JSONCodec:
+		return codec, nil
+	default:
+		return "", fmt.Errorf("invalid value '%s' for 'codec'", codec)
+	}
+}
+
+// appendEntry appends the entry encoded with the codec to b
+func appendEntry(codec string, b []byte, e *entry.Entry) ([]byte, error) {
+	if codec == JSONCodec {
+		encoded, err := json.Marshal(e)
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, encoded...)
+		return append(b, '\n'), nil
+	}
+	return e.AppendBinary(b)
+}
+
+// decodeEntry decodes a single entry stored with any codec
+func decodeEntry(data []byte) (*entry.Entry, error) {
+	var e entry.Entry
+	if entry.IsBinary(data) {
+		if err := e.UnmarshalBinary(data); err != nil {
+			return nil, err
+		}
+		return &e, nil
+	}
+
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// decodeNext reads the next entry stored with any codec from r. It returns the entry, the
+// number of bytes it takes up, and whether it was stored in the binary entry format.
+func decodeNext(r *bufio.Reader) (*entry.Entry, int64, bool, error) {
+	first, err := r.Peek(1)
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	if first[0] == entry.BinaryMagic {
+		e, n, err := entry.ReadBinary(r)
+		return e, n, true, err
+	}
+
+	line, err := r.ReadBytes('\n')
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	var e entry.Entry
+	if err := json.Unmarshal(line, &e); err != nil {
+		return nil, 0, false, err
+	}
+	return &e, int64(len(line)), false, nil
+}
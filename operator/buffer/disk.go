@@ -1,9 +1,8 @@
 package buffer
 
 import (
-	"bytes"
+	"bufio"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -14,6 +13,7 @@ import (
 	"github.com/observiq/stanza/entry"
 	"github.com/observiq/stanza/operator"
 	"github.com/observiq/stanza/operator/helper"
+	"go.uber.org/zap"
 	"golang.org/x/sync/semaphore"
 )
 
@@ -34,35 +34,92 @@ type DiskBufferConfig struct {
 
 	MaxChunkDelay helper.Duration `json:"max_delay"   yaml:"max_delay"`
 	MaxChunkSize  uint            `json:"max_chunk_size" yaml:"max_chunk_size"`
+
+	// MaxChunkBytes is the maximum size in bytes of the entries in a chunk, measured
+	// in the binary entry encoding. A value of 0 means chunks are only limited by MaxChunkSize.
+	MaxChunkBytes helper.ByteSize `json:"max_chunk_bytes,omitempty" yaml:"max_chunk_bytes,omitempty"`
+
+	// OversizedEntry is the action taken when a single entry is larger than MaxChunkBytes
+	OversizedEntry string `json:"oversized_entry,omitempty" yaml:"oversized_entry,omitempty"`
+
+	// Priority splits the buffer into lanes that are read by priority. Each lane
+	// is stored in a subdirectory of Path named after the lane.
+	Priority *PriorityConfig `json:"priority,omitempty" yaml:"priority,omitempty"`
+
+	// Codec is the format that new entries are written in. Entries written in
+	// either format can always be read.
+	Codec string `json:"codec,omitempty" yaml:"codec,omitempty"`
 }
 
 // NewDiskBufferConfig creates a new default disk buffer config
 func NewDiskBufferConfig() *DiskBufferConfig {
 	return &DiskBufferConfig{
-		Type:          "disk",
-		MaxSize:       1 << 32, // 4GiB
-		Sync:          true,
-		MaxChunkDelay: helper.NewDuration(time.Second),
-		MaxChunkSize:  1000,
+		Type:           "disk",
+		MaxSize:        1 << 32, // 4GiB
+		Sync:           true,
+		MaxChunkDelay:  helper.NewDuration(time.Second),
+		MaxChunkSize:   1000,
+		OversizedEntry: SendAlone,
 	}
 }
 
 // Build creates a new Buffer from a DiskBufferConfig
-func (c DiskBufferConfig) Build(context operator.BuildContext, _ string) (Buffer, error) {
-	maxSize := c.MaxSize
-	if maxSize == 0 {
-		maxSize = 1 << 32
+func (c DiskBufferConfig) Build(context operator.BuildContext, pluginID string) (Buffer, error) {
+	if c.MaxSize == 0 {
+		c.MaxSize = 1 << 32
 	}
 
 	if c.Path == "" {
 		return nil, fmt.Errorf("missing required field 'path'")
 	}
-	b := NewDiskBuffer(int64(maxSize))
+
+	if c.Priority != nil {
+		// The lanes share max_size, so that any lane can use the space the others leave free
+		diskSize := semaphore.NewWeighted(int64(c.MaxSize))
+		return c.Priority.build(defaultPriorityMaxEntries, c.MaxChunkSize, c.MaxChunkDelay.Raw(), context.Logger.SugaredLogger, func(name string, _ int) (Buffer, error) {
+			laneCfg := c
+			laneCfg.Priority = nil
+			laneCfg.Path = filepath.Join(c.Path, name)
+			if err := os.MkdirAll(laneCfg.Path, 0755); err != nil {
+				return nil, err
+			}
+			b, err := laneCfg.build(context, diskSize)
+			if err != nil {
+				return nil, err
+			}
+			return b, nil
+		})
+	}
+
+	b, err := c.build(context, semaphore.NewWeighted(int64(c.MaxSize)))
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// build creates a DiskBuffer that reserves its space on disk from diskSize
+func (c DiskBufferConfig) build(context operator.BuildContext, diskSize *semaphore.Weighted) (*DiskBuffer, error) {
+	limits, err := newChunkLimits(int64(c.MaxChunkBytes), c.OversizedEntry)
+	if err != nil {
+		return nil, err
+	}
+
+	codec, err := validateCodec(c.Codec)
+	if err != nil {
+		return nil, err
+	}
+
+	b := NewDiskBuffer(int64(c.MaxSize))
+	b.diskSizeSemaphore = diskSize
 	if err := b.Open(c.Path, c.Sync); err != nil {
 		return nil, err
 	}
 	b.maxChunkSize = c.MaxChunkSize
 	b.maxChunkDelay = c.MaxChunkDelay.Raw()
+	b.chunkLimits = limits
+	b.codec = codec
+	b.logger = context.Logger.SugaredLogger
 	return b, nil
 }
 
@@ -103,6 +160,12 @@ type DiskBuffer struct {
 
 	maxChunkDelay time.Duration
 	maxChunkSize  uint
+	chunkLimits
+
+	// codec is the format that entries are written in
+	codec string
+
+	logger *zap.SugaredLogger
 }
 
 // NewDiskBuffer creates a new DiskBuffer
@@ -112,6 +175,9 @@ func NewDiskBuffer(maxDiskSize int64) *DiskBuffer {
 		entryAdded:        make(chan int64, 1),
 		copyBuffer:        make([]byte, 1<<16),
 		diskSizeSemaphore: semaphore.NewWeighted(int64(maxDiskSize)),
+		chunkLimits:       chunkLimits{oversizedEntry: SendAlone},
+		codec:             JSONCodec,
+		logger:            zap.NewNop().Sugar(),
 	}
 }
 
@@ -170,37 +236,122 @@ func (d *DiskBuffer) Close() error {
 	return d.data.Close()
 }
 
+// unreadCount returns the number of entries waiting to be read
+func (d *DiskBuffer) unreadCount() int64 {
+	d.Lock()
+	defer d.Unlock()
+	return d.metadata.unreadCount
+}
+
+// Purge discards every entry in the buffer, whether read or unread, and resets
+// the metadata to that of an empty buffer
+func (d *DiskBuffer) Purge() error {
+	d.Lock()
+	defer d.Unlock()
+
+	info, err := d.data.Stat()
+	if err != nil {
+		return err
+	}
+
+	if err = d.data.Truncate(0); err != nil {
+		return err
+	}
+	d.atEnd = false
+	d.diskSizeSemaphore.Release(info.Size())
+	d.flushedBytes = 0
+
+	d.metadata.read = d.metadata.read[:0]
+	d.metadata.unreadStartOffset = 0
+	d.metadata.deadRangeStart = 0
+	d.metadata.deadRangeLength = 0
+	d.addUnreadCount(-d.metadata.unreadCount)
+	return d.metadata.Sync()
+}
+
 // Add adds an entry to the buffer, blocking until it is either added or the context
 // is cancelled.
 func (d *DiskBuffer) Add(ctx context.Context, newEntry *entry.Entry) error {
-	var buf bytes.Buffer
-	var err error
-	enc := json.NewEncoder(&buf)
-	if err := enc.Encode(newEntry); err != nil {
+	buf, err := appendEntry(d.codec, nil, newEntry)
+	if err != nil {
 		return err
 	}
+	if int64(len(buf)) > d.maxBytes {
+		return fmt.Errorf("entry of %d bytes is larger than the buffer's max size of %d bytes", len(buf), d.maxBytes)
+	}
 
-	if err = d.diskSizeSemaphore.Acquire(ctx, int64(buf.Len())); err != nil {
+	if err = d.diskSizeSemaphore.Acquire(ctx, int64(len(buf))); err != nil {
 		return err
 	}
 
+	return d.write(buf, 1)
+}
+
+// tryAdd adds an entry only if there is space for it on disk without waiting.
+// It returns whether the entry was added.
+func (d *DiskBuffer) tryAdd(newEntry *entry.Entry) (bool, error) {
+	buf, err := appendEntry(d.codec, nil, newEntry)
+	if err != nil {
+		return false, err
+	}
+	if int64(len(buf)) > d.maxBytes {
+		return false, fmt.Errorf("entry of %d bytes is larger than the buffer's max size of %d bytes", len(buf), d.maxBytes)
+	}
+
+	if !d.diskSizeSemaphore.TryAcquire(int64(len(buf))) {
+		return false, nil
+	}
+
+	return true, d.write(buf, 1)
+}
+
+// write appends n encoded entries to the end of the data file. Their space must
+// already be reserved.
+func (d *DiskBuffer) write(buf []byte, n int64) error {
 	d.Lock()
 	defer d.Unlock()
 
 	// Seek to end of the file if we're not there
-	if err = d.seekToEnd(); err != nil {
+	if err := d.seekToEnd(); err != nil {
 		return err
 	}
 
-	if _, err = d.data.Write(buf.Bytes()); err != nil {
+	if _, err := d.data.Write(buf); err != nil {
 		return err
 	}
 
-	d.addUnreadCount(1)
-
+	d.addUnreadCount(n)
 	return nil
 }
 
+// AddBatch adds a batch of entries to the buffer with a single write, blocking until they
+// are all added or the context is cancelled. A batch that is larger than the buffer's
+// max size is added one entry at a time.
+func (d *DiskBuffer) AddBatch(ctx context.Context, entries []*entry.Entry) error {
+	var buf []byte
+	for _, e := range entries {
+		var err error
+		if buf, err = appendEntry(d.codec, buf, e); err != nil {
+			return err
+		}
+	}
+
+	if int64(len(buf)) > d.maxBytes {
+		for _, e := range entries {
+			if err := d.Add(ctx, e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := d.diskSizeSemaphore.Acquire(ctx, int64(len(buf))); err != nil {
+		return err
+	}
+
+	return d.write(buf, int64(len(entries)))
+}
+
 // addUnreadCount adds i to the unread count and notifies any callers of
 // ReadWait that an entry has been added. The disk buffer lock must be held when
 // calling this.
@@ -275,30 +426,69 @@ func (d *DiskBuffer) Read(dst []*entry.Entry) (f Clearer, i int, err error) {
 		return nil, 0, fmt.Errorf("seek to unread: %s", err)
 	}
 
-	readCount := min(len(dst), int(d.metadata.unreadCount))
-	newRead := make([]*readEntry, readCount)
+	// Entries are read until dst is full, there are no unread entries left, or the
+	// next entry would not fit in the chunk. Rejected oversized entries are consumed
+	// and marked as flushed, but not returned.
+	readCount := 0
+	consumed := 0
+	newRead := make([]*readEntry, 0, min(len(dst), int(d.metadata.unreadCount)))
+	returned := make([]*readEntry, 0, cap(newRead))
+	var chunkBytes int64
 
-	dec := json.NewDecoder(d.data)
+	r := bufio.NewReader(d.data)
 	startOffset := d.metadata.unreadStartOffset
-	for i := 0; i < readCount; i++ {
-		// Decode an entry from the file
-		var entry entry.Entry
-		err := dec.Decode(&entry)
+	for readCount < len(dst) && int64(consumed) < d.metadata.unreadCount {
+		// Decode an entry from the file. The file may contain a mix of codecs if
+		// the codec was changed while entries were buffered.
+		entry, length, isBinary, err := decodeNext(r)
 		if err != nil {
 			return nil, 0, fmt.Errorf("decode: %s", err)
 		}
-		dst[i] = &entry
 
-		// Calculate the end offset of the entry. We add one because
-		// the decoder doesn't consume the newline at the end of every entry
-		endOffset := d.metadata.unreadStartOffset + dec.InputOffset() + 1
-		newRead[i] = &readEntry{
+		endOffset := startOffset + length
+		newEntry := &readEntry{
 			startOffset: startOffset,
-			length:      endOffset - startOffset,
+			length:      length,
+		}
+
+		// Chunk sizes are measured in the binary entry format, which is how entries are
+		// stored by the binary codec. Only entries stored as JSON need to be measured.
+		size := newEntry.length
+		if !isBinary && d.maxChunkBytes > 0 {
+			if size, err = entrySize(entry); err != nil {
+				d.logger.Warnw("Failed to measure entry size", "error", err)
+			}
+		}
+
+		action, size := d.oversizedAction(entry, size)
+		if action == Reject {
+			d.logger.Warnw("Dropping entry larger than max_chunk_bytes", "size", size, "max_chunk_bytes", d.maxChunkBytes)
+			d.notifyReject()
+			newEntry.flushed = true
+			d.flushedBytes += newEntry.length
+			newRead = append(newRead, newEntry)
+			consumed++
+			startOffset = endOffset
+			continue
 		}
 
+		if !d.fits(readCount, chunkBytes, size) || (action == SendAlone && readCount > 0) {
+			break
+		}
+		chunkBytes += size
+
+		dst[readCount] = entry
+		newRead = append(newRead, newEntry)
+		returned = append(returned, newEntry)
+		readCount++
+		consumed++
+
 		// The start offset of the next entry is the end offset of the current
 		startOffset = endOffset
+
+		if action == SendAlone {
+			break
+		}
 	}
 
 	// Set the offset for the next unread entry
@@ -308,9 +498,9 @@ func (d *DiskBuffer) Read(dst []*entry.Entry) (f Clearer, i int, err error) {
 	d.metadata.read = append(d.metadata.read, newRead...)
 
 	// Remove the read entries from the unread count
-	d.addUnreadCount(-int64(readCount))
+	d.addUnreadCount(-int64(consumed))
 
-	return d.newClearer(newRead), readCount, nil
+	return d.newClearer(returned), readCount, nil
 }
 
 // newFlushFunc returns a function that marks read entries as flushed
@@ -350,6 +540,18 @@ func (dc *diskClearer) MarkRangeAsFlushed(start, end uint) error {
 	return dc.buffer.checkCompact()
 }
 
+// compactFlushed compacts the buffer if it holds any flushed entries, returning whether
+// it did. Flushed entries only give their space back once they are compacted.
+func (d *DiskBuffer) compactFlushed() (bool, error) {
+	d.Lock()
+	flushed := d.flushedBytes
+	d.Unlock()
+	if flushed == 0 {
+		return false, nil
+	}
+	return true, d.Compact()
+}
+
 // checkCompact checks if a compaction should be performed, then kicks one off
 func (d *DiskBuffer) checkCompact() error {
 	d.Lock()
@@ -0,0 +1,159 @@
+package buffer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/observiq/stanza/entry"
+)
+
+// DiskBufferInfo is a read-only summary of the state of a disk buffer directory
+type DiskBufferInfo struct {
+	// DataSize is the size in bytes of the data file
+	DataSize int64 `json:"data_size"`
+
+	// ReadCount is the number of entries that have been read but not yet compacted
+	ReadCount int64 `json:"read_count"`
+
+	// FlushedCount is the number of read entries that have been marked as flushed
+	FlushedCount int64 `json:"flushed_count"`
+
+	// UnreadCount is the number of entries that have not yet been read
+	UnreadCount int64 `json:"unread_count"`
+
+	// UnreadStartOffset is the offset in the data file where the unread entries start
+	UnreadStartOffset int64 `json:"unread_start_offset"`
+
+	// DeadRangeStart and DeadRangeLength describe a range left behind by an
+	// interrupted compaction. It is removed the next time the buffer is opened.
+	DeadRangeStart  int64 `json:"dead_range_start"`
+	DeadRangeLength int64 `json:"dead_range_length"`
+}
+
+// EntryCount returns the number of entries stored in the buffer that have not been flushed
+func (i *DiskBufferInfo) EntryCount() int64 {
+	return i.UnreadCount + i.ReadCount - i.FlushedCount
+}
+
+// InspectDiskBuffer reads the metadata of the disk buffer stored at path without
+// modifying it. It should only be used while no agent has the buffer open.
+func InspectDiskBuffer(path string) (*DiskBufferInfo, error) {
+	dataInfo, err := os.Stat(filepath.Join(path, "data"))
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := readMetadata(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &DiskBufferInfo{
+		DataSize:          dataInfo.Size(),
+		ReadCount:         int64(len(m.read)),
+		UnreadCount:       m.unreadCount,
+		UnreadStartOffset: m.unreadStartOffset,
+		DeadRangeStart:    m.deadRangeStart,
+		DeadRangeLength:   m.deadRangeLength,
+	}
+	for _, readEntry := range m.read {
+		if readEntry.flushed {
+			info.FlushedCount++
+		}
+	}
+
+	return info, nil
+}
+
+// DumpDiskBuffer calls handle for each unflushed entry in the disk buffer stored at path,
+// in the order they would be read. Unlike opening the buffer, it does not compact the
+// files or modify them in any other way. It should only be used while no agent has the
+// buffer open.
+func DumpDiskBuffer(path string, handle func(*entry.Entry) error) error {
+	m, err := readMetadata(path)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.Open(filepath.Join(path, "data"))
+	if err != nil {
+		return err
+	}
+	defer data.Close()
+
+	// Offsets in the metadata are where entries will be once the dead range is removed
+	r := &deadRangeReader{data, m.deadRangeStart, m.deadRangeLength}
+
+	for _, readEntry := range m.read {
+		if readEntry.flushed {
+			continue
+		}
+
+		e, _, _, err := decodeNext(bufio.NewReader(io.NewSectionReader(r, readEntry.startOffset, readEntry.length)))
+		if err != nil {
+			return fmt.Errorf("decode: %s", err)
+		}
+		if err := handle(e); err != nil {
+			return err
+		}
+	}
+
+	br := bufio.NewReader(io.NewSectionReader(r, m.unreadStartOffset, math.MaxInt64-m.unreadStartOffset))
+	for i := int64(0); i < m.unreadCount; i++ {
+		e, _, _, err := decodeNext(br)
+		if err != nil {
+			return fmt.Errorf("decode: %s", err)
+		}
+		if err := handle(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readMetadata reads the metadata of the disk buffer stored at path without opening it
+// for writing
+func readMetadata(path string) (*Metadata, error) {
+	metadataFile, err := os.Open(filepath.Join(path, "metadata"))
+	if err != nil {
+		return nil, err
+	}
+	defer metadataFile.Close()
+
+	m := &Metadata{}
+	if err := m.UnmarshalBinary(metadataFile); err != nil {
+		return nil, fmt.Errorf("read metadata file: %s", err)
+	}
+	return m, nil
+}
+
+// deadRangeReader reads a data file as if its dead range had been removed
+type deadRangeReader struct {
+	file       io.ReaderAt
+	deadStart  int64
+	deadLength int64
+}
+
+// ReadAt reads from the data file, skipping over the dead range
+func (r *deadRangeReader) ReadAt(p []byte, off int64) (int, error) {
+	if r.deadLength == 0 || off+int64(len(p)) <= r.deadStart {
+		return r.file.ReadAt(p, off)
+	}
+
+	if off >= r.deadStart {
+		return r.file.ReadAt(p, off+r.deadLength)
+	}
+
+	// The read spans the start of the dead range
+	before := r.deadStart - off
+	n, err := r.file.ReadAt(p[:before], off)
+	if err != nil {
+		return n, err
+	}
+	m, err := r.file.ReadAt(p[before:], r.deadStart+r.deadLength)
+	return n + m, err
+}
@@ -3,8 +3,11 @@ package buffer
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"math/rand"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -175,9 +178,11 @@ func TestDiskBuffer(t *testing.T) {
 
 	t.Run("AddTimesOut", func(t *testing.T) {
 		t.Parallel()
-		b := NewDiskBuffer(100) // Enough space for 1, but not 2 entries
+		encoded, err := appendEntry(JSONCodec, nil, entry.New())
+		require.NoError(t, err)
+		b := NewDiskBuffer(int64(len(encoded)) * 3 / 2) // Enough space for 1, but not 2 entries
 		dir := testutil.NewTempDir(t)
-		err := b.Open(dir, false)
+		err = b.Open(dir, false)
 		require.NoError(t, err)
 
 		// Add a first entry
@@ -201,6 +206,11 @@ func TestDiskBuffer(t *testing.T) {
 		// Now there should be space for another entry
 		err = b.Add(context.Background(), entry.New())
 		require.NoError(t, err)
+
+		// An entry that could never fit is rejected instead of blocking forever
+		large := entry.New()
+		large.Record = strings.Repeat("a", len(encoded))
+		require.Error(t, b.Add(context.Background(), large))
 	})
 
 	t.Run("Write1kRandomFlushReadCompact", func(t *testing.T) {
@@ -241,6 +251,127 @@ func TestDiskBuffer(t *testing.T) {
 	})
 }
 
+func TestDiskBufferPurge(t *testing.T) {
+	b := NewDiskBuffer(1 << 11)
+	dir := testutil.NewTempDir(t)
+	err := b.Open(dir, false)
+	require.NoError(t, err)
+	defer b.Close()
+
+	writeN(t, b, 10, 0)
+	readN(t, b, 5, 0)
+
+	require.NoError(t, b.Purge())
+
+	dst := make([]*entry.Entry, 10)
+	_, n, err := b.Read(dst)
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+
+	// All of the space should be released after a purge
+	writeN(t, b, 10, 10)
+	readN(t, b, 10, 10)
+}
+
+func TestInspectDiskBuffer(t *testing.T) {
+	b := NewDiskBuffer(1 << 20)
+	dir := testutil.NewTempDir(t)
+	err := b.Open(dir, false)
+	require.NoError(t, err)
+
+	writeN(t, b, 10, 0)
+	flushN(t, b, 2, 0)
+	readN(t, b, 3, 2)
+	require.NoError(t, b.Close())
+
+	info, err := InspectDiskBuffer(dir)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), info.ReadCount)
+	require.Equal(t, int64(2), info.FlushedCount)
+	require.Equal(t, int64(5), info.UnreadCount)
+	require.Equal(t, int64(8), info.EntryCount())
+	require.Equal(t, int64(0), info.DeadRangeLength)
+	require.True(t, info.DataSize > 0)
+	require.True(t, info.UnreadStartOffset > 0)
+
+	_, err = InspectDiskBuffer(testutil.NewTempDir(t))
+	require.Error(t, err)
+}
+
+func TestDumpDiskBuffer(t *testing.T) {
+	dumpAll := func(dir string) []*entry.Entry {
+		var entries []*entry.Entry
+		require.NoError(t, DumpDiskBuffer(dir, func(e *entry.Entry) error {
+			entries = append(entries, e)
+			return nil
+		}))
+		return entries
+	}
+
+	t.Run("Unflushed", func(t *testing.T) {
+		b := NewDiskBuffer(1 << 20)
+		dir := testutil.NewTempDir(t)
+		require.NoError(t, b.Open(dir, false))
+
+		writeN(t, b, 10, 0)
+		flushN(t, b, 2, 0)
+		readN(t, b, 3, 2)
+		require.NoError(t, b.Close())
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, "data"))
+		require.NoError(t, err)
+		metadata, err := ioutil.ReadFile(filepath.Join(dir, "metadata"))
+		require.NoError(t, err)
+
+		entries := dumpAll(dir)
+		require.Len(t, entries, 8)
+		for i, e := range entries {
+			require.Equal(t, intEntry(i+2), e)
+		}
+
+		// The files are not modified
+		newData, err := ioutil.ReadFile(filepath.Join(dir, "data"))
+		require.NoError(t, err)
+		require.Equal(t, data, newData)
+		newMetadata, err := ioutil.ReadFile(filepath.Join(dir, "metadata"))
+		require.NoError(t, err)
+		require.Equal(t, metadata, newMetadata)
+	})
+
+	t.Run("DeadRange", func(t *testing.T) {
+		b := NewDiskBuffer(1 << 20)
+		dir := testutil.NewTempDir(t)
+		require.NoError(t, b.Open(dir, false))
+		writeN(t, b, 5, 0)
+		require.NoError(t, b.Close())
+
+		// Leave a dead range in the middle of the second entry, as an interrupted
+		// compaction can
+		dataPath := filepath.Join(dir, "data")
+		data, err := ioutil.ReadFile(dataPath)
+		require.NoError(t, err)
+		deadStart := int64(len(data)/5 + 3)
+		dead := []byte("dead range")
+		withDead := append(append(append([]byte{}, data[:deadStart]...), dead...), data[deadStart:]...)
+		require.NoError(t, ioutil.WriteFile(dataPath, withDead, 0600))
+
+		m, err := OpenMetadata(filepath.Join(dir, "metadata"), false)
+		require.NoError(t, err)
+		require.NoError(t, m.setDeadRange(deadStart, int64(len(dead))))
+		require.NoError(t, m.Close())
+
+		entries := dumpAll(dir)
+		require.Len(t, entries, 5)
+		for i, e := range entries {
+			require.Equal(t, intEntry(i), e)
+		}
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		require.Error(t, DumpDiskBuffer(testutil.NewTempDir(t), func(*entry.Entry) error { return nil }))
+	})
+}
+
 func TestDiskBufferBuild(t *testing.T) {
 	t.Run("Default", func(t *testing.T) {
 		cfg := NewDiskBufferConfig()
@@ -253,7 +384,131 @@ func TestDiskBufferBuild(t *testing.T) {
 		require.Equal(t, diskBuffer.maxBytes, int64(1<<32))
 		require.Equal(t, diskBuffer.flushedBytes, int64(0))
 		require.Len(t, diskBuffer.copyBuffer, 1<<16)
+		require.Equal(t, JSONCodec, diskBuffer.codec)
+	})
+
+	t.Run("InvalidCodec", func(t *testing.T) {
+		cfg := NewDiskBufferConfig()
+		cfg.Path = testutil.NewTempDir(t)
+		cfg.Codec = "xml"
+		_, err := cfg.Build(testutil.NewBuildContext(t), "test")
+		require.Error(t, err)
+	})
+}
+
+func TestDiskBufferCodecs(t *testing.T) {
+	t.Run("JSON", func(t *testing.T) {
+		b := openBuffer(t)
+		writeN(t, b, 10, 0)
+
+		info, err := b.data.Stat()
+		require.NoError(t, err)
+		require.NotZero(t, info.Size())
+
+		// Entries are stored as lines of JSON by default
+		first := make([]byte, 1)
+		_, err = b.data.ReadAt(first, 0)
+		require.NoError(t, err)
+		require.Equal(t, byte('{'), first[0])
+
+		readN(t, b, 10, 0)
+	})
+
+	t.Run("Binary", func(t *testing.T) {
+		b := openBuffer(t)
+		b.codec = BinaryCodec
+		writeN(t, b, 10, 0)
+
+		first := make([]byte, 1)
+		_, err := b.data.ReadAt(first, 0)
+		require.NoError(t, err)
+		require.Equal(t, entry.BinaryMagic, first[0])
+
+		readN(t, b, 10, 0)
+	})
+
+	t.Run("ChangedCodec", func(t *testing.T) {
+		dir := testutil.NewTempDir(t)
+		b := NewDiskBuffer(1 << 20)
+		b.codec = BinaryCodec
+		require.NoError(t, b.Open(dir, false))
+		writeN(t, b, 10, 0)
+		readN(t, b, 5, 0)
+		require.NoError(t, b.Close())
+
+		// Entries written by a buffer using the binary codec are still read after
+		// switching to the JSON codec
+		b2 := NewDiskBuffer(1 << 20)
+		require.NoError(t, b2.Open(dir, false))
+		defer b2.Close()
+		writeN(t, b2, 10, 10)
+		readN(t, b2, 20, 0)
+	})
+
+	t.Run("MaxChunkBytesMeasuredAsBinary", func(t *testing.T) {
+		for _, codec := range []string{JSONCodec, BinaryCodec} {
+			t.Run(codec, func(t *testing.T) {
+				b := openBuffer(t)
+				b.codec = codec
+				e := intEntry(0)
+				size, err := entrySize(e)
+				require.NoError(t, err)
+				b.maxChunkBytes = 2 * size
+
+				// Entries are measured the same way whatever format they are stored in
+				writeN(t, b, 3, 0)
+				dst := make([]*entry.Entry, 3)
+				_, n, err := b.Read(dst)
+				require.NoError(t, err)
+				require.Equal(t, 2, n)
+			})
+		}
+	})
+}
+
+func TestDiskBufferAddBatch(t *testing.T) {
+	batchN := func(n, start int) []*entry.Entry {
+		entries := make([]*entry.Entry, 0, n)
+		for i := start; i < n+start; i++ {
+			entries = append(entries, intEntry(i))
+		}
+		return entries
+	}
+
+	t.Run("Simple", func(t *testing.T) {
+		b := openBuffer(t)
+		require.NoError(t, AddBatch(context.Background(), b, batchN(10, 0)))
+		writeN(t, b, 5, 10)
+		readN(t, b, 15, 0)
+	})
+
+	t.Run("LargerThanBuffer", func(t *testing.T) {
+		b := NewDiskBuffer(200)
+		require.NoError(t, b.Open(testutil.NewTempDir(t), false))
+		defer b.Close()
+
+		// The batch doesn't fit at once, so entries are added as space frees up
+		done := make(chan error)
+		go func() {
+			done <- AddBatch(context.Background(), b, batchN(10, 0))
+		}()
+
+		for i := 0; i < 10; {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			dst := make([]*entry.Entry, 1)
+			c, n, err := b.ReadWait(ctx, dst)
+			cancel()
+			require.NoError(t, err)
+			for j := 0; j < n; j++ {
+				require.Equal(t, intEntry(i+j), dst[j])
+			}
+			require.NoError(t, c.MarkAllAsFlushed())
+			require.NoError(t, b.Compact())
+			i += n
+		}
+		require.NoError(t, <-done)
 	})
+
 }
 
 func BenchmarkDiskBuffer(b *testing.B) {
@@ -329,3 +584,35 @@ func BenchmarkDiskBuffer(b *testing.B) {
 		wg.Wait()
 	})
 }
+
+func BenchmarkDiskBufferAddBatch(b *testing.B) {
+	entries := make([]*entry.Entry, 100)
+	for i := range entries {
+		entries[i] = entry.New()
+		entries[i].Record = "test log"
+	}
+
+	b.Run("Add", func(b *testing.B) {
+		buffer := NewDiskBuffer(1 << 30)
+		require.NoError(b, buffer.Open(testutil.NewTempDir(b), false))
+		b.Cleanup(func() { buffer.Close() })
+		ctx := context.Background()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for _, e := range entries {
+				panicOnErr(buffer.Add(ctx, e))
+			}
+		}
+	})
+
+	b.Run("AddBatch", func(b *testing.B) {
+		buffer := NewDiskBuffer(1 << 30)
+		require.NoError(b, buffer.Open(testutil.NewTempDir(b), false))
+		b.Cleanup(func() { buffer.Close() })
+		ctx := context.Background()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			panicOnErr(buffer.AddBatch(ctx, entries))
+		}
+	})
+}
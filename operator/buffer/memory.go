@@ -1,10 +1,8 @@
 package buffer
 
 import (
-	"bytes"
 	"context"
 	"encoding/binary"
-	"encoding/json"
 	"fmt"
 	"sync"
 	"sync/atomic"
@@ -15,6 +13,7 @@ import (
 	"github.com/observiq/stanza/operator"
 	"github.com/observiq/stanza/operator/helper"
 	"go.etcd.io/bbolt"
+	"go.uber.org/zap"
 	"golang.org/x/sync/semaphore"
 )
 
@@ -24,21 +23,54 @@ type MemoryBufferConfig struct {
 	MaxEntries    int             `json:"max_entries" yaml:"max_entries"`
 	MaxChunkDelay helper.Duration `json:"max_delay"   yaml:"max_delay"`
 	MaxChunkSize  uint            `json:"max_chunk_size" yaml:"max_chunk_size"`
+
+	// MaxChunkBytes is the maximum size in bytes of the entries in a chunk, measured
+	// in the binary entry encoding. A value of 0 means chunks are only limited by MaxChunkSize.
+	MaxChunkBytes helper.ByteSize `json:"max_chunk_bytes,omitempty" yaml:"max_chunk_bytes,omitempty"`
+
+	// OversizedEntry is the action taken when a single entry is larger than MaxChunkBytes
+	OversizedEntry string `json:"oversized_entry,omitempty" yaml:"oversized_entry,omitempty"`
+
+	// Priority splits the buffer into lanes that are read by priority
+	Priority *PriorityConfig `json:"priority,omitempty" yaml:"priority,omitempty"`
+
+	// Codec is the format that entries are saved to the database in on shutdown
+	Codec string `json:"codec,omitempty" yaml:"codec,omitempty"`
 }
 
 // NewMemoryBufferConfig creates a new default MemoryBufferConfig
 func NewMemoryBufferConfig() *MemoryBufferConfig {
 	return &MemoryBufferConfig{
-		Type:          "memory",
-		MaxEntries:    1 << 20,
-		MaxChunkDelay: helper.NewDuration(time.Second),
-		MaxChunkSize:  1000,
+		Type:           "memory",
+		MaxEntries:     1 << 20,
+		MaxChunkDelay:  helper.NewDuration(time.Second),
+		MaxChunkSize:   1000,
+		OversizedEntry: SendAlone,
 	}
 }
 
 // Build builds a MemoryBufferConfig into a Buffer, loading any entries that were previously unflushed
 // back into memory
 func (c MemoryBufferConfig) Build(context operator.BuildContext, pluginID string) (Buffer, error) {
+	if c.Priority != nil {
+		return c.Priority.build(c.MaxEntries, c.MaxChunkSize, c.MaxChunkDelay.Raw(), context.Logger.SugaredLogger, func(name string, maxEntries int) (Buffer, error) {
+			laneCfg := c
+			laneCfg.Priority = nil
+			laneCfg.MaxEntries = maxEntries
+			return laneCfg.Build(context, pluginID+"/"+name)
+		})
+	}
+
+	limits, err := newChunkLimits(int64(c.MaxChunkBytes), c.OversizedEntry)
+	if err != nil {
+		return nil, err
+	}
+
+	codec, err := validateCodec(c.Codec)
+	if err != nil {
+		return nil, err
+	}
+
 	mb := &MemoryBuffer{
 		db:            context.Database,
 		pluginID:      pluginID,
@@ -47,6 +79,9 @@ func (c MemoryBufferConfig) Build(context operator.BuildContext, pluginID string
 		inFlight:      make(map[uint64]*entry.Entry, c.MaxEntries),
 		maxChunkDelay: c.MaxChunkDelay.Raw(),
 		maxChunkSize:  c.MaxChunkSize,
+		chunkLimits:   limits,
+		codec:         codec,
+		logger:        context.Logger.SugaredLogger,
 	}
 	if err := mb.loadFromDB(); err != nil {
 		return nil, err
@@ -68,6 +103,16 @@ type MemoryBuffer struct {
 	sem           *semaphore.Weighted
 	maxChunkDelay time.Duration
 	maxChunkSize  uint
+	chunkLimits
+	codec string
+
+	// held is an entry that was taken from buf but did not fit in the previous
+	// chunk. It is the first entry returned by the next read.
+	held    *entry.Entry
+	hasHeld int32
+	readMux sync.Mutex
+
+	logger *zap.SugaredLogger
 }
 
 // Add inserts an entry into the memory database, blocking until there is space
@@ -84,23 +129,7 @@ func (m *MemoryBuffer) Add(ctx context.Context, e *entry.Entry) error {
 // or the destination slice is full. The returned function must be called
 // once the entries are flushed to remove them from the memory buffer.
 func (m *MemoryBuffer) Read(dst []*entry.Entry) (Clearer, int, error) {
-	inFlight := make([]uint64, len(dst))
-	i := 0
-	for ; i < len(dst); i++ {
-		select {
-		case e := <-m.buf:
-			dst[i] = e
-			id := atomic.AddUint64(&m.entryID, 1)
-			m.inFlightMux.Lock()
-			m.inFlight[id] = e
-			m.inFlightMux.Unlock()
-			inFlight[i] = id
-		default:
-			return m.newClearer(inFlight[:i]), i, nil
-		}
-	}
-
-	return m.newClearer(inFlight[:i]), i, nil
+	return m.read(nil, false, dst)
 }
 
 // ReadChunk is a thin wrapper around ReadWait that simplifies the call at the expense of an extra allocation
@@ -126,23 +155,89 @@ func (m *MemoryBuffer) ReadChunk(ctx context.Context) ([]*entry.Entry, Clearer,
 // is cancelled. The returned function must be called once the entries are flushed to remove them
 // from the memory buffer
 func (m *MemoryBuffer) ReadWait(ctx context.Context, dst []*entry.Entry) (Clearer, int, error) {
+	return m.read(ctx.Done(), true, dst)
+}
+
+// read reads entries into dst until it is full, the next entry would not fit in the chunk,
+// or there are no entries left. If wait is true, it waits for more entries until done is closed.
+func (m *MemoryBuffer) read(done <-chan struct{}, wait bool, dst []*entry.Entry) (Clearer, int, error) {
+	m.readMux.Lock()
+	defer m.readMux.Unlock()
+
 	inFlightIDs := make([]uint64, len(dst))
+	var chunkBytes int64
 	i := 0
-	for ; i < len(dst); i++ {
+	for i < len(dst) {
+		e, ok := m.next(done, wait)
+		if !ok {
+			break
+		}
+
+		var size int64
+		var action string
+		if m.maxChunkBytes > 0 {
+			var err error
+			if size, err = entrySize(e); err != nil {
+				m.logger.Warnw("Failed to measure entry size", "error", err)
+			}
+
+			action, size = m.oversizedAction(e, size)
+			if action == Reject {
+				m.logger.Warnw("Dropping entry larger than max_chunk_bytes", "size", size, "max_chunk_bytes", m.maxChunkBytes)
+				m.sem.Release(1)
+				m.notifyReject()
+				continue
+			}
+
+			if !m.fits(i, chunkBytes, size) || (action == SendAlone && i > 0) {
+				m.held = e
+				atomic.StoreInt32(&m.hasHeld, 1)
+				break
+			}
+			chunkBytes += size
+		}
+
+		dst[i] = e
+		id := atomic.AddUint64(&m.entryID, 1)
+		m.inFlightMux.Lock()
+		m.inFlight[id] = e
+		m.inFlightMux.Unlock()
+		inFlightIDs[i] = id
+		i++
+
+		if action == SendAlone {
+			break
+		}
+	}
+
+	return m.newClearer(inFlightIDs[:i]), i, nil
+}
+
+// next returns the held entry if there is one, or the next entry in the buffer. The read
+// lock must be held when calling this.
+func (m *MemoryBuffer) next(done <-chan struct{}, wait bool) (*entry.Entry, bool) {
+	if m.held != nil {
+		e := m.held
+		m.held = nil
+		atomic.StoreInt32(&m.hasHeld, 0)
+		return e, true
+	}
+
+	if !wait {
 		select {
 		case e := <-m.buf:
-			dst[i] = e
-			id := atomic.AddUint64(&m.entryID, 1)
-			m.inFlightMux.Lock()
-			m.inFlight[id] = e
-			m.inFlightMux.Unlock()
-			inFlightIDs[i] = id
-		case <-ctx.Done():
-			return m.newClearer(inFlightIDs[:i]), i, nil
+			return e, true
+		default:
+			return nil, false
 		}
 	}
 
-	return m.newClearer(inFlightIDs[:i]), i, nil
+	select {
+	case e := <-m.buf:
+		return e, true
+	case <-done:
+		return nil, false
+	}
 }
 
 type memoryClearer struct {
@@ -182,9 +277,17 @@ func (m *MemoryBuffer) newClearer(ids []uint64) Clearer {
 	}
 }
 
+// unreadCount returns the number of entries waiting to be read
+func (m *MemoryBuffer) unreadCount() int64 {
+	return int64(len(m.buf)) + int64(atomic.LoadInt32(&m.hasHeld))
+}
+
 // Close closes the memory buffer, saving all entries currently in the memory buffer to the
 // agent's database.
 func (m *MemoryBuffer) Close() error {
+	// The read lock is taken before the in flight lock, in the same order as read
+	m.readMux.Lock()
+	defer m.readMux.Unlock()
 	m.inFlightMux.Lock()
 	defer m.inFlightMux.Unlock()
 	return m.db.Update(func(tx *bbolt.Tx) error {
@@ -199,7 +302,15 @@ func (m *MemoryBuffer) Close() error {
 		}
 
 		for k, v := range m.inFlight {
-			if err := putKeyValue(b, k, v); err != nil {
+			if err := putKeyValue(m.codec, b, k, v); err != nil {
+				return err
+			}
+		}
+
+		if held := m.held; held != nil {
+			m.held = nil
+			atomic.StoreInt32(&m.hasHeld, 0)
+			if err := putKeyValue(m.codec, b, atomic.AddUint64(&m.entryID, 1), held); err != nil {
 				return err
 			}
 		}
@@ -207,8 +318,7 @@ func (m *MemoryBuffer) Close() error {
 		for {
 			select {
 			case e := <-m.buf:
-				m.entryID++
-				if err := putKeyValue(b, m.entryID, e); err != nil {
+				if err := putKeyValue(m.codec, b, atomic.AddUint64(&m.entryID, 1), e); err != nil {
 					return err
 				}
 			default:
@@ -218,16 +328,15 @@ func (m *MemoryBuffer) Close() error {
 	})
 }
 
-func putKeyValue(b *bbolt.Bucket, k uint64, v *entry.Entry) error {
-	var buf bytes.Buffer
-	enc := json.NewEncoder(&buf)
+func putKeyValue(codec string, b *bbolt.Bucket, k uint64, v *entry.Entry) error {
 	key := [8]byte{}
-
 	binary.LittleEndian.PutUint64(key[:], k)
-	if err := enc.Encode(v); err != nil {
+
+	value, err := appendEntry(codec, nil, v)
+	if err != nil {
 		return err
 	}
-	return b.Put(key[:], buf.Bytes())
+	return b.Put(key[:], value)
 }
 
 // loadFromDB loads any entries saved to the database previously into the memory buffer,
@@ -249,14 +358,13 @@ func (m *MemoryBuffer) loadFromDB() error {
 				return fmt.Errorf("max_entries is smaller than the number of entries stored in the database")
 			}
 
-			dec := json.NewDecoder(bytes.NewReader(v))
-			var e entry.Entry
-			if err := dec.Decode(&e); err != nil {
+			e, err := decodeEntry(v)
+			if err != nil {
 				return err
 			}
 
 			select {
-			case m.buf <- &e:
+			case m.buf <- e:
 				return nil
 			default:
 				return fmt.Errorf("max_entries is smaller than the number of entries stored in the database")
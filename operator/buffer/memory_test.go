@@ -153,6 +153,22 @@ func TestMemoryBuffer(t *testing.T) {
 		require.Equal(t, 0, n)
 	})
 
+	t.Run("WriteCloseReadBinary", func(t *testing.T) {
+		t.Parallel()
+		bc := testutil.NewBuildContext(t)
+		cfg := NewMemoryBufferConfig()
+		cfg.Codec = BinaryCodec
+		b, err := cfg.Build(bc, "test")
+		require.NoError(t, err)
+		writeN(t, b, 10, 0)
+		require.NoError(t, b.Close())
+
+		// Entries saved in the binary format are loaded by a buffer using the JSON codec
+		b, err = NewMemoryBufferConfig().Build(bc, "test")
+		require.NoError(t, err)
+		readN(t, b, 10, 0)
+	})
+
 	t.Run("AddTimesOut", func(t *testing.T) {
 		t.Parallel()
 		cfg := MemoryBufferConfig{
@@ -214,6 +230,37 @@ func TestMemoryBuffer(t *testing.T) {
 		})
 	})
 
+	t.Run("CloseDuringReadWait", func(t *testing.T) {
+		t.Parallel()
+		b := newMemoryBuffer(t)
+
+		readDone := make(chan struct{})
+		go func() {
+			defer close(readDone)
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			dst := make([]*entry.Entry, 10)
+			_, _, err := b.ReadWait(ctx, dst)
+			require.NoError(t, err)
+		}()
+		time.Sleep(20 * time.Millisecond)
+
+		closeDone := make(chan error)
+		go func() { closeDone <- b.Close() }()
+		time.Sleep(20 * time.Millisecond)
+
+		// The waiting read takes this entry while Close is waiting for it to finish
+		writeN(t, b, 1, 0)
+
+		select {
+		case err := <-closeDone:
+			require.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "Close deadlocked with a waiting read")
+		}
+		<-readDone
+	})
+
 	t.Run("CloseReadUnflushed", func(t *testing.T) {
 		t.Parallel()
 		buildContext := testutil.NewBuildContext(t)
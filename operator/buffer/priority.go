@@ -0,0 +1,499 @@
+package buffer
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/antonmedv/expr"
+	"github.com/antonmedv/expr/vm"
+	"github.com/observiq/stanza/entry"
+	"github.com/observiq/stanza/errors"
+	"github.com/observiq/stanza/operator/helper"
+	"go.uber.org/zap"
+	"golang.org/x/sync/semaphore"
+)
+
+// PriorityConfig configures a buffer to keep entries in separate lanes by priority
+type PriorityConfig struct {
+	// MaxEntries is the maximum number of entries held across all lanes. When it is
+	// reached, entries are dropped from the lowest-priority lane first.
+	MaxEntries int `json:"max_entries,omitempty" yaml:"max_entries,omitempty"`
+
+	// Lanes are listed from highest to lowest priority
+	Lanes []LaneConfig `json:"lanes" yaml:"lanes"`
+}
+
+// LaneConfig is the configuration of a single priority lane
+type LaneConfig struct {
+	// Name identifies the lane. It is used to name the lane's storage.
+	Name string `json:"name" yaml:"name"`
+
+	// MinSeverity matches entries with at least this severity
+	MinSeverity interface{} `json:"min_severity,omitempty" yaml:"min_severity,omitempty"`
+
+	// Expression matches entries for which it evaluates to true
+	Expression string `json:"expr,omitempty" yaml:"expr,omitempty"`
+
+	// Weight is the share of chunks read from this lane when several lanes have entries.
+	// When no lane has a weight, lanes are read in strict priority order. Otherwise, lanes
+	// without a weight have a weight of 1.
+	Weight int `json:"weight,omitempty" yaml:"weight,omitempty"`
+}
+
+// defaultPriorityMaxEntries is the number of entries a disk buffer with priority lanes
+// holds across its lanes when max_entries is not set
+const defaultPriorityMaxEntries = 1 << 20
+
+var laneNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// build creates a PriorityBuffer, using buildLane to create the buffer backing each lane
+func (c *PriorityConfig) build(defaultMaxEntries int, maxChunkSize uint, maxChunkDelay time.Duration, logger *zap.SugaredLogger, buildLane func(name string, maxEntries int) (Buffer, error)) (*PriorityBuffer, error) {
+	if len(c.Lanes) == 0 {
+		return nil, fmt.Errorf("priority buffers require at least one lane")
+	}
+
+	maxEntries := c.MaxEntries
+	if maxEntries == 0 {
+		maxEntries = defaultMaxEntries
+	}
+
+	p := &PriorityBuffer{
+		lanes:         make([]*priorityLane, 0, len(c.Lanes)),
+		sem:           semaphore.NewWeighted(int64(maxEntries)),
+		added:         make(chan struct{}, 1),
+		maxChunkSize:  maxChunkSize,
+		maxChunkDelay: maxChunkDelay,
+		logger:        logger,
+	}
+
+	for _, laneCfg := range c.Lanes {
+		if laneCfg.Weight != 0 {
+			p.weighted = true
+		}
+	}
+
+	names := make(map[string]bool, len(c.Lanes))
+	for _, laneCfg := range c.Lanes {
+		lane, err := laneCfg.build()
+		if err != nil {
+			p.Close()
+			return nil, err
+		}
+		if names[lane.name] {
+			p.Close()
+			return nil, fmt.Errorf("duplicate lane name '%s'", lane.name)
+		}
+		names[lane.name] = true
+
+		if lane.Buffer, err = buildLane(lane.name, maxEntries); err != nil {
+			p.Close()
+			return nil, errors.Wrap(err, "build lane "+lane.name)
+		}
+		if notifier, ok := lane.Buffer.(rejectNotifier); ok {
+			notifier.setRejectHook(func() { p.sem.Release(1) })
+		}
+		p.lanes = append(p.lanes, lane)
+	}
+
+	// Account for entries that were persisted by a previous run
+	for _, lane := range p.lanes {
+		if ok := p.sem.TryAcquire(unreadCount(lane.Buffer)); !ok {
+			p.Close()
+			return nil, fmt.Errorf("priority max_entries is smaller than the number of entries stored in the lanes")
+		}
+	}
+
+	return p, nil
+}
+
+func (c LaneConfig) build() (*priorityLane, error) {
+	if !laneNameRegex.MatchString(c.Name) {
+		return nil, fmt.Errorf("invalid lane name '%s'. Lane names may only contain letters, digits, '_' and '-'", c.Name)
+	}
+
+	lane := &priorityLane{
+		name:   c.Name,
+		weight: c.Weight,
+	}
+	if lane.weight == 0 {
+		lane.weight = 1
+	} else if lane.weight < 0 {
+		return nil, fmt.Errorf("weight of lane '%s' must be positive", c.Name)
+	}
+
+	if c.MinSeverity != nil {
+		severity, err := helper.ParseSeverity(c.MinSeverity)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse min_severity of lane "+c.Name)
+		}
+		lane.minSeverity = &severity
+	}
+
+	if c.Expression != "" {
+		compiled, err := expr.Compile(c.Expression, expr.AsBool(), expr.AllowUndefinedVariables())
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile expression '%s': %w", c.Expression, err)
+		}
+		lane.expression = compiled
+	}
+
+	return lane, nil
+}
+
+// priorityLane is a buffer that holds the entries of a single priority
+type priorityLane struct {
+	Buffer
+	name        string
+	weight      int
+	minSeverity *entry.Severity
+	expression  *vm.Program
+
+	// current is the lane's running score for smooth weighted round-robin
+	current int
+}
+
+// matches returns whether an entry belongs in the lane. A lane with
+// neither a severity nor an expression matches every entry.
+func (l *priorityLane) matches(e *entry.Entry) bool {
+	if l.minSeverity == nil && l.expression == nil {
+		return true
+	}
+
+	if l.minSeverity != nil && e.Severity >= *l.minSeverity {
+		return true
+	}
+
+	if l.expression != nil {
+		env := helper.GetExprEnv(e)
+		defer helper.PutExprEnv(env)
+
+		matches, err := vm.Run(l.expression, env)
+		if err == nil {
+			if m, ok := matches.(bool); ok && m {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// PriorityBuffer is a buffer that keeps entries in separate lanes by priority. Chunks are
+// read from one lane at a time. By default, a lane is only read once every higher-priority
+// lane is empty. When lanes have weights, they are chosen by weighted round-robin instead, so
+// that higher-priority lanes are favored without starving the others. When the buffer is full,
+// either of entries or of the space its lanes share, the oldest unread entries of the
+// lowest-priority lane are dropped to make space.
+type PriorityBuffer struct {
+	lanes []*priorityLane
+	sem   *semaphore.Weighted
+
+	// weighted is whether lanes are chosen by weighted round-robin rather than strict priority
+	weighted bool
+
+	// added is notified every time an entry is added, so ReadChunk can wait
+	// for entries when every lane is empty
+	added chan struct{}
+
+	// laneMux guards the round-robin scores of the lanes
+	laneMux sync.Mutex
+
+	maxChunkSize  uint
+	maxChunkDelay time.Duration
+	logger        *zap.SugaredLogger
+}
+
+// Add adds an entry to the lane it belongs in. If the buffer is full, an entry is dropped from
+// the lowest-priority lane that is not higher than the new entry's lane. If there is no such
+// entry, Add blocks until there is space or the context is cancelled.
+func (p *PriorityBuffer) Add(ctx context.Context, e *entry.Entry) error {
+	laneIndex := p.laneIndex(e)
+	for !p.sem.TryAcquire(1) {
+		if p.dropLowest(laneIndex, 1) == nil {
+			if err := p.sem.Acquire(ctx, 1); err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	if err := p.addToLane(ctx, laneIndex, e); err != nil {
+		p.sem.Release(1)
+		return err
+	}
+
+	select {
+	case p.added <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// addToLane adds an entry to a lane. When the lanes share their space and it has run out,
+// space is reclaimed from the lanes before the entry is added. If none can be reclaimed,
+// addToLane blocks until there is space or the context is cancelled.
+func (p *PriorityBuffer) addToLane(ctx context.Context, laneIndex int, e *entry.Entry) error {
+	lane := p.lanes[laneIndex]
+	shared, ok := lane.Buffer.(sharedSpaceBuffer)
+	if !ok {
+		return lane.Add(ctx, e)
+	}
+
+	for {
+		added, err := shared.tryAdd(e)
+		if err != nil || added {
+			return err
+		}
+		if !p.reclaimSpace(laneIndex) {
+			return lane.Add(ctx, e)
+		}
+	}
+}
+
+// reclaimSpace frees space shared by the lanes. Flushed entries are compacted away first.
+// If no lane has any, the oldest chunk of unread entries is dropped from the lowest-priority
+// lane at or below minIndex that has one. It returns false if no space could be freed.
+func (p *PriorityBuffer) reclaimSpace(minIndex int) bool {
+	reclaimed := false
+	for _, lane := range p.lanes {
+		shared, ok := lane.Buffer.(sharedSpaceBuffer)
+		if !ok {
+			continue
+		}
+		compacted, err := shared.compactFlushed()
+		if err != nil {
+			p.logger.Errorw("Failed to compact lane", "lane", lane.name, zap.Error(err))
+			continue
+		}
+		reclaimed = reclaimed || compacted
+	}
+	if reclaimed {
+		return true
+	}
+
+	chunkSize := int(p.maxChunkSize)
+	if chunkSize == 0 {
+		chunkSize = 1
+	}
+	lane := p.dropLowest(minIndex, chunkSize)
+	if lane == nil {
+		return false
+	}
+	if shared, ok := lane.Buffer.(sharedSpaceBuffer); ok {
+		if _, err := shared.compactFlushed(); err != nil {
+			p.logger.Errorw("Failed to compact lane", "lane", lane.name, zap.Error(err))
+		}
+	}
+	return true
+}
+
+// laneIndex returns the index of the highest-priority lane the entry matches. Entries
+// that match no lane go in the lowest-priority lane.
+func (p *PriorityBuffer) laneIndex(e *entry.Entry) int {
+	for i, lane := range p.lanes {
+		if lane.matches(e) {
+			return i
+		}
+	}
+	return len(p.lanes) - 1
+}
+
+// dropLowest drops up to count of the oldest unread entries from the lowest-priority lane at
+// or below minIndex that has any. It returns the lane, or nil if there was no entry to drop.
+func (p *PriorityBuffer) dropLowest(minIndex, count int) *priorityLane {
+	dst := make([]*entry.Entry, count)
+	for i := len(p.lanes) - 1; i >= minIndex; i-- {
+		lane := p.lanes[i]
+		if unreadCount(lane.Buffer) == 0 {
+			continue
+		}
+
+		clearer, n, err := lane.Read(dst)
+		if err != nil || n == 0 {
+			continue
+		}
+
+		if err := clearer.MarkAllAsFlushed(); err != nil {
+			p.logger.Errorw("Failed to mark dropped entries as flushed", zap.Error(err))
+		}
+		p.sem.Release(int64(n))
+		p.logger.Warnw("Buffer is full. Dropped entries from lowest priority lane", "lane", lane.name, "count", n)
+		return lane
+	}
+	return nil
+}
+
+// nextLane chooses the lane to read the next chunk from. It is the highest-priority lane
+// with unread entries, or when lanes are weighted, the lane chosen by smooth weighted
+// round-robin over the lanes with unread entries, with ties going to the higher-priority
+// lane. It returns nil if every lane is empty.
+func (p *PriorityBuffer) nextLane() *priorityLane {
+	if !p.weighted {
+		for _, lane := range p.lanes {
+			if unreadCount(lane.Buffer) > 0 {
+				return lane
+			}
+		}
+		return nil
+	}
+
+	p.laneMux.Lock()
+	defer p.laneMux.Unlock()
+
+	var best *priorityLane
+	total := 0
+	for _, lane := range p.lanes {
+		if unreadCount(lane.Buffer) == 0 {
+			continue
+		}
+		lane.current += lane.weight
+		total += lane.weight
+		if best == nil || lane.current > best.current {
+			best = lane
+		}
+	}
+
+	if best != nil {
+		best.current -= total
+	}
+	return best
+}
+
+// Read reads entries from the next lane until either the lane has no entries left
+// or the destination slice is full.
+func (p *PriorityBuffer) Read(dst []*entry.Entry) (Clearer, int, error) {
+	lane := p.nextLane()
+	if lane == nil {
+		return p.newClearer(nil, 0), 0, nil
+	}
+
+	clearer, n, err := lane.Read(dst)
+	return p.newClearer(clearer, n), n, err
+}
+
+// ReadWait waits until an entry is available in any lane, then reads from the next lane
+// until either the destination slice is full or the context is cancelled.
+func (p *PriorityBuffer) ReadWait(ctx context.Context, dst []*entry.Entry) (Clearer, int, error) {
+	lane := p.nextLane()
+	for lane == nil {
+		select {
+		case <-p.added:
+		case <-ctx.Done():
+			return p.newClearer(nil, 0), 0, nil
+		}
+		lane = p.nextLane()
+	}
+
+	clearer, n, err := lane.ReadWait(ctx, dst)
+	return p.newClearer(clearer, n), n, err
+}
+
+// ReadChunk reads a chunk of entries from the next lane with entries
+func (p *PriorityBuffer) ReadChunk(ctx context.Context) ([]*entry.Entry, Clearer, error) {
+	entries := make([]*entry.Entry, p.maxChunkSize)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		default:
+		}
+
+		lane := p.nextLane()
+		if lane == nil {
+			select {
+			case <-p.added:
+			case <-ctx.Done():
+			}
+			continue
+		}
+
+		readCtx, cancel := context.WithTimeout(ctx, p.maxChunkDelay)
+		clearer, n, err := lane.ReadWait(readCtx, entries)
+		cancel()
+		if n > 0 {
+			return entries[:n], p.newClearer(clearer, n), err
+		}
+	}
+}
+
+// Close closes every lane
+func (p *PriorityBuffer) Close() error {
+	var firstErr error
+	for _, lane := range p.lanes {
+		if err := lane.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// limitChunkBytes caps the chunk size in bytes of every lane
+func (p *PriorityBuffer) limitChunkBytes(limit int64) {
+	for _, lane := range p.lanes {
+		if limiter, ok := lane.Buffer.(payloadLimiter); ok {
+			limiter.limitChunkBytes(limit)
+		}
+	}
+}
+
+func (p *PriorityBuffer) newClearer(clearer Clearer, n int) Clearer {
+	return &priorityClearer{
+		clearer: clearer,
+		buffer:  p,
+		n:       n,
+	}
+}
+
+// priorityClearer releases space in the priority buffer as entries in a lane are flushed
+type priorityClearer struct {
+	clearer Clearer
+	buffer  *PriorityBuffer
+	n       int
+}
+
+func (pc *priorityClearer) MarkAllAsFlushed() error {
+	if pc.clearer == nil {
+		return nil
+	}
+	if err := pc.clearer.MarkAllAsFlushed(); err != nil {
+		return err
+	}
+	pc.buffer.sem.Release(int64(pc.n))
+	return nil
+}
+
+func (pc *priorityClearer) MarkRangeAsFlushed(start, end uint) error {
+	if pc.clearer == nil {
+		return nil
+	}
+	if err := pc.clearer.MarkRangeAsFlushed(start, end); err != nil {
+		return err
+	}
+	pc.buffer.sem.Release(int64(end - start))
+	return nil
+}
+
+// sharedSpaceBuffer is implemented by lane buffers that share their space with the other
+// lanes, so that adding to one lane may need space to be freed in another
+type sharedSpaceBuffer interface {
+	// tryAdd adds an entry if there is space for it without waiting, returning whether it did
+	tryAdd(e *entry.Entry) (bool, error)
+
+	// compactFlushed gives back the space of flushed entries, returning whether there were any
+	compactFlushed() (bool, error)
+}
+
+// unreadCounter is implemented by buffers that can report how many entries are waiting to be read
+type unreadCounter interface {
+	unreadCount() int64
+}
+
+func unreadCount(b Buffer) int64 {
+	if counter, ok := b.(unreadCounter); ok {
+		return counter.unreadCount()
+	}
+	return 0
+}
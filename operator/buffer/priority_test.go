@@ -0,0 +1,293 @@
+package buffer
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/observiq/stanza/entry"
+	"github.com/observiq/stanza/operator/helper"
+	"github.com/observiq/stanza/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func severityEntry(record string, severity entry.Severity) *entry.Entry {
+	e := stringEntry(record)
+	e.Severity = severity
+	return e
+}
+
+func newPriorityConfig(maxEntries int) *PriorityConfig {
+	return &PriorityConfig{
+		MaxEntries: maxEntries,
+		Lanes: []LaneConfig{
+			{Name: "high", MinSeverity: "error"},
+			{Name: "medium", Expression: `$labels.important == "true"`},
+			{Name: "low"},
+		},
+	}
+}
+
+func newPriorityBuffers(t *testing.T, maxEntries int) map[string]Buffer {
+	return newPriorityBuffersWithConfig(t, func() *PriorityConfig { return newPriorityConfig(maxEntries) })
+}
+
+func newPriorityBuffersWithConfig(t *testing.T, newConfig func() *PriorityConfig) map[string]Buffer {
+	memCfg := NewMemoryBufferConfig()
+	memCfg.Priority = newConfig()
+	mem, err := memCfg.Build(testutil.NewBuildContext(t), "test")
+	require.NoError(t, err)
+
+	diskCfg := NewDiskBufferConfig()
+	diskCfg.Path = testutil.NewTempDir(t)
+	diskCfg.Sync = false
+	diskCfg.Priority = newConfig()
+	disk, err := diskCfg.Build(testutil.NewBuildContext(t), "test")
+	require.NoError(t, err)
+	t.Cleanup(func() { disk.Close() })
+
+	return map[string]Buffer{
+		"Memory": mem,
+		"Disk":   disk,
+	}
+}
+
+func TestPriorityBuffer(t *testing.T) {
+	t.Run("RoutesByPriority", func(t *testing.T) {
+		for name, b := range newPriorityBuffers(t, 100) {
+			t.Run(name, func(t *testing.T) {
+				important := severityEntry("important", entry.Info)
+				important.Labels = map[string]interface{}{"important": "true"}
+
+				require.NoError(t, b.Add(context.Background(), severityEntry("info", entry.Info)))
+				require.NoError(t, b.Add(context.Background(), important))
+				require.NoError(t, b.Add(context.Background(), severityEntry("error", entry.Error)))
+
+				require.Equal(t, []string{"error"}, readRecords(t, b))
+				require.Equal(t, []string{"important"}, readRecords(t, b))
+				require.Equal(t, []string{"info"}, readRecords(t, b))
+				require.Empty(t, readRecords(t, b))
+			})
+		}
+	})
+
+	t.Run("StrictPriority", func(t *testing.T) {
+		for name, b := range newPriorityBuffers(t, 100) {
+			t.Run(name, func(t *testing.T) {
+				dst := make([]*entry.Entry, 1)
+				for i := 0; i < 3; i++ {
+					require.NoError(t, b.Add(context.Background(), severityEntry("info", entry.Info)))
+					require.NoError(t, b.Add(context.Background(), severityEntry("error", entry.Error)))
+				}
+
+				// The backlog of the high lane is drained before the low lane is read
+				records := []string{}
+				for i := 0; i < 6; i++ {
+					c, n, err := b.Read(dst)
+					require.NoError(t, err)
+					require.Equal(t, 1, n)
+					require.NoError(t, c.MarkAllAsFlushed())
+					records = append(records, dst[0].Record.(string))
+				}
+				require.Equal(t, []string{"error", "error", "error", "info", "info", "info"}, records)
+			})
+		}
+	})
+
+	t.Run("WeightedRoundRobin", func(t *testing.T) {
+		weighted := func() *PriorityConfig {
+			cfg := newPriorityConfig(100)
+			cfg.Lanes[0].Weight = 2
+			return cfg
+		}
+		for name, b := range newPriorityBuffersWithConfig(t, weighted) {
+			t.Run(name, func(t *testing.T) {
+				dst := make([]*entry.Entry, 1)
+				for i := 0; i < 3; i++ {
+					require.NoError(t, b.Add(context.Background(), severityEntry("error", entry.Error)))
+					require.NoError(t, b.Add(context.Background(), severityEntry("info", entry.Info)))
+				}
+
+				records := []string{}
+				for i := 0; i < 6; i++ {
+					c, n, err := b.Read(dst)
+					require.NoError(t, err)
+					require.Equal(t, 1, n)
+					require.NoError(t, c.MarkAllAsFlushed())
+					records = append(records, dst[0].Record.(string))
+				}
+				require.Equal(t, []string{"error", "info", "error", "error", "info", "info"}, records)
+			})
+		}
+	})
+
+	t.Run("DropsLowestPriorityWhenFull", func(t *testing.T) {
+		for name, b := range newPriorityBuffers(t, 2) {
+			t.Run(name, func(t *testing.T) {
+				require.NoError(t, b.Add(context.Background(), severityEntry("info1", entry.Info)))
+				require.NoError(t, b.Add(context.Background(), severityEntry("info2", entry.Info)))
+				require.NoError(t, b.Add(context.Background(), severityEntry("error", entry.Error)))
+
+				require.Equal(t, []string{"error"}, readRecords(t, b))
+				require.Equal(t, []string{"info2"}, readRecords(t, b))
+			})
+		}
+	})
+
+	t.Run("BlocksWhenFullOfHigherPriority", func(t *testing.T) {
+		for name, b := range newPriorityBuffers(t, 1) {
+			t.Run(name, func(t *testing.T) {
+				require.NoError(t, b.Add(context.Background(), severityEntry("error", entry.Error)))
+
+				ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+				defer cancel()
+				err := b.Add(ctx, severityEntry("info", entry.Info))
+				require.Error(t, err)
+
+				require.Equal(t, []string{"error"}, readRecords(t, b))
+				require.NoError(t, b.Add(context.Background(), severityEntry("info", entry.Info)))
+			})
+		}
+	})
+
+	t.Run("ReadChunk", func(t *testing.T) {
+		for name, b := range newPriorityBuffers(t, 100) {
+			t.Run(name, func(t *testing.T) {
+				go func() {
+					time.Sleep(50 * time.Millisecond)
+					_ = b.Add(context.Background(), severityEntry("info", entry.Info))
+				}()
+
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				entries, c, err := b.ReadChunk(ctx)
+				require.NoError(t, err)
+				require.Len(t, entries, 1)
+				require.Equal(t, "info", entries[0].Record)
+				require.NoError(t, c.MarkAllAsFlushed())
+			})
+		}
+	})
+
+	t.Run("DiskPersistence", func(t *testing.T) {
+		cfg := NewDiskBufferConfig()
+		cfg.Path = testutil.NewTempDir(t)
+		cfg.Sync = false
+		cfg.Priority = newPriorityConfig(2)
+
+		b, err := cfg.Build(testutil.NewBuildContext(t), "test")
+		require.NoError(t, err)
+		require.NoError(t, b.Add(context.Background(), severityEntry("error1", entry.Error)))
+		require.NoError(t, b.Add(context.Background(), severityEntry("error2", entry.Error)))
+		require.NoError(t, b.Close())
+
+		b, err = cfg.Build(testutil.NewBuildContext(t), "test")
+		require.NoError(t, err)
+		defer b.Close()
+
+		// The persisted entries fill the buffer, so the oldest one is dropped
+		require.NoError(t, b.Add(context.Background(), severityEntry("error3", entry.Error)))
+		require.Equal(t, []string{"error2", "error3"}, readRecords(t, b))
+	})
+
+	t.Run("DiskLanesShareMaxSize", func(t *testing.T) {
+		encoded, err := appendEntry(JSONCodec, nil, severityEntry("hi1", entry.Error))
+		require.NoError(t, err)
+
+		cfg := NewDiskBufferConfig()
+		cfg.Path = testutil.NewTempDir(t)
+		cfg.Sync = false
+		cfg.MaxSize = helper.ByteSize(len(encoded) * 6)
+		cfg.Priority = newPriorityConfig(100)
+
+		b, err := cfg.Build(testutil.NewBuildContext(t), "test")
+		require.NoError(t, err)
+		defer b.Close()
+
+		// A single lane can use all of max_size while the others are empty
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		for i := 1; i <= 6; i++ {
+			require.NoError(t, b.Add(ctx, severityEntry("hi"+strconv.Itoa(i), entry.Error)))
+		}
+		require.Len(t, readRecords(t, b), 6)
+	})
+
+	t.Run("DiskDropsLowestPriorityWhenMaxSizeFull", func(t *testing.T) {
+		encoded, err := appendEntry(JSONCodec, nil, severityEntry("lo1", entry.Info))
+		require.NoError(t, err)
+
+		cfg := NewDiskBufferConfig()
+		cfg.Path = testutil.NewTempDir(t)
+		cfg.Sync = false
+		cfg.MaxSize = helper.ByteSize(len(encoded)*4 + len(encoded)/2)
+		cfg.MaxChunkSize = 1
+		cfg.Priority = newPriorityConfig(100)
+
+		b, err := cfg.Build(testutil.NewBuildContext(t), "test")
+		require.NoError(t, err)
+		defer b.Close()
+
+		for i := 1; i <= 4; i++ {
+			require.NoError(t, b.Add(context.Background(), severityEntry("lo"+strconv.Itoa(i), entry.Info)))
+		}
+
+		// The disk is full of low-priority entries, so the oldest are dropped to make space
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		require.NoError(t, b.Add(ctx, severityEntry("hi1", entry.Error)))
+		require.NoError(t, b.Add(ctx, severityEntry("hi2", entry.Error)))
+
+		require.Equal(t, []string{"hi1", "hi2"}, readRecords(t, b))
+		require.Equal(t, []string{"lo3", "lo4"}, readRecords(t, b))
+	})
+
+	t.Run("DiskBlocksWhenMaxSizeFullOfHigherPriority", func(t *testing.T) {
+		encoded, err := appendEntry(JSONCodec, nil, severityEntry("hi1", entry.Error))
+		require.NoError(t, err)
+
+		cfg := NewDiskBufferConfig()
+		cfg.Path = testutil.NewTempDir(t)
+		cfg.Sync = false
+		cfg.MaxSize = helper.ByteSize(len(encoded) * 2)
+		cfg.Priority = newPriorityConfig(100)
+
+		b, err := cfg.Build(testutil.NewBuildContext(t), "test")
+		require.NoError(t, err)
+		defer b.Close()
+
+		require.NoError(t, b.Add(context.Background(), severityEntry("hi1", entry.Error)))
+		require.NoError(t, b.Add(context.Background(), severityEntry("hi2", entry.Error)))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		require.Error(t, b.Add(ctx, severityEntry("lo1", entry.Info)))
+
+		// Flushed entries give back their space
+		require.Equal(t, []string{"hi1", "hi2"}, readRecords(t, b))
+		ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		require.NoError(t, b.Add(ctx, severityEntry("lo1", entry.Info)))
+	})
+
+	t.Run("InvalidConfig", func(t *testing.T) {
+		cases := map[string]*PriorityConfig{
+			"NoLanes":         {},
+			"InvalidName":     {Lanes: []LaneConfig{{Name: "a/b"}}},
+			"DuplicateName":   {Lanes: []LaneConfig{{Name: "a"}, {Name: "a"}}},
+			"InvalidSeverity": {Lanes: []LaneConfig{{Name: "a", MinSeverity: "invalid"}}},
+			"InvalidExpr":     {Lanes: []LaneConfig{{Name: "a", Expression: "$record =="}}},
+			"NegativeWeight":  {Lanes: []LaneConfig{{Name: "a", Weight: -1}}},
+		}
+
+		for name, priority := range cases {
+			t.Run(name, func(t *testing.T) {
+				cfg := NewMemoryBufferConfig()
+				cfg.Priority = priority
+				_, err := cfg.Build(testutil.NewBuildContext(t), "test")
+				require.Error(t, err)
+			})
+		}
+	})
+}
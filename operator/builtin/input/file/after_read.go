@@ -0,0 +1,168 @@
+package file
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/observiq/stanza/operator"
+	"github.com/observiq/stanza/operator/helper"
+	"go.uber.org/zap"
+)
+
+// These are the actions a file input can take on a file once it has been read
+const (
+	// NoAfterRead leaves files where they are
+	NoAfterRead = "none"
+
+	// DeleteAfterRead deletes files
+	DeleteAfterRead = "delete"
+
+	// MoveAfterRead moves files into the move_to directory
+	MoveAfterRead = "move"
+)
+
+const defaultAfterReadDelay = time.Minute
+
+// afterRead deletes or moves the files that were read to the end during this poll, and have
+// not been modified for the after read delay. It must be called after the files are closed.
+//
+// A file counts as read once its entries have been passed to the next operator. That is not
+// a confirmation of delivery: an output's buffer may still hold the entries, and entries that
+// an operator fails to process are not read again. Async stages are rejected at start by
+// checkAfterReadOutputs, since they would hold the entries in memory.
+func (f *InputOperator) afterRead(readers []*Reader) {
+	if f.AfterRead == NoAfterRead {
+		return
+	}
+
+	for _, reader := range readers {
+		if reader.readInfo == nil || time.Since(reader.readInfo.ModTime()) < f.AfterReadDelay {
+			continue
+		}
+
+		// Files that have already been moved are left in place
+		if f.AfterRead == MoveAfterRead && filepath.Dir(reader.Path) == filepath.Clean(f.MoveTo) {
+			continue
+		}
+
+		// Make sure the file has not been replaced or written since it was read
+		info, err := os.Stat(reader.Path)
+		if err != nil {
+			continue
+		}
+		if !isUnchanged(info, reader.readInfo) {
+			continue
+		}
+
+		switch f.AfterRead {
+		case DeleteAfterRead:
+			if err := os.Remove(reader.Path); err != nil {
+				reader.Errorw("Failed to delete file after reading it", zap.Error(err))
+				continue
+			}
+			reader.Infow("Deleted file after reading it")
+		case MoveAfterRead:
+			dest, err := f.moveFile(reader.Path)
+			if err != nil {
+				reader.Errorw("Failed to move file after reading it", zap.Error(err))
+				continue
+			}
+			reader.Infow("Moved file after reading it", "destination", dest)
+		}
+	}
+}
+
+// moveFile moves a file into the move_to directory, without replacing a file of the same name.
+// When move_to is on another filesystem, the file is copied and then removed.
+func (f *InputOperator) moveFile(path string) (string, error) {
+	dest := filepath.Join(f.MoveTo, filepath.Base(path))
+	if _, err := os.Lstat(dest); err == nil {
+		return "", fmt.Errorf("destination %s already exists", dest)
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+
+	err := os.Rename(path, dest)
+	if err == nil {
+		return dest, nil
+	}
+	if !isCrossDevice(err) {
+		return "", err
+	}
+
+	if err := copyFile(path, dest); err != nil {
+		return "", err
+	}
+	if err := os.Remove(path); err != nil {
+		return "", fmt.Errorf("remove after copying to %s: %s", dest, err)
+	}
+	return dest, nil
+}
+
+// isCrossDevice returns whether a rename failed because the paths are on different filesystems
+func isCrossDevice(err error) bool {
+	linkErr, ok := err.(*os.LinkError)
+	return ok && linkErr.Err == errCrossDevice
+}
+
+// copyFile copies a file to a new file at dest, syncing it to disk before returning. A
+// partially written copy is removed.
+func copyFile(path, dest string) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	dst, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := dst.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			_ = os.Remove(dest)
+		}
+	}()
+
+	if _, err = io.Copy(dst, src); err != nil {
+		return err
+	}
+	return dst.Sync()
+}
+
+// checkAfterReadOutputs returns an error if files are deleted or moved after they are read, and
+// an async stage is downstream of the input. An async stage holds entries in a queue in memory
+// after the input has passed them on, so they would be lost with the file if the agent stopped.
+func (f *InputOperator) checkAfterReadOutputs() error {
+	if f.AfterRead == NoAfterRead {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	next := append([]operator.Operator{}, f.OutputOperators...)
+	for len(next) > 0 {
+		op := next[0]
+		next = next[1:]
+		if seen[op.ID()] {
+			continue
+		}
+		seen[op.ID()] = true
+
+		if _, ok := op.(*helper.AsyncOperator); ok {
+			return fmt.Errorf("after_read '%s' cannot be used with the async operator '%s' downstream, because entries in its queue would be lost with the file", f.AfterRead, op.ID())
+		}
+		next = append(next, op.Outputs()...)
+	}
+	return nil
+}
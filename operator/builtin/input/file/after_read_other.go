@@ -0,0 +1,8 @@
+// +build !windows
+
+package file
+
+import "syscall"
+
+// errCrossDevice is the error returned when renaming a file onto another filesystem
+const errCrossDevice = syscall.EXDEV
@@ -0,0 +1,223 @@
+package file
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/observiq/stanza/operator"
+	"github.com/observiq/stanza/operator/helper"
+	"github.com/observiq/stanza/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func afterReadConfig(action string, delay time.Duration) func(*InputConfig) {
+	return func(cfg *InputConfig) {
+		cfg.AfterRead = action
+		cfg.AfterReadDelay = helper.Duration{Duration: delay}
+	}
+}
+
+func TestDeleteAfterRead(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, afterReadConfig(DeleteAfterRead, 0), nil)
+
+	path := filepath.Join(tempDir, "test.log")
+	temp := openFile(t, path)
+	writeString(t, temp, "testlog1\ntestlog2\n")
+	require.NoError(t, temp.Close())
+
+	operator.poll(context.Background())
+	defer operator.Stop()
+	waitForMessages(t, logReceived, []string{"testlog1", "testlog2"})
+
+	_, err := os.Stat(path)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestAfterReadRejectsAsyncOutput(t *testing.T) {
+	t.Parallel()
+	input, _, _ := newTestFileOperator(t, afterReadConfig(DeleteAfterRead, 0), nil)
+
+	async, err := helper.AsyncConfig{}.Build(testutil.NewFakeOutput(t))
+	require.NoError(t, err)
+	input.OutputOperators = []operator.Operator{async}
+
+	err = input.Start()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "async operator")
+}
+
+func TestDeleteAfterReadDelay(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, afterReadConfig(DeleteAfterRead, time.Hour), nil)
+
+	path := filepath.Join(tempDir, "test.log")
+	temp := openFile(t, path)
+	writeString(t, temp, "testlog1\n")
+	require.NoError(t, temp.Close())
+
+	// The file was modified too recently to be deleted
+	operator.poll(context.Background())
+	defer operator.Stop()
+	waitForMessage(t, logReceived, "testlog1")
+	require.FileExists(t, path)
+
+	// Once the file has not been modified for the delay, it is deleted
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(path, old, old))
+	operator.poll(context.Background())
+	_, err := os.Stat(path)
+	require.True(t, os.IsNotExist(err))
+	expectNoMessages(t, logReceived)
+}
+
+func TestDeleteAfterReadPartialEntry(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, afterReadConfig(DeleteAfterRead, 0), nil)
+
+	path := filepath.Join(tempDir, "test.log")
+	temp := openFile(t, path)
+	writeString(t, temp, "testlog1\ntestlog2")
+
+	// A file with a partial entry after the offset has not been read to the end
+	operator.poll(context.Background())
+	defer operator.Stop()
+	waitForMessage(t, logReceived, "testlog1")
+	require.FileExists(t, path)
+
+	writeString(t, temp, "\n")
+	require.NoError(t, temp.Close())
+	operator.poll(context.Background())
+	waitForMessage(t, logReceived, "testlog2")
+	_, err := os.Stat(path)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestMoveAfterRead(t *testing.T) {
+	t.Parallel()
+	archiveDir := testutil.NewTempDir(t)
+	operator, logReceived, tempDir := newTestFileOperator(t, func(cfg *InputConfig) {
+		afterReadConfig(MoveAfterRead, 0)(cfg)
+		cfg.MoveTo = archiveDir
+	}, nil)
+
+	path := filepath.Join(tempDir, "test.log")
+	temp := openFile(t, path)
+	writeString(t, temp, "testlog1\n")
+	require.NoError(t, temp.Close())
+
+	operator.poll(context.Background())
+	defer operator.Stop()
+	waitForMessage(t, logReceived, "testlog1")
+
+	_, err := os.Stat(path)
+	require.True(t, os.IsNotExist(err))
+	require.FileExists(t, filepath.Join(archiveDir, "test.log"))
+}
+
+func TestMoveAfterReadDestinationExists(t *testing.T) {
+	t.Parallel()
+	archiveDir := testutil.NewTempDir(t)
+	operator, logReceived, tempDir := newTestFileOperator(t, func(cfg *InputConfig) {
+		afterReadConfig(MoveAfterRead, 0)(cfg)
+		cfg.MoveTo = archiveDir
+	}, nil)
+
+	existing := openFile(t, filepath.Join(archiveDir, "test.log"))
+	writeString(t, existing, "existing\n")
+	require.NoError(t, existing.Close())
+
+	path := filepath.Join(tempDir, "test.log")
+	temp := openFile(t, path)
+	writeString(t, temp, "testlog1\n")
+	require.NoError(t, temp.Close())
+
+	// Files in the destination are not replaced
+	operator.poll(context.Background())
+	defer operator.Stop()
+	waitForMessage(t, logReceived, "testlog1")
+	require.FileExists(t, path)
+}
+
+func TestMoveAfterReadIntoIncludedDirectory(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, func(cfg *InputConfig) {
+		afterReadConfig(MoveAfterRead, 0)(cfg)
+		cfg.Include = []string{filepath.Join(includeDir(cfg), "*.log"), filepath.Join(includeDir(cfg), "archive", "*")}
+		cfg.MoveTo = filepath.Join(includeDir(cfg), "archive")
+	}, nil)
+	require.NoError(t, os.Mkdir(filepath.Join(tempDir, "archive"), 0755))
+
+	path := filepath.Join(tempDir, "test.log")
+	temp := openFile(t, path)
+	writeString(t, temp, "testlog1\n")
+	require.NoError(t, temp.Close())
+
+	operator.poll(context.Background())
+	defer operator.Stop()
+	waitForMessage(t, logReceived, "testlog1")
+
+	// The moved file is recognised by its fingerprint, so it is not read again, and
+	// it is not moved again
+	operator.poll(context.Background())
+	expectNoMessages(t, logReceived)
+	require.FileExists(t, filepath.Join(tempDir, "archive", "test.log"))
+}
+
+func TestMoveAfterReadAcrossFilesystems(t *testing.T) {
+	t.Parallel()
+
+	// A directory on another filesystem than the temp dir, where renames fail
+	archiveDir, err := ioutil.TempDir("/dev/shm", "stanza")
+	if err != nil {
+		t.Skip("No /dev/shm directory to move files into")
+	}
+	defer os.RemoveAll(archiveDir)
+
+	operator, logReceived, tempDir := newTestFileOperator(t, func(cfg *InputConfig) {
+		afterReadConfig(MoveAfterRead, 0)(cfg)
+		cfg.MoveTo = archiveDir
+	}, nil)
+	if err := os.Rename(archiveDir, filepath.Join(tempDir, "probe")); !isCrossDevice(err) {
+		if err == nil {
+			require.NoError(t, os.Rename(filepath.Join(tempDir, "probe"), archiveDir))
+		}
+		t.Skip("/dev/shm is on the same filesystem as the temp dir")
+	}
+
+	path := filepath.Join(tempDir, "test.log")
+	temp := openFile(t, path)
+	writeString(t, temp, "testlog1\n")
+	require.NoError(t, temp.Close())
+
+	operator.poll(context.Background())
+	defer operator.Stop()
+	waitForMessage(t, logReceived, "testlog1")
+
+	_, err = os.Stat(path)
+	require.True(t, os.IsNotExist(err))
+	contents, err := ioutil.ReadFile(filepath.Join(archiveDir, "test.log"))
+	require.NoError(t, err)
+	require.Equal(t, "testlog1\n", string(contents))
+}
+
+func TestCopyFile(t *testing.T) {
+	t.Parallel()
+	tempDir := testutil.NewTempDir(t)
+	path := filepath.Join(tempDir, "source.log")
+	require.NoError(t, ioutil.WriteFile(path, []byte("testlog1\n"), 0640))
+
+	dest := filepath.Join(tempDir, "dest.log")
+	require.NoError(t, copyFile(path, dest))
+	contents, err := ioutil.ReadFile(dest)
+	require.NoError(t, err)
+	require.Equal(t, "testlog1\n", string(contents))
+
+	// An existing destination is not replaced
+	require.Error(t, copyFile(path, dest))
+	require.True(t, isCrossDevice(&os.LinkError{Op: "rename", Old: path, New: dest, Err: errCrossDevice}))
+}
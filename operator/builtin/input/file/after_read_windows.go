@@ -0,0 +1,8 @@
+// +build windows
+
+package file
+
+import "syscall"
+
+// errCrossDevice is the error returned when moving a file onto another volume (ERROR_NOT_SAME_DEVICE)
+const errCrossDevice = syscall.Errno(17)
@@ -0,0 +1,124 @@
+package file
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// These are the values of the compression option
+const (
+	// AutoCompression detects compressed files by their magic bytes or extension
+	AutoCompression = "auto"
+
+	// NoCompression reads every file as it is
+	NoCompression = "none"
+)
+
+// compression is the compression format of a file
+type compression int
+
+const (
+	uncompressed compression = iota
+	gzipCompressed
+	zstdCompressed
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// String returns the name of the compression format
+func (c compression) String() string {
+	switch c {
+	case gzipCompressed:
+		return "gzip"
+	case zstdCompressed:
+		return "zstd"
+	default:
+		return "none"
+	}
+}
+
+// detectCompression returns the compression format of a file. Files that are too short to
+// hold their magic bytes are recognised by their extension.
+func (f *InputOperator) detectCompression(file *os.File) (compression, error) {
+	if f.Compression == NoCompression {
+		return uncompressed, nil
+	}
+
+	magic := make([]byte, len(zstdMagic))
+	n, err := file.ReadAt(magic, 0)
+	if err != nil && err != io.EOF {
+		return uncompressed, fmt.Errorf("reading magic bytes: %s", err)
+	}
+	magic = magic[:n]
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		return gzipCompressed, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		return zstdCompressed, nil
+	}
+
+	switch strings.ToLower(filepath.Ext(file.Name())) {
+	case ".gz", ".gzip":
+		if n < len(gzipMagic) {
+			return gzipCompressed, nil
+		}
+	case ".zst", ".zstd":
+		if n < len(zstdMagic) {
+			return zstdCompressed, nil
+		}
+	}
+	return uncompressed, nil
+}
+
+// checkCompression warns if a newly matched file uses a compression format that is not supported
+func (f *InputOperator) checkCompression(file *os.File) {
+	if c, err := f.detectCompression(file); err == nil && c == zstdCompressed {
+		f.Warnw("Skipping file with unsupported compression", "path", file.Name(), "compression", c.String())
+	}
+}
+
+// newDecompressor returns a reader of the decompressed contents of a file, starting at
+// offset in the decompressed stream
+func newDecompressor(file *os.File, c compression, offset int64) (io.Reader, error) {
+	if c != gzipCompressed {
+		return nil, fmt.Errorf("%s compression is not supported", c)
+	}
+
+	// Read through a section so that the file's own offset is not used
+	gr, err := gzip.NewReader(io.NewSectionReader(file, 0, math.MaxInt64))
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := io.CopyN(ioutil.Discard, gr, offset); err != nil {
+		return nil, fmt.Errorf("skipping to offset: %w", err)
+	}
+	return gr, nil
+}
+
+// isIncomplete returns whether an error is caused by a compressed file that ends early,
+// which is usually because it is still being written
+func isIncomplete(err error) bool {
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// decompressedSize returns the size of the decompressed contents of a file
+func decompressedSize(file *os.File, c compression) (int64, error) {
+	r, err := newDecompressor(file, c, 0)
+	if err != nil {
+		return 0, err
+	}
+	return io.Copy(ioutil.Discard, r)
+}
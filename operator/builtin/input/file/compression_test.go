@@ -0,0 +1,201 @@
+package file
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/observiq/stanza/operator"
+	"github.com/observiq/stanza/testutil"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func gzipBytes(t *testing.T, s string) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(s))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func writeGzipFile(t *testing.T, path, s string) {
+	require.NoError(t, ioutil.WriteFile(path, gzipBytes(t, s), 0600))
+}
+
+func TestDetectCompression(t *testing.T) {
+	cases := []struct {
+		name        string
+		fileName    string
+		contents    []byte
+		compression string
+		expected    compression
+	}{
+		{"Plain", "test.log", []byte("testlog\n"), AutoCompression, uncompressed},
+		{"Gzip", "test.log.1", []byte{0x1f, 0x8b, 0x08, 0x00}, AutoCompression, gzipCompressed},
+		{"Zstd", "test.log.1", []byte{0x28, 0xb5, 0x2f, 0xfd, 0x00}, AutoCompression, zstdCompressed},
+		{"GzipExtension", "test.log.gz", []byte{0x1f}, AutoCompression, gzipCompressed},
+		{"GzipExtensionPlain", "test.log.gz", []byte("testlog\n"), AutoCompression, uncompressed},
+		{"ZstdExtension", "test.log.zst", []byte{}, AutoCompression, zstdCompressed},
+		{"None", "test.log.gz", []byte{0x1f, 0x8b, 0x08, 0x00}, NoCompression, uncompressed},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			operator, _, tempDir := newTestFileOperator(t, func(cfg *InputConfig) {
+				cfg.Compression = tc.compression
+			}, nil)
+
+			path := filepath.Join(tempDir, tc.fileName)
+			require.NoError(t, ioutil.WriteFile(path, tc.contents, 0600))
+			file := openFile(t, path)
+
+			c, err := operator.detectCompression(file)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, c)
+		})
+	}
+}
+
+func TestReadGzipFile(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, nil, nil)
+
+	writeGzipFile(t, filepath.Join(tempDir, "test.log.gz"), "testlog1\ntestlog2\n")
+
+	operator.poll(context.Background())
+	defer operator.Stop()
+	waitForMessages(t, logReceived, []string{"testlog1", "testlog2"})
+
+	// An unchanged compressed file is not read again
+	operator.poll(context.Background())
+	expectNoMessages(t, logReceived)
+}
+
+func TestReadGzipFileCancelled(t *testing.T) {
+	t.Parallel()
+	input, logReceived, tempDir := newTestFileOperator(t, nil, nil)
+	fake := input.OutputOperators[0]
+
+	var contents strings.Builder
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(&contents, "testlog%d\n", i)
+	}
+	path := filepath.Join(tempDir, "test.log.gz")
+	writeGzipFile(t, path, contents.String())
+
+	// The whole file is scanned, and the output cancels the read while it receives the last batch
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cancelling := &testutil.Operator{}
+	cancelling.On("Process", mock.Anything, mock.Anything).Return(nil).Run(func(mock.Arguments) { cancel() })
+	input.OutputOperators = []operator.Operator{cancelling}
+
+	fp, err := input.NewFingerprint(openFile(t, path))
+	require.NoError(t, err)
+	reader, err := input.NewReader(path, openFile(t, path), fp)
+	require.NoError(t, err)
+	reader.ReadToEnd(ctx)
+
+	// The unchanged file is read again, since its entries were not all emitted
+	input.OutputOperators = []operator.Operator{fake}
+	next, err := reader.Copy(openFile(t, path))
+	require.NoError(t, err)
+	go next.ReadToEnd(context.Background())
+	for i := 0; i < 10; i++ {
+		waitForMessage(t, logReceived, fmt.Sprintf("testlog%d", i))
+	}
+}
+
+func TestReadGzipFileStartAtEnd(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, func(cfg *InputConfig) {
+		cfg.StartAt = "end"
+	}, nil)
+
+	writeGzipFile(t, filepath.Join(tempDir, "test.log.gz"), "testlog1\ntestlog2\n")
+
+	operator.poll(context.Background())
+	defer operator.Stop()
+	expectNoMessages(t, logReceived)
+
+	// The offset of the reader is the size of the decompressed contents
+	require.Len(t, operator.knownFiles, 1)
+	require.Equal(t, int64(len("testlog1\ntestlog2\n")), operator.knownFiles[0].Offset)
+}
+
+func TestReadIncompleteGzipFile(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, nil, nil)
+
+	path := filepath.Join(tempDir, "test.log.gz")
+	compressed := gzipBytes(t, "testlog1\ntestlog2\n")
+
+	// A file that is still being compressed is read again once it is complete
+	require.NoError(t, ioutil.WriteFile(path, compressed[:len(compressed)/2], 0600))
+	operator.poll(context.Background())
+	defer operator.Stop()
+
+	require.NoError(t, ioutil.WriteFile(path, compressed, 0600))
+	operator.poll(context.Background())
+	waitForMessages(t, logReceived, []string{"testlog1", "testlog2"})
+}
+
+func TestRotateIntoGzip(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, nil, nil)
+
+	path := filepath.Join(tempDir, "test.log")
+	temp := openFile(t, path)
+	writeString(t, temp, "testlog1\ntestlog2\n")
+
+	operator.poll(context.Background())
+	defer operator.Stop()
+	waitForMessages(t, logReceived, []string{"testlog1", "testlog2"})
+
+	// A line is written, then the file is compressed before it is read
+	writeString(t, temp, "testlog3\n")
+	require.NoError(t, temp.Close())
+	contents, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	writeGzipFile(t, filepath.Join(tempDir, "test.log.1.gz"), string(contents))
+	require.NoError(t, os.Remove(path))
+
+	// Only the line that was missed is read from the compressed file
+	operator.poll(context.Background())
+	waitForMessages(t, logReceived, []string{"testlog3"})
+}
+
+func TestGzipFingerprint(t *testing.T) {
+	operator, _, tempDir := newTestFileOperator(t, nil, nil)
+
+	plainPath := filepath.Join(tempDir, "test.log")
+	require.NoError(t, ioutil.WriteFile(plainPath, []byte("testlog1\ntestlog2\n"), 0600))
+	gzipPath := filepath.Join(tempDir, "test.log.gz")
+	writeGzipFile(t, gzipPath, "testlog1\ntestlog2\n")
+
+	plainFp, err := operator.NewFingerprint(openFile(t, plainPath))
+	require.NoError(t, err)
+	gzipFp, err := operator.NewFingerprint(openFile(t, gzipPath))
+	require.NoError(t, err)
+	require.Equal(t, plainFp, gzipFp)
+}
+
+func TestSkipZstdFile(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, nil, nil)
+
+	path := filepath.Join(tempDir, "test.log.zst")
+	require.NoError(t, ioutil.WriteFile(path, []byte{0x28, 0xb5, 0x2f, 0xfd, 0x00, 0x01, 0x02}, 0600))
+
+	operator.poll(context.Background())
+	defer operator.Stop()
+	expectNoMessages(t, logReceived)
+}
@@ -3,11 +3,12 @@ package file
 import (
 	"bufio"
 	"fmt"
-	"path/filepath"
+	"os"
 	"regexp"
 	"strings"
 	"time"
 
+	"github.com/bmatcuk/doublestar/v2"
 	"github.com/observiq/stanza/entry"
 	"github.com/observiq/stanza/operator"
 	"github.com/observiq/stanza/operator/helper"
@@ -30,12 +31,16 @@ func NewInputConfig(operatorID string) *InputConfig {
 	return &InputConfig{
 		InputConfig:        helper.NewInputConfig(operatorID, "file_input"),
 		PollInterval:       helper.Duration{Duration: 200 * time.Millisecond},
+		WatchMode:          PollWatchMode,
 		IncludeFileName:    true,
 		IncludeFilePath:    false,
 		StartAt:            "end",
 		MaxLogSize:         defaultMaxLogSize,
 		MaxConcurrentFiles: defaultMaxConcurrentFiles,
 		Encoding:           "nop",
+		Compression:        AutoCompression,
+		AfterRead:          NoAfterRead,
+		AfterReadDelay:     helper.Duration{Duration: defaultAfterReadDelay},
 	}
 }
 
@@ -46,22 +51,40 @@ type InputConfig struct {
 	Include []string `json:"include,omitempty" yaml:"include,omitempty"`
 	Exclude []string `json:"exclude,omitempty" yaml:"exclude,omitempty"`
 
-	PollInterval       helper.Duration  `json:"poll_interval,omitempty"        yaml:"poll_interval,omitempty"`
-	Multiline          *MultilineConfig `json:"multiline,omitempty"            yaml:"multiline,omitempty"`
-	IncludeFileName    bool             `json:"include_file_name,omitempty"    yaml:"include_file_name,omitempty"`
-	IncludeFilePath    bool             `json:"include_file_path,omitempty"    yaml:"include_file_path,omitempty"`
-	StartAt            string           `json:"start_at,omitempty"             yaml:"start_at,omitempty"`
-	FingerprintSize    helper.ByteSize  `json:"fingerprint_size,omitempty"     yaml:"fingerprint_size,omitempty"`
-	MaxLogSize         helper.ByteSize  `json:"max_log_size,omitempty"         yaml:"max_log_size,omitempty"`
-	MaxConcurrentFiles int              `json:"max_concurrent_files,omitempty" yaml:"max_concurrent_files,omitempty"`
-	Encoding           string           `json:"encoding,omitempty"             yaml:"encoding,omitempty"`
+	PollInterval         helper.Duration        `json:"poll_interval,omitempty"          yaml:"poll_interval,omitempty"`
+	WatchMode            string                 `json:"watch_mode,omitempty"             yaml:"watch_mode,omitempty"`
+	FallbackPollInterval helper.Duration        `json:"fallback_poll_interval,omitempty" yaml:"fallback_poll_interval,omitempty"`
+	Multiline            *MultilineConfig       `json:"multiline,omitempty"              yaml:"multiline,omitempty"`
+	ForceFlushPeriod     helper.Duration        `json:"force_flush_period,omitempty"     yaml:"force_flush_period,omitempty"`
+	Header               *HeaderConfig          `json:"header,omitempty"                 yaml:"header,omitempty"`
+	IncludeFileName      bool                   `json:"include_file_name,omitempty"      yaml:"include_file_name,omitempty"`
+	IncludeFilePath      bool                   `json:"include_file_path,omitempty"      yaml:"include_file_path,omitempty"`
+	IncludeFileOffset    bool                   `json:"include_file_offset,omitempty"    yaml:"include_file_offset,omitempty"`
+	IncludeLineNumber    bool                   `json:"include_line_number,omitempty"    yaml:"include_line_number,omitempty"`
+	IncludeFileInode     bool                   `json:"include_file_inode,omitempty"     yaml:"include_file_inode,omitempty"`
+	IncludeFingerprintID bool                   `json:"include_fingerprint_id,omitempty" yaml:"include_fingerprint_id,omitempty"`
+	FileOffsetField      *entry.Field           `json:"file_offset_field,omitempty"      yaml:"file_offset_field,omitempty"`
+	LineNumberField      *entry.Field           `json:"line_number_field,omitempty"      yaml:"line_number_field,omitempty"`
+	FileInodeField       *entry.Field           `json:"file_inode_field,omitempty"       yaml:"file_inode_field,omitempty"`
+	FileDeviceField      *entry.Field           `json:"file_device_field,omitempty"      yaml:"file_device_field,omitempty"`
+	FingerprintIDField   *entry.Field           `json:"fingerprint_id_field,omitempty"   yaml:"fingerprint_id_field,omitempty"`
+	StartAt              string                 `json:"start_at,omitempty"               yaml:"start_at,omitempty"`
+	FingerprintSize      helper.ByteSize        `json:"fingerprint_size,omitempty"       yaml:"fingerprint_size,omitempty"`
+	MaxLogSize           helper.ByteSize        `json:"max_log_size,omitempty"           yaml:"max_log_size,omitempty"`
+	MaxConcurrentFiles   int                    `json:"max_concurrent_files,omitempty"   yaml:"max_concurrent_files,omitempty"`
+	IgnoreOlder          helper.Duration        `json:"ignore_older,omitempty"           yaml:"ignore_older,omitempty"`
+	CloseInactive        helper.Duration        `json:"close_inactive,omitempty"         yaml:"close_inactive,omitempty"`
+	Encoding             string                 `json:"encoding,omitempty"               yaml:"encoding,omitempty"`
+	Compression          string                 `json:"compression,omitempty"            yaml:"compression,omitempty"`
+	AfterRead            string                 `json:"after_read,omitempty"             yaml:"after_read,omitempty"`
+	AfterReadDelay       helper.Duration        `json:"after_read_delay,omitempty"       yaml:"after_read_delay,omitempty"`
+	MoveTo               string                 `json:"move_to,omitempty"                yaml:"move_to,omitempty"`
+	PathRegex            string                 `json:"path_regex,omitempty"             yaml:"path_regex,omitempty"`
+	PathFields           map[string]entry.Field `json:"path_fields,omitempty"            yaml:"path_fields,omitempty"`
 }
 
 // MultilineConfig is the configuration a multiline operation
-type MultilineConfig struct {
-	LineStartPattern string `json:"line_start_pattern" yaml:"line_start_pattern"`
-	LineEndPattern   string `json:"line_end_pattern"   yaml:"line_end_pattern"`
-}
+type MultilineConfig = helper.MultilineConfig
 
 // Build will build a file input operator from the supplied configuration
 func (c InputConfig) Build(context operator.BuildContext) ([]operator.Operator, error) {
@@ -76,7 +99,7 @@ func (c InputConfig) Build(context operator.BuildContext) ([]operator.Operator,
 
 	// Ensure includes can be parsed as globs
 	for _, include := range c.Include {
-		_, err := filepath.Match(include, "matchstring")
+		_, err := doublestar.PathMatch(include, "matchstring")
 		if err != nil {
 			return nil, fmt.Errorf("parse include glob: %s", err)
 		}
@@ -84,12 +107,24 @@ func (c InputConfig) Build(context operator.BuildContext) ([]operator.Operator,
 
 	// Ensure excludes can be parsed as globs
 	for _, exclude := range c.Exclude {
-		_, err := filepath.Match(exclude, "matchstring")
+		_, err := doublestar.PathMatch(exclude, "matchstring")
 		if err != nil {
 			return nil, fmt.Errorf("parse exclude glob: %s", err)
 		}
 	}
 
+	pathRegex, pathFields, err := c.buildPathFields()
+	if err != nil {
+		return nil, err
+	}
+
+	var header *header
+	if c.Header != nil {
+		if header, err = c.Header.Build(); err != nil {
+			return nil, err
+		}
+	}
+
 	if c.MaxLogSize <= 0 {
 		return nil, fmt.Errorf("`max_log_size` must be positive")
 	}
@@ -104,6 +139,64 @@ func (c InputConfig) Build(context operator.BuildContext) ([]operator.Operator,
 		return nil, fmt.Errorf("`fingerprint_size` must be at least %d bytes", minFingerprintSize)
 	}
 
+	switch c.WatchMode {
+	case "":
+		c.WatchMode = PollWatchMode
+	case PollWatchMode, FsnotifyWatchMode:
+	default:
+		return nil, fmt.Errorf("invalid watch_mode '%s'", c.WatchMode)
+	}
+
+	if c.FallbackPollInterval.Raw() == 0 {
+		c.FallbackPollInterval = helper.Duration{Duration: defaultFallbackPollInterval}
+	} else if c.FallbackPollInterval.Raw() < 0 {
+		return nil, fmt.Errorf("`fallback_poll_interval` must be positive")
+	}
+
+	switch c.Compression {
+	case "":
+		c.Compression = AutoCompression
+	case AutoCompression, NoCompression:
+	default:
+		return nil, fmt.Errorf("invalid compression '%s'", c.Compression)
+	}
+
+	switch c.AfterRead {
+	case "":
+		c.AfterRead = NoAfterRead
+	case NoAfterRead, DeleteAfterRead:
+	case MoveAfterRead:
+		if c.MoveTo == "" {
+			return nil, fmt.Errorf("`move_to` is required when `after_read` is `move`")
+		}
+	default:
+		return nil, fmt.Errorf("invalid after_read action '%s'", c.AfterRead)
+	}
+
+	if c.AfterReadDelay.Raw() < 0 {
+		return nil, fmt.Errorf("`after_read_delay` must not be negative")
+	}
+
+	if c.IgnoreOlder.Raw() < 0 {
+		return nil, fmt.Errorf("`ignore_older` must not be negative")
+	}
+
+	if c.CloseInactive.Raw() < 0 {
+		return nil, fmt.Errorf("`close_inactive` must not be negative")
+	}
+
+	if c.ForceFlushPeriod.Raw() < 0 {
+		return nil, fmt.Errorf("`force_flush_period` must not be negative")
+	}
+
+	var autoEncodings map[string]*detectedEncoding
+	if strings.ToLower(c.Encoding) == AutoEncoding {
+		if autoEncodings, err = c.buildAutoEncodings(); err != nil {
+			return nil, err
+		}
+		c.Encoding = utf8Encoding
+	}
+
 	encoding, err := lookupEncoding(c.Encoding)
 	if err != nil {
 		return nil, err
@@ -134,30 +227,127 @@ func (c InputConfig) Build(context operator.BuildContext) ([]operator.Operator,
 		filePathField = entry.NewLabelField("file_path")
 	}
 
+	fileOffsetField := entry.NewNilField()
+	if c.IncludeFileOffset {
+		fileOffsetField = fieldOrLabel(c.FileOffsetField, "file_offset")
+	}
+
+	lineNumberField := entry.NewNilField()
+	var newline []byte
+	if c.IncludeLineNumber {
+		lineNumberField = fieldOrLabel(c.LineNumberField, "line_number")
+		if newline, err = encodedNewline(encoding); err != nil {
+			return nil, err
+		}
+	}
+
+	fileInodeField, fileDeviceField := entry.NewNilField(), entry.NewNilField()
+	if c.IncludeFileInode {
+		fileInodeField = fieldOrLabel(c.FileInodeField, "file_inode")
+		fileDeviceField = fieldOrLabel(c.FileDeviceField, "file_device")
+	}
+
+	fingerprintIDField := entry.NewNilField()
+	if c.IncludeFingerprintID {
+		fingerprintIDField = fieldOrLabel(c.FingerprintIDField, "fingerprint_id")
+	}
+
 	op := &InputOperator{
-		InputOperator:      inputOperator,
-		Include:            c.Include,
-		Exclude:            c.Exclude,
-		SplitFunc:          splitFunc,
-		PollInterval:       c.PollInterval.Raw(),
-		persist:            helper.NewScopedDBPersister(context.Database, c.ID()),
-		FilePathField:      filePathField,
-		FileNameField:      fileNameField,
-		startAtBeginning:   startAtBeginning,
-		queuedMatches:      make([]string, 0),
-		encoding:           encoding,
-		firstCheck:         true,
-		cancel:             func() {},
-		knownFiles:         make([]*Reader, 0, 10),
-		fingerprintSize:    int(c.FingerprintSize),
-		MaxLogSize:         int(c.MaxLogSize),
-		MaxConcurrentFiles: c.MaxConcurrentFiles,
-		SeenPaths:          make(map[string]struct{}, 100),
+		InputOperator:        inputOperator,
+		Include:              c.Include,
+		Exclude:              c.Exclude,
+		SplitFunc:            splitFunc,
+		ForceFlushPeriod:     c.ForceFlushPeriod.Raw(),
+		PollInterval:         c.PollInterval.Raw(),
+		WatchMode:            c.WatchMode,
+		FallbackPollInterval: c.FallbackPollInterval.Raw(),
+		persist:              helper.NewScopedDBPersister(context.Database, c.ID()),
+		FilePathField:        filePathField,
+		FileNameField:        fileNameField,
+		FileOffsetField:      fileOffsetField,
+		LineNumberField:      lineNumberField,
+		FileInodeField:       fileInodeField,
+		FileDeviceField:      fileDeviceField,
+		FingerprintIDField:   fingerprintIDField,
+		newline:              newline,
+		startAtBeginning:     startAtBeginning,
+		queuedMatches:        make([]string, 0),
+		encoding:             encoding,
+		autoEncodings:        autoEncodings,
+		firstCheck:           true,
+		cancel:               func() {},
+		knownFiles:           make([]*Reader, 0, 10),
+		fingerprintSize:      int(c.FingerprintSize),
+		MaxLogSize:           int(c.MaxLogSize),
+		MaxConcurrentFiles:   c.MaxConcurrentFiles,
+		IgnoreOlder:          c.IgnoreOlder.Raw(),
+		CloseInactive:        c.CloseInactive.Raw(),
+		inactiveFiles:        make(map[string]os.FileInfo),
+		Compression:          c.Compression,
+		AfterRead:            c.AfterRead,
+		AfterReadDelay:       c.AfterReadDelay.Raw(),
+		MoveTo:               c.MoveTo,
+		PathRegex:            pathRegex,
+		pathFields:           pathFields,
+		header:               header,
+		SeenPaths:            make(map[string]struct{}, 100),
 	}
 
 	return []operator.Operator{op}, nil
 }
 
+// buildPathFields compiles the path regex, and pairs each of its named capture groups with
+// the field its value is written to. Groups without a field in path_fields are written to
+// a label of the same name.
+func (c InputConfig) buildPathFields() (*regexp.Regexp, []pathField, error) {
+	if c.PathRegex == "" {
+		if len(c.PathFields) > 0 {
+			return nil, nil, fmt.Errorf("`path_fields` requires `path_regex`")
+		}
+		return nil, nil, nil
+	}
+
+	re, err := regexp.Compile(c.PathRegex)
+	if err != nil {
+		return nil, nil, fmt.Errorf("compiling path_regex: %s", err)
+	}
+
+	groups := make(map[string]struct{})
+	pathFields := make([]pathField, 0, re.NumSubexp())
+	for i, name := range re.SubexpNames() {
+		if name == "" {
+			continue
+		}
+		groups[name] = struct{}{}
+
+		field, ok := c.PathFields[name]
+		if !ok {
+			field = entry.NewLabelField(name)
+		}
+		pathFields = append(pathFields, pathField{group: i, field: field})
+	}
+
+	if len(pathFields) == 0 {
+		return nil, nil, fmt.Errorf("`path_regex` must contain at least one named capture group")
+	}
+
+	for name := range c.PathFields {
+		if _, ok := groups[name]; !ok {
+			return nil, nil, fmt.Errorf("`path_fields` key '%s' is not a named capture group of `path_regex`", name)
+		}
+	}
+
+	return re, pathFields, nil
+}
+
+// fieldOrLabel returns the configured field, or the label of the given name if none is set
+func fieldOrLabel(field *entry.Field, label string) entry.Field {
+	if field != nil {
+		return *field
+	}
+	return entry.NewLabelField(label)
+}
+
 var encodingOverrides = map[string]encoding.Encoding{
 	"utf-16":   unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM),
 	"utf16":    unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM),
@@ -187,27 +377,5 @@ func (c InputConfig) getSplitFunc(encoding encoding.Encoding) (bufio.SplitFunc,
 	if c.Multiline == nil {
 		return NewNewlineSplitFunc(encoding)
 	}
-	endPattern := c.Multiline.LineEndPattern
-	startPattern := c.Multiline.LineStartPattern
-
-	switch {
-	case endPattern != "" && startPattern != "":
-		return nil, fmt.Errorf("only one of line_start_pattern or line_end_pattern can be set")
-	case endPattern == "" && startPattern == "":
-		return nil, fmt.Errorf("one of line_start_pattern or line_end_pattern must be set")
-	case endPattern != "":
-		re, err := regexp.Compile("(?m)" + c.Multiline.LineEndPattern)
-		if err != nil {
-			return nil, fmt.Errorf("compile line end regex: %s", err)
-		}
-		return NewLineEndSplitFunc(re), nil
-	case startPattern != "":
-		re, err := regexp.Compile("(?m)" + c.Multiline.LineStartPattern)
-		if err != nil {
-			return nil, fmt.Errorf("compile line start regex: %s", err)
-		}
-		return NewLineStartSplitFunc(re), nil
-	default:
-		return nil, fmt.Errorf("unreachable")
-	}
+	return c.Multiline.Build()
 }
@@ -0,0 +1,116 @@
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// AutoEncoding detects the encoding of each file from its byte order mark
+const AutoEncoding = "auto"
+
+// These are the encodings detected by the auto encoding
+const (
+	utf8Encoding    = "utf-8"
+	utf16leEncoding = "utf-16le"
+	utf16beEncoding = "utf-16be"
+)
+
+// byteOrderMark is a byte order mark decoded to UTF-8
+const byteOrderMark = "\ufeff"
+
+var byteOrderMarks = []struct {
+	bom      []byte
+	encoding string
+}{
+	{[]byte{0xef, 0xbb, 0xbf}, utf8Encoding},
+	{[]byte{0xff, 0xfe}, utf16leEncoding},
+	{[]byte{0xfe, 0xff}, utf16beEncoding},
+}
+
+// detectedEncoding is an encoding detected by the auto encoding, and the values that
+// depend on it
+type detectedEncoding struct {
+	encoding  encoding.Encoding
+	splitFunc bufio.SplitFunc
+	newline   []byte
+}
+
+// buildAutoEncodings creates the encodings that can be detected from a byte order mark
+func (c InputConfig) buildAutoEncodings() (map[string]*detectedEncoding, error) {
+	encodings := map[string]encoding.Encoding{
+		utf8Encoding:    unicode.UTF8,
+		utf16leEncoding: unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM),
+		utf16beEncoding: unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM),
+	}
+
+	detected := make(map[string]*detectedEncoding, len(encodings))
+	for name, enc := range encodings {
+		splitFunc, err := c.getSplitFunc(enc)
+		if err != nil {
+			return nil, err
+		}
+
+		var newline []byte
+		if c.IncludeLineNumber {
+			if newline, err = encodedNewline(enc); err != nil {
+				return nil, err
+			}
+		}
+
+		detected[name] = &detectedEncoding{
+			encoding:  enc,
+			splitFunc: splitFunc,
+			newline:   newline,
+		}
+	}
+	return detected, nil
+}
+
+// detectEncoding returns the encoding of a file from the byte order mark at the start of
+// its first bytes. Files without a byte order mark are UTF-8.
+func detectEncoding(firstBytes []byte) string {
+	for _, bom := range byteOrderMarks {
+		if bytes.HasPrefix(firstBytes, bom.bom) {
+			return bom.encoding
+		}
+	}
+	return utf8Encoding
+}
+
+// resolveEncoding detects the encoding of the file if it is not known yet, and uses it
+// to decode and split the file
+func (f *Reader) resolveEncoding() {
+	if f.fileInput.autoEncodings == nil || f.encodingResolved {
+		return
+	}
+
+	if f.Encoding == "" {
+		f.Encoding = detectEncoding(f.Fingerprint.FirstBytes)
+		f.Debugw("Detected file encoding", "encoding", f.Encoding)
+	}
+
+	detected, ok := f.fileInput.autoEncodings[f.Encoding]
+	if !ok {
+		f.Warnw("Unknown file encoding. Reading it as UTF-8", "encoding", f.Encoding)
+		f.Encoding = utf8Encoding
+		detected = f.fileInput.autoEncodings[utf8Encoding]
+	}
+
+	f.decoder = detected.encoding.NewDecoder()
+	f.splitFunc = detected.splitFunc
+	f.newline = detected.newline
+	f.encodingResolved = true
+}
+
+// trimByteOrderMark removes the byte order mark from a message at the start of a file
+// whose encoding was detected from it
+func (f *Reader) trimByteOrderMark(msg string, offset int64) string {
+	if offset != 0 || !f.encodingResolved {
+		return msg
+	}
+	return strings.TrimPrefix(msg, byteOrderMark)
+}
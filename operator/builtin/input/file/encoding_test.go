@@ -0,0 +1,117 @@
+package file
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func encodeString(t *testing.T, enc encoding.Encoding, s string) string {
+	encoded, err := enc.NewEncoder().String(s)
+	require.NoError(t, err)
+	return encoded
+}
+
+func TestDetectEncoding(t *testing.T) {
+	cases := []struct {
+		name       string
+		firstBytes []byte
+		expected   string
+	}{
+		{"UTF8BOM", []byte("\xef\xbb\xbftestlog"), utf8Encoding},
+		{"UTF16LEBOM", []byte("\xff\xfet\x00"), utf16leEncoding},
+		{"UTF16BEBOM", []byte("\xfe\xff\x00t"), utf16beEncoding},
+		{"NoBOM", []byte("testlog"), utf8Encoding},
+		{"Empty", []byte{}, utf8Encoding},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, detectEncoding(tc.firstBytes))
+		})
+	}
+}
+
+func TestAutoEncoding(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, func(cfg *InputConfig) {
+		cfg.Encoding = AutoEncoding
+		cfg.IncludeLineNumber = true
+	}, nil)
+
+	files := map[string]string{
+		"utf8.log":    "\xef\xbb\xbfutf8 log1\nutf8 log2\n",
+		"utf16le.log": encodeString(t, unicode.UTF16(unicode.LittleEndian, unicode.UseBOM), "utf16le log1\r\nutf16le log2\r\n"),
+		"utf16be.log": encodeString(t, unicode.UTF16(unicode.BigEndian, unicode.UseBOM), "utf16be log1\nutf16be log2\n"),
+		"plain.log":   "plain log1\nplain log2\n",
+	}
+	for name, contents := range files {
+		writeString(t, openFile(t, filepath.Join(tempDir, name)), contents)
+	}
+
+	operator.poll(context.Background())
+	defer operator.Stop()
+
+	received := map[string]interface{}{}
+	for i := 0; i < 8; i++ {
+		e := waitForOne(t, logReceived)
+		received[e.Record.(string)] = e.Labels["line_number"]
+	}
+	require.Equal(t, map[string]interface{}{
+		"utf8 log1":    int64(1),
+		"utf8 log2":    int64(2),
+		"utf16le log1": int64(1),
+		"utf16le log2": int64(2),
+		"utf16be log1": int64(1),
+		"utf16be log2": int64(2),
+		"plain log1":   int64(1),
+		"plain log2":   int64(2),
+	}, received)
+	expectNoMessages(t, logReceived)
+}
+
+func TestAutoEncodingAfterRestart(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, func(cfg *InputConfig) {
+		cfg.Encoding = AutoEncoding
+	}, nil)
+
+	temp := openFile(t, filepath.Join(tempDir, "test.log"))
+	writeString(t, temp, encodeString(t, unicode.UTF16(unicode.LittleEndian, unicode.UseBOM), "testlog1\n"))
+
+	require.NoError(t, operator.Start())
+	defer operator.Stop()
+	waitForMessage(t, logReceived, "testlog1")
+
+	// The detected encoding is persisted with the offset, and used for data written
+	// after the byte order mark
+	require.NoError(t, operator.Stop())
+	require.NoError(t, operator.Start())
+	require.Equal(t, utf16leEncoding, operator.knownFiles[len(operator.knownFiles)-1].Encoding)
+
+	writeString(t, temp, encodeString(t, unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM), "testlog2\n"))
+	waitForMessage(t, logReceived, "testlog2")
+	expectNoMessages(t, logReceived)
+}
+
+func TestAutoEncodingCSVHeader(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, func(cfg *InputConfig) {
+		cfg.Encoding = AutoEncoding
+		cfg.Header = &HeaderConfig{Format: CSVHeaderFormat}
+	}, nil)
+
+	writeString(t, openFile(t, filepath.Join(tempDir, "export.csv")), "\xef\xbb\xbfname,count\nfoo,1\n")
+
+	operator.poll(context.Background())
+	defer operator.Stop()
+
+	// The byte order mark is not part of the header
+	e := waitForOne(t, logReceived)
+	require.Equal(t, "foo,1", e.Record)
+	require.Equal(t, "name,count", e.Labels["header"])
+}
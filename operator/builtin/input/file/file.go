@@ -7,10 +7,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
+	"regexp"
 	"sync"
 	"time"
 
+	"github.com/bmatcuk/doublestar/v2"
 	"github.com/observiq/stanza/entry"
 	"github.com/observiq/stanza/operator/helper"
 	"go.uber.org/zap"
@@ -21,27 +22,55 @@ import (
 type InputOperator struct {
 	helper.InputOperator
 
-	Include            []string
-	Exclude            []string
-	FilePathField      entry.Field
-	FileNameField      entry.Field
-	PollInterval       time.Duration
-	SplitFunc          bufio.SplitFunc
-	MaxLogSize         int
-	MaxConcurrentFiles int
-	SeenPaths          map[string]struct{}
+	Include              []string
+	Exclude              []string
+	FilePathField        entry.Field
+	FileNameField        entry.Field
+	FileOffsetField      entry.Field
+	LineNumberField      entry.Field
+	FileInodeField       entry.Field
+	FileDeviceField      entry.Field
+	FingerprintIDField   entry.Field
+	PollInterval         time.Duration
+	WatchMode            string
+	FallbackPollInterval time.Duration
+	SplitFunc            bufio.SplitFunc
+	ForceFlushPeriod     time.Duration
+	MaxLogSize           int
+	MaxConcurrentFiles   int
+	IgnoreOlder          time.Duration
+	CloseInactive        time.Duration
+	Compression          string
+	AfterRead            string
+	AfterReadDelay       time.Duration
+	MoveTo               string
+	PathRegex            *regexp.Regexp
+	SeenPaths            map[string]struct{}
 
 	persist helper.Persister
 
+	pathFields []pathField
+	header     *header
+
+	// newline is the encoded newline that lines are counted by, or nil if they are not
+	newline []byte
+
 	knownFiles    []*Reader
 	queuedMatches []string
 
+	// inactiveFiles are the infos of files that were closed as inactive, by path
+	inactiveFiles map[string]os.FileInfo
+
 	startAtBeginning bool
 
 	fingerprintSize int
 
 	encoding encoding.Encoding
 
+	// autoEncodings are the encodings that can be detected by name, or nil if the
+	// encoding is not auto
+	autoEncodings map[string]*detectedEncoding
+
 	wg         sync.WaitGroup
 	readerWg   sync.WaitGroup
 	firstCheck bool
@@ -50,6 +79,10 @@ type InputOperator struct {
 
 // Start will start the file monitoring process
 func (f *InputOperator) Start() error {
+	if err := f.checkAfterReadOutputs(); err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	f.cancel = cancel
 	f.firstCheck = true
@@ -59,7 +92,14 @@ func (f *InputOperator) Start() error {
 		return fmt.Errorf("read known files from database: %s", err)
 	}
 
-	// Start polling goroutine
+	// Start watching for changes, falling back to polling if the filesystem can't be watched
+	if f.WatchMode == FsnotifyWatchMode {
+		err := f.startWatcher(ctx)
+		if err == nil {
+			return nil
+		}
+		f.Warnw("Failed to start file watcher. Falling back to polling", zap.Error(err))
+	}
 	f.startPoller(ctx)
 
 	return nil
@@ -111,7 +151,7 @@ func (f *InputOperator) poll(ctx context.Context) {
 		}
 
 		// Get the list of paths on disk
-		matches = getMatches(f.Include, f.Exclude)
+		matches = f.filterMatches(getMatches(f.Include, f.Exclude))
 		if f.firstCheck && len(matches) == 0 {
 			f.Warnw("no files match the configured include patterns", "include", f.Include)
 		} else if len(matches) > f.MaxConcurrentFiles {
@@ -122,7 +162,8 @@ func (f *InputOperator) poll(ctx context.Context) {
 	// Open the files first to minimize the time between listing and opening
 	files := make([]*os.File, 0, len(matches))
 	for _, path := range matches {
-		if _, ok := f.SeenPaths[path]; !ok {
+		_, seen := f.SeenPaths[path]
+		if !seen {
 			if f.startAtBeginning {
 				f.Infow("Started watching file", "path", path)
 			} else {
@@ -135,6 +176,9 @@ func (f *InputOperator) poll(ctx context.Context) {
 			f.Errorw("Failed to open file", zap.Error(err))
 			continue
 		}
+		if !seen {
+			f.checkCompression(file)
+		}
 		files = append(files, file)
 	}
 
@@ -158,6 +202,9 @@ func (f *InputOperator) poll(ctx context.Context) {
 		file.Close()
 	}
 
+	f.afterRead(readers)
+	f.closeInactive(readers)
+
 	f.saveCurrent(readers)
 	f.syncLastPollFiles()
 }
@@ -166,15 +213,19 @@ func (f *InputOperator) poll(ctx context.Context) {
 func getMatches(includes, excludes []string) []string {
 	all := make([]string, 0, len(includes))
 	for _, include := range includes {
-		matches, _ := filepath.Glob(include) // compile error checked in build
+		matches, _ := doublestar.Glob(include) // compile error checked in build
 	INCLUDE:
 		for _, match := range matches {
 			for _, exclude := range excludes {
-				if itMatches, _ := filepath.Match(exclude, match); itMatches {
+				if itMatches, _ := doublestar.PathMatch(exclude, match); itMatches {
 					continue INCLUDE
 				}
 			}
 
+			if info, err := os.Stat(match); err == nil && info.IsDir() {
+				continue
+			}
+
 			for _, existing := range all {
 				if existing == match {
 					continue INCLUDE
@@ -192,6 +243,8 @@ func getMatches(includes, excludes []string) []string {
 // discarding any that have a duplicate fingerprint to other files that have already
 // been read this polling interval
 func (f *InputOperator) makeReaders(files []*os.File) []*Reader {
+	files = f.dedupeFiles(files)
+
 	// Get fingerprints for each file
 	fps := make([]*Fingerprint, 0, len(files))
 	for _, file := range files {
@@ -248,6 +301,36 @@ OUTER:
 	return readers
 }
 
+// dedupeFiles removes files that are the same as a file earlier in the list, which happens
+// when a file is matched both through a symlink and through its real path. Files are
+// compared by their device and inode.
+func (f *InputOperator) dedupeFiles(files []*os.File) []*os.File {
+	type id struct{ inode, device uint64 }
+	seen := make(map[id]string, len(files))
+	deduped := make([]*os.File, 0, len(files))
+	for _, file := range files {
+		info, err := file.Stat()
+		if err != nil {
+			deduped = append(deduped, file)
+			continue
+		}
+		inode, device, ok := fileID(file, info)
+		if !ok {
+			deduped = append(deduped, file)
+			continue
+		}
+
+		key := id{inode, device}
+		if path, ok := seen[key]; ok {
+			f.Debugw("Skipping file that is the same as another matched file", "path", file.Name(), "same_as", path)
+			continue
+		}
+		seen[key] = file.Name()
+		deduped = append(deduped, file)
+	}
+	return deduped
+}
+
 // saveCurrent adds the readers from this polling interval to this list of
 // known files, then increments the generation of all tracked old readers
 // before clearing out readers that have existed for 3 generations.
@@ -0,0 +1,18 @@
+// +build windows
+
+package file
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileID returns the file index and volume serial number of a file, which identify
+// it like an inode and device number
+func fileID(file *os.File, _ os.FileInfo) (uint64, uint64, bool) {
+	var info syscall.ByHandleFileInformation
+	if err := syscall.GetFileInformationByHandle(syscall.Handle(file.Fd()), &info); err != nil {
+		return 0, 0, false
+	}
+	return uint64(info.FileIndexHigh)<<32 | uint64(info.FileIndexLow), uint64(info.VolumeSerialNumber), true
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/observiq/stanza/operator"
 	"github.com/observiq/stanza/operator/helper"
 	"github.com/observiq/stanza/testutil"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -138,6 +139,154 @@ func TestBuild(t *testing.T) {
 			require.Error,
 			nil,
 		},
+		{
+			"InvalidCompression",
+			func(f *InputConfig) {
+				f.Compression = "invalid"
+			},
+			require.Error,
+			nil,
+		},
+		{
+			"InvalidAfterRead",
+			func(f *InputConfig) {
+				f.AfterRead = "invalid"
+			},
+			require.Error,
+			nil,
+		},
+		{
+			"MoveWithoutMoveTo",
+			func(f *InputConfig) {
+				f.AfterRead = MoveAfterRead
+			},
+			require.Error,
+			nil,
+		},
+		{
+			"Move",
+			func(f *InputConfig) {
+				f.AfterRead = MoveAfterRead
+				f.MoveTo = "/var/log/archive"
+			},
+			require.NoError,
+			func(t *testing.T, f *InputOperator) {
+				require.Equal(t, MoveAfterRead, f.AfterRead)
+				require.Equal(t, "/var/log/archive", f.MoveTo)
+				require.Equal(t, defaultAfterReadDelay, f.AfterReadDelay)
+			},
+		},
+		{
+			"PathRegex",
+			func(f *InputConfig) {
+				f.PathRegex = `^/var/log/(?P<app>[^/]+)/(?P<host>[^/]+)\.log$`
+				f.PathFields = map[string]entry.Field{"host": entry.NewResourceField("host.name")}
+			},
+			require.NoError,
+			func(t *testing.T, f *InputOperator) {
+				require.Equal(t, []pathField{
+					{group: 1, field: entry.NewLabelField("app")},
+					{group: 2, field: entry.NewResourceField("host.name")},
+				}, f.pathFields)
+			},
+		},
+		{
+			"InvalidPathRegex",
+			func(f *InputConfig) {
+				f.PathRegex = "("
+			},
+			require.Error,
+			nil,
+		},
+		{
+			"PathRegexWithoutNamedGroups",
+			func(f *InputConfig) {
+				f.PathRegex = "^/var/log/(.*)$"
+			},
+			require.Error,
+			nil,
+		},
+		{
+			"PathFieldsWithoutPathRegex",
+			func(f *InputConfig) {
+				f.PathFields = map[string]entry.Field{"app": entry.NewLabelField("app")}
+			},
+			require.Error,
+			nil,
+		},
+		{
+			"PathFieldsUnknownGroup",
+			func(f *InputConfig) {
+				f.PathRegex = "^/var/log/(?P<app>[^/]+)/"
+				f.PathFields = map[string]entry.Field{"host": entry.NewLabelField("host")}
+			},
+			require.Error,
+			nil,
+		},
+		{
+			"IgnoreOlderAndCloseInactive",
+			func(f *InputConfig) {
+				f.IgnoreOlder = helper.Duration{Duration: 24 * time.Hour}
+				f.CloseInactive = helper.Duration{Duration: 5 * time.Minute}
+			},
+			require.NoError,
+			func(t *testing.T, f *InputOperator) {
+				require.Equal(t, 24*time.Hour, f.IgnoreOlder)
+				require.Equal(t, 5*time.Minute, f.CloseInactive)
+			},
+		},
+		{
+			"NegativeIgnoreOlder",
+			func(f *InputConfig) {
+				f.IgnoreOlder = helper.Duration{Duration: -time.Second}
+			},
+			require.Error,
+			nil,
+		},
+		{
+			"NegativeCloseInactive",
+			func(f *InputConfig) {
+				f.CloseInactive = helper.Duration{Duration: -time.Second}
+			},
+			require.Error,
+			nil,
+		},
+		{
+			"Header",
+			func(f *InputConfig) {
+				f.Header = &HeaderConfig{Format: W3CHeaderFormat}
+			},
+			require.NoError,
+			func(t *testing.T, f *InputOperator) {
+				require.Equal(t, W3CHeaderFormat, f.header.format)
+			},
+		},
+		{
+			"InvalidHeader",
+			func(f *InputConfig) {
+				f.Header = &HeaderConfig{Format: "invalid"}
+			},
+			require.Error,
+			nil,
+		},
+		{
+			"NegativeForceFlushPeriod",
+			func(f *InputConfig) {
+				f.ForceFlushPeriod = helper.Duration{Duration: -time.Second}
+			},
+			require.Error,
+			nil,
+		},
+		{
+			"AutoEncoding",
+			func(f *InputConfig) {
+				f.Encoding = AutoEncoding
+			},
+			require.NoError,
+			func(t *testing.T, f *InputOperator) {
+				require.Len(t, f.autoEncodings, 3)
+			},
+		},
 		{
 			"BadExcludeGlob",
 			func(f *InputConfig) {
@@ -1162,6 +1311,43 @@ func TestFileReader_FingerprintUpdated(t *testing.T) {
 	require.Equal(t, []byte("testlog1\n"), reader.Fingerprint.FirstBytes)
 }
 
+func TestFileReader_CancelledDoesNotAdvance(t *testing.T) {
+	t.Parallel()
+
+	input, logReceived, tempDir := newTestFileOperator(t, nil, nil)
+	fake := input.OutputOperators[0]
+
+	// The output cancels the read while it receives the first batch
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cancelling := &testutil.Operator{}
+	cancelling.On("Process", mock.Anything, mock.Anything).Return(nil).Run(func(mock.Arguments) { cancel() })
+	input.OutputOperators = []operator.Operator{cancelling}
+
+	temp := openTemp(t, tempDir)
+	for i := 0; i < emitBatchSize+10; i++ {
+		writeString(t, temp, fmt.Sprintf("testlog%d\n", i))
+	}
+
+	fp, err := input.NewFingerprint(temp)
+	require.NoError(t, err)
+	reader, err := input.NewReader(temp.Name(), openFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+
+	reader.ReadToEnd(ctx)
+	require.Equal(t, int64(0), reader.Offset)
+	require.Equal(t, int64(0), reader.LineNumber)
+
+	// The entries are read again by the reader of the next poll
+	input.OutputOperators = []operator.Operator{fake}
+	next, err := reader.Copy(openFile(t, temp.Name()))
+	require.NoError(t, err)
+	go next.ReadToEnd(context.Background())
+	for i := 0; i < emitBatchSize+10; i++ {
+		waitForMessage(t, logReceived, fmt.Sprintf("testlog%d", i))
+	}
+}
+
 func stringWithLength(length int) string {
 	charset := "abcdefghijklmnopqrstuvwxyz"
 	b := make([]byte, length)
@@ -1427,3 +1613,60 @@ func writeTempFiles(tempDir string, names []string) []string {
 	}
 	return result
 }
+
+func TestForceFlushPeriod(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, func(cfg *InputConfig) {
+		cfg.Multiline = &MultilineConfig{LineStartPattern: "^LOGSTART"}
+		cfg.ForceFlushPeriod = helper.Duration{Duration: 100 * time.Millisecond}
+	}, nil)
+
+	path := filepath.Join(tempDir, "test.log")
+	temp := openFile(t, path)
+	writeString(t, temp, "LOGSTART log1\nLOGSTART log2\n  at stack\n")
+
+	// The last entry is held while the file is being written
+	operator.poll(context.Background())
+	defer operator.Stop()
+	waitForMessage(t, logReceived, "LOGSTART log1\n")
+	expectNoMessages(t, logReceived)
+
+	// Once the file has been idle for the flush period, the last entry is read
+	time.Sleep(150 * time.Millisecond)
+	operator.poll(context.Background())
+	waitForMessage(t, logReceived, "LOGSTART log2\n  at stack\n")
+
+	// The offset is at the end of the flushed entry, so it is not read again
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, info.Size(), operator.knownFiles[len(operator.knownFiles)-1].Offset)
+
+	writeString(t, temp, "LOGSTART log3\n")
+	time.Sleep(150 * time.Millisecond)
+	operator.poll(context.Background())
+	waitForMessage(t, logReceived, "LOGSTART log3\n")
+	expectNoMessages(t, logReceived)
+}
+
+func TestForceFlushPeriodAfterRestart(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, func(cfg *InputConfig) {
+		cfg.Multiline = &MultilineConfig{LineStartPattern: "^LOGSTART"}
+		cfg.ForceFlushPeriod = helper.Duration{Duration: 100 * time.Millisecond}
+	}, nil)
+
+	temp := openFile(t, filepath.Join(tempDir, "test.log"))
+	writeString(t, temp, "LOGSTART log1\n  at stack\n")
+
+	require.NoError(t, operator.Start())
+	defer operator.Stop()
+	waitForMessage(t, logReceived, "LOGSTART log1\n  at stack\n")
+
+	// The offset of the flushed entry is persisted, so it is not read again after a restart
+	require.NoError(t, operator.Stop())
+	require.NoError(t, operator.Start())
+
+	writeString(t, temp, "LOGSTART log2\n")
+	waitForMessage(t, logReceived, "LOGSTART log2\n")
+	expectNoMessages(t, logReceived)
+}
@@ -15,11 +15,30 @@ type Fingerprint struct {
 	FirstBytes []byte
 }
 
-// NewFingerprint creates a new fingerprint from an open file
+// NewFingerprint creates a new fingerprint from an open file. The fingerprint of a
+// compressed file is taken from its decompressed contents, and is empty if the file's
+// compression is not supported.
 func (f *InputOperator) NewFingerprint(file *os.File) (*Fingerprint, error) {
 	buf := make([]byte, f.fingerprintSize)
 
-	n, err := file.ReadAt(buf, 0)
+	c, err := f.detectCompression(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var n int
+	switch c {
+	case uncompressed:
+		n, err = file.ReadAt(buf, 0)
+	case gzipCompressed:
+		var r io.Reader
+		if r, err = newDecompressor(file, c, 0); err == nil {
+			n, err = io.ReadFull(r, buf)
+		}
+		if isIncomplete(err) {
+			err = nil
+		}
+	}
 	if err != nil && err != io.EOF {
 		return nil, fmt.Errorf("reading fingerprint bytes: %s", err)
 	}
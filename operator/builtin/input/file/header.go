@@ -0,0 +1,115 @@
+package file
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/observiq/stanza/entry"
+)
+
+// These are the formats of header lines a file input can read
+const (
+	// W3CHeaderFormat reads the directives of W3C extended log files. Lines starting with
+	// '#' are directives, and the '#Fields:' directive declares the header.
+	W3CHeaderFormat = "w3c"
+
+	// CSVHeaderFormat reads the first line of a file as its header
+	CSVHeaderFormat = "csv"
+
+	// RegexHeaderFormat reads lines that match a pattern as headers
+	RegexHeaderFormat = "regex"
+)
+
+const w3cFieldsDirective = "#Fields:"
+
+// HeaderConfig is the configuration of the header lines of a file
+type HeaderConfig struct {
+	Format  string      `json:"format"            yaml:"format"`
+	Pattern string      `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	Field   entry.Field `json:"field,omitempty"   yaml:"field,omitempty"`
+}
+
+// Build creates a header reader from the config
+func (c HeaderConfig) Build() (*header, error) {
+	h := &header{
+		format: c.Format,
+		field:  c.Field,
+	}
+	if h.field.FieldInterface == nil {
+		h.field = entry.NewLabelField("header")
+	}
+
+	switch c.Format {
+	case W3CHeaderFormat, CSVHeaderFormat:
+		if c.Pattern != "" {
+			return nil, fmt.Errorf("`pattern` is only used with the `%s` header format", RegexHeaderFormat)
+		}
+	case RegexHeaderFormat:
+		if c.Pattern == "" {
+			return nil, fmt.Errorf("`pattern` is required for the `%s` header format", RegexHeaderFormat)
+		}
+		re, err := regexp.Compile(c.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("compiling header pattern: %s", err)
+		}
+		h.regex = re
+	case "":
+		return nil, fmt.Errorf("missing required header `format`")
+	default:
+		return nil, fmt.Errorf("invalid header format '%s'", c.Format)
+	}
+
+	return h, nil
+}
+
+// header reads the header lines of a file
+type header struct {
+	format string
+	regex  *regexp.Regexp
+	field  entry.Field
+}
+
+// parse returns whether a line is a header line, and the header it declares. Header lines
+// that don't declare a header, such as other W3C directives, return an empty header.
+func (h *header) parse(line string, first bool) (string, bool) {
+	switch h.format {
+	case W3CHeaderFormat:
+		if !strings.HasPrefix(line, "#") {
+			return "", false
+		}
+		if strings.HasPrefix(line, w3cFieldsDirective) {
+			return strings.TrimSpace(strings.TrimPrefix(line, w3cFieldsDirective)), true
+		}
+		return "", true
+	case CSVHeaderFormat:
+		if !first {
+			return "", false
+		}
+		return line, true
+	default:
+		matches := h.regex.FindStringSubmatch(line)
+		switch {
+		case matches == nil:
+			return "", false
+		case len(matches) > 1:
+			return matches[1], true
+		default:
+			return matches[0], true
+		}
+	}
+}
+
+// readHeader returns the header of the file after a message, given the current header, and
+// whether the message is a header line. The first message of the file is at the start of the file.
+func (f *Reader) readHeader(msg string, first bool, current string) (string, bool) {
+	header, ok := f.fileInput.header.parse(msg, first)
+	if !ok {
+		return current, false
+	}
+	if header != "" && header != current {
+		f.Debugw("Read file header", "header", header)
+		return header, true
+	}
+	return current, true
+}
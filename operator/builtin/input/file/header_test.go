@@ -0,0 +1,220 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/observiq/stanza/entry"
+	"github.com/observiq/stanza/operator"
+	"github.com/observiq/stanza/testutil"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeaderConfigBuild(t *testing.T) {
+	cases := []struct {
+		name      string
+		config    HeaderConfig
+		expectErr bool
+	}{
+		{"W3C", HeaderConfig{Format: W3CHeaderFormat}, false},
+		{"CSV", HeaderConfig{Format: CSVHeaderFormat}, false},
+		{"Regex", HeaderConfig{Format: RegexHeaderFormat, Pattern: "^HEADER (.*)$"}, false},
+		{"MissingFormat", HeaderConfig{}, true},
+		{"InvalidFormat", HeaderConfig{Format: "invalid"}, true},
+		{"RegexWithoutPattern", HeaderConfig{Format: RegexHeaderFormat}, true},
+		{"InvalidPattern", HeaderConfig{Format: RegexHeaderFormat, Pattern: "("}, true},
+		{"PatternWithoutRegex", HeaderConfig{Format: CSVHeaderFormat, Pattern: ".*"}, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h, err := tc.config.Build()
+			if tc.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, entry.NewLabelField("header"), h.field)
+		})
+	}
+}
+
+func TestHeaderParse(t *testing.T) {
+	cases := []struct {
+		name           string
+		config         HeaderConfig
+		line           string
+		first          bool
+		expectedHeader string
+		expectedOk     bool
+	}{
+		{"W3CFields", HeaderConfig{Format: W3CHeaderFormat}, "#Fields: date time cs-method", false, "date time cs-method", true},
+		{"W3CDirective", HeaderConfig{Format: W3CHeaderFormat}, "#Version: 1.0", false, "", true},
+		{"W3CLine", HeaderConfig{Format: W3CHeaderFormat}, "2021-01-01 00:00:00 GET", true, "", false},
+		{"CSVFirst", HeaderConfig{Format: CSVHeaderFormat}, "a,b,c", true, "a,b,c", true},
+		{"CSVNotFirst", HeaderConfig{Format: CSVHeaderFormat}, "1,2,3", false, "", false},
+		{"RegexGroup", HeaderConfig{Format: RegexHeaderFormat, Pattern: "^HEADER (.*)$"}, "HEADER a b", false, "a b", true},
+		{"RegexNoGroup", HeaderConfig{Format: RegexHeaderFormat, Pattern: "^HEADER"}, "HEADER a b", false, "HEADER", true},
+		{"RegexNoMatch", HeaderConfig{Format: RegexHeaderFormat, Pattern: "^HEADER"}, "a b", true, "", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h, err := tc.config.Build()
+			require.NoError(t, err)
+			header, ok := h.parse(tc.line, tc.first)
+			require.Equal(t, tc.expectedHeader, header)
+			require.Equal(t, tc.expectedOk, ok)
+		})
+	}
+}
+
+func TestW3CHeader(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, func(cfg *InputConfig) {
+		cfg.Header = &HeaderConfig{Format: W3CHeaderFormat}
+	}, nil)
+
+	temp := openFile(t, filepath.Join(tempDir, "u_ex210101.log"))
+	writeString(t, temp, "#Software: Microsoft Internet Information Services 10.0\n#Fields: date time cs-method\n2021-01-01 00:00:00 GET\n")
+
+	operator.poll(context.Background())
+	defer operator.Stop()
+
+	e := waitForOne(t, logReceived)
+	require.Equal(t, "2021-01-01 00:00:00 GET", e.Record)
+	require.Equal(t, "date time cs-method", e.Labels["header"])
+
+	// The header can change within a file
+	writeString(t, temp, "#Fields: date time cs-method cs-uri-stem\n2021-01-01 00:00:01 GET /\n")
+	operator.poll(context.Background())
+
+	e = waitForOne(t, logReceived)
+	require.Equal(t, "2021-01-01 00:00:01 GET /", e.Record)
+	require.Equal(t, "date time cs-method cs-uri-stem", e.Labels["header"])
+	expectNoMessages(t, logReceived)
+}
+
+func TestCSVHeader(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, func(cfg *InputConfig) {
+		cfg.Header = &HeaderConfig{
+			Format: CSVHeaderFormat,
+			Field:  entry.NewRecordField("header"),
+		}
+		cfg.WriteTo = entry.NewRecordField("message")
+	}, nil)
+
+	temp := openFile(t, filepath.Join(tempDir, "export.csv"))
+	writeString(t, temp, "name,count\nfoo,1\nbar,2\n")
+
+	operator.poll(context.Background())
+	defer operator.Stop()
+
+	for _, expected := range []string{"foo,1", "bar,2"} {
+		e := waitForOne(t, logReceived)
+		require.Equal(t, map[string]interface{}{
+			"message": expected,
+			"header":  "name,count",
+		}, e.Record)
+	}
+	expectNoMessages(t, logReceived)
+}
+
+func TestHeaderStartAtEnd(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, func(cfg *InputConfig) {
+		cfg.Header = &HeaderConfig{Format: W3CHeaderFormat}
+		cfg.StartAt = "end"
+	}, nil)
+
+	temp := openFile(t, filepath.Join(tempDir, "u_ex210101.log"))
+	writeString(t, temp, "#Fields: date time cs-method\n2021-01-01 00:00:00 GET\n")
+
+	operator.poll(context.Background())
+	defer operator.Stop()
+	expectNoMessages(t, logReceived)
+
+	// The header before the starting offset is still known
+	writeString(t, temp, "2021-01-01 00:00:01 POST\n")
+	operator.poll(context.Background())
+
+	e := waitForOne(t, logReceived)
+	require.Equal(t, "2021-01-01 00:00:01 POST", e.Record)
+	require.Equal(t, "date time cs-method", e.Labels["header"])
+}
+
+func TestHeaderAfterRestart(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, func(cfg *InputConfig) {
+		cfg.Header = &HeaderConfig{Format: CSVHeaderFormat}
+	}, nil)
+
+	temp := openFile(t, filepath.Join(tempDir, "export.csv"))
+	writeString(t, temp, "name,count\nfoo,1\n")
+
+	require.NoError(t, operator.Start())
+	defer operator.Stop()
+	waitForMessage(t, logReceived, "foo,1")
+
+	// The header is persisted with the offset
+	require.NoError(t, operator.Stop())
+	require.NoError(t, operator.Start())
+
+	writeString(t, temp, "bar,2\n")
+	e := waitForOne(t, logReceived)
+	require.Equal(t, "bar,2", e.Record)
+	require.Equal(t, "name,count", e.Labels["header"])
+}
+
+func TestHeaderCancelledDoesNotAdvance(t *testing.T) {
+	t.Parallel()
+	input, logReceived, tempDir := newTestFileOperator(t, func(cfg *InputConfig) {
+		cfg.Header = &HeaderConfig{Format: W3CHeaderFormat}
+	}, nil)
+	fake := input.OutputOperators[0]
+
+	temp := openFile(t, filepath.Join(tempDir, "access.log"))
+	writeString(t, temp, "#Fields: a\nline0\n")
+
+	fp, err := input.NewFingerprint(temp)
+	require.NoError(t, err)
+	reader, err := input.NewReader(temp.Name(), openFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	reader.ReadToEnd(context.Background())
+	waitForMessage(t, logReceived, "line0")
+	offset := reader.Offset
+
+	// A new header is read in the batch that the output cancels the read in
+	writeString(t, temp, "line1\n#Fields: b\n")
+	for i := 2; i < emitBatchSize+2; i++ {
+		writeString(t, temp, fmt.Sprintf("line%d\n", i))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cancelling := &testutil.Operator{}
+	cancelling.On("Process", mock.Anything, mock.Anything).Return(nil).Run(func(mock.Arguments) { cancel() })
+	input.OutputOperators = []operator.Operator{cancelling}
+
+	reader, err = reader.Copy(openFile(t, temp.Name()))
+	require.NoError(t, err)
+	reader.ReadToEnd(ctx)
+	require.Equal(t, offset, reader.Offset)
+	require.Equal(t, "a", reader.Header)
+
+	// The lines before the new header are read again with the header before it
+	input.OutputOperators = []operator.Operator{fake}
+	next, err := reader.Copy(openFile(t, temp.Name()))
+	require.NoError(t, err)
+	go next.ReadToEnd(context.Background())
+	e := waitForOne(t, logReceived)
+	require.Equal(t, "line1", e.Record)
+	require.Equal(t, "a", e.Labels["header"])
+	e = waitForOne(t, logReceived)
+	require.Equal(t, "line2", e.Record)
+	require.Equal(t, "b", e.Labels["header"])
+}
@@ -0,0 +1,136 @@
+package file
+
+import (
+	"os"
+	"sort"
+	"time"
+)
+
+// matchInfo is a matched path and the info of its file
+type matchInfo struct {
+	path string
+	info os.FileInfo
+}
+
+// filterMatches removes the paths of files that are older than ignore_older, or that have
+// been closed as inactive and not modified since. The known readers of those files are kept,
+// so that they are read from their last offset if they are modified again. If there are more
+// matches than can be read at once, the most recently modified files are returned first.
+func (f *InputOperator) filterMatches(matches []string) []string {
+	if f.IgnoreOlder == 0 && f.CloseInactive == 0 && len(matches) <= f.MaxConcurrentFiles {
+		return matches
+	}
+
+	now := time.Now()
+	infos := make([]matchInfo, 0, len(matches))
+	skipped := make(map[string]struct{})
+	for _, path := range matches {
+		info, err := os.Stat(path)
+		if err != nil {
+			// Leave the error to be reported when the file is opened
+			infos = append(infos, matchInfo{path: path})
+			continue
+		}
+
+		if f.IgnoreOlder != 0 && now.Sub(info.ModTime()) > f.IgnoreOlder {
+			skipped[path] = struct{}{}
+			continue
+		}
+
+		if closedInfo, ok := f.inactiveFiles[path]; ok {
+			if isUnchanged(info, closedInfo) {
+				skipped[path] = struct{}{}
+				continue
+			}
+			f.Debugw("Reopening inactive file", "path", path)
+			delete(f.inactiveFiles, path)
+		}
+
+		infos = append(infos, matchInfo{path: path, info: info})
+	}
+
+	// Forget inactive files that no longer match
+	for path := range f.inactiveFiles {
+		if _, ok := skipped[path]; !ok {
+			delete(f.inactiveFiles, path)
+		}
+	}
+
+	f.keepReaders(skipped)
+
+	if len(infos) > f.MaxConcurrentFiles {
+		sort.SliceStable(infos, func(i, j int) bool {
+			return modTime(infos[i].info).After(modTime(infos[j].info))
+		})
+	}
+
+	filtered := make([]string, 0, len(infos))
+	for _, m := range infos {
+		filtered = append(filtered, m.path)
+	}
+	return filtered
+}
+
+// keepReaders moves the newest known reader of each of the paths to the end of the known
+// files, as though it had been read this poll, so that it is not forgotten
+func (f *InputOperator) keepReaders(paths map[string]struct{}) {
+	if len(paths) == 0 {
+		return
+	}
+
+	kept := make([]*Reader, 0, len(paths))
+	for i := len(f.knownFiles) - 1; i >= 0; i-- {
+		reader := f.knownFiles[i]
+		if _, ok := paths[reader.Path]; !ok {
+			continue
+		}
+		delete(paths, reader.Path)
+		f.knownFiles = append(f.knownFiles[:i], f.knownFiles[i+1:]...)
+		reader.generation = 0
+		kept = append(kept, reader)
+	}
+
+	for i := len(kept) - 1; i >= 0; i-- {
+		f.knownFiles = append(f.knownFiles, kept[i])
+	}
+}
+
+// closeInactive records the files that were read to the end this poll and have not been
+// modified for close_inactive. They are not opened again until they are modified. Files
+// waiting for an after read action are not closed until it is due.
+func (f *InputOperator) closeInactive(readers []*Reader) {
+	if f.CloseInactive == 0 {
+		return
+	}
+
+	delay := f.CloseInactive
+	if f.AfterRead != NoAfterRead && f.AfterReadDelay > delay {
+		delay = f.AfterReadDelay
+	}
+
+	for _, reader := range readers {
+		if reader.readInfo == nil || time.Since(reader.readInfo.ModTime()) < delay {
+			continue
+		}
+		if _, err := os.Stat(reader.Path); err != nil {
+			// The file was deleted or moved by an after read action
+			continue
+		}
+		f.inactiveFiles[reader.Path] = reader.readInfo
+		reader.Debugw("Closed inactive file")
+	}
+}
+
+// isUnchanged returns whether a file has the same identity, size and modification time
+// as when its info was taken
+func isUnchanged(info, old os.FileInfo) bool {
+	return os.SameFile(info, old) && info.Size() == old.Size() && info.ModTime().Equal(old.ModTime())
+}
+
+// modTime returns the modification time of a file, or the zero time if its info is unknown
+func modTime(info os.FileInfo) time.Time {
+	if info == nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,149 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/observiq/stanza/operator/helper"
+	"github.com/stretchr/testify/require"
+)
+
+func setModTime(t *testing.T, path string, age time.Duration) {
+	modTime := time.Now().Add(-age)
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestIgnoreOlder(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, func(cfg *InputConfig) {
+		cfg.IgnoreOlder = helper.Duration{Duration: time.Hour}
+	}, nil)
+
+	oldPath := filepath.Join(tempDir, "old.log")
+	writeString(t, openFile(t, oldPath), "old\n")
+	setModTime(t, oldPath, 2*time.Hour)
+	writeString(t, openFile(t, filepath.Join(tempDir, "new.log")), "new\n")
+
+	operator.poll(context.Background())
+	defer operator.Stop()
+
+	waitForMessage(t, logReceived, "new")
+	expectNoMessages(t, logReceived)
+}
+
+func TestIgnoreOlderKeepsOffset(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, func(cfg *InputConfig) {
+		cfg.IgnoreOlder = helper.Duration{Duration: time.Hour}
+	}, nil)
+
+	path := filepath.Join(tempDir, "test.log")
+	temp := openFile(t, path)
+	writeString(t, temp, "testlog1\n")
+	writeString(t, openFile(t, filepath.Join(tempDir, "active.log")), "active\n")
+
+	operator.poll(context.Background())
+	defer operator.Stop()
+	waitForMessages(t, logReceived, []string{"testlog1", "active"})
+
+	// The file is ignored for longer than known files are usually remembered
+	setModTime(t, path, 2*time.Hour)
+	for i := 0; i < 5; i++ {
+		operator.poll(context.Background())
+	}
+	expectNoMessages(t, logReceived)
+
+	// Once it is written again, it is read from where it was left
+	writeString(t, temp, "testlog2\n")
+	operator.poll(context.Background())
+	waitForMessage(t, logReceived, "testlog2")
+	expectNoMessages(t, logReceived)
+}
+
+func TestCloseInactive(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, func(cfg *InputConfig) {
+		cfg.CloseInactive = helper.Duration{Duration: time.Minute}
+	}, nil)
+
+	path := filepath.Join(tempDir, "test.log")
+	temp := openFile(t, path)
+	writeString(t, temp, "testlog1\n")
+	setModTime(t, path, time.Hour)
+	writeString(t, openFile(t, filepath.Join(tempDir, "active.log")), "active\n")
+
+	operator.poll(context.Background())
+	defer operator.Stop()
+	waitForMessages(t, logReceived, []string{"testlog1", "active"})
+	require.Contains(t, operator.inactiveFiles, path)
+
+	// The inactive file is not opened, and its reader is remembered for longer than
+	// known files usually are
+	for i := 0; i < 5; i++ {
+		operator.poll(context.Background())
+	}
+	expectNoMessages(t, logReceived)
+
+	// Once it is written again, it is reopened and read from where it was left
+	writeString(t, temp, "testlog2\n")
+	operator.poll(context.Background())
+	waitForMessage(t, logReceived, "testlog2")
+	require.NotContains(t, operator.inactiveFiles, path)
+}
+
+func TestCloseInactiveActiveFile(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, func(cfg *InputConfig) {
+		cfg.CloseInactive = helper.Duration{Duration: time.Minute}
+	}, nil)
+
+	path := filepath.Join(tempDir, "test.log")
+	writeString(t, openFile(t, path), "testlog\n")
+
+	operator.poll(context.Background())
+	defer operator.Stop()
+	waitForMessage(t, logReceived, "testlog")
+	require.NotContains(t, operator.inactiveFiles, path)
+}
+
+func TestCloseInactiveWaitsForAfterRead(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, func(cfg *InputConfig) {
+		cfg.CloseInactive = helper.Duration{Duration: time.Minute}
+		cfg.AfterRead = DeleteAfterRead
+		cfg.AfterReadDelay = helper.Duration{Duration: 2 * time.Hour}
+	}, nil)
+
+	path := filepath.Join(tempDir, "test.log")
+	writeString(t, openFile(t, path), "testlog\n")
+	setModTime(t, path, time.Hour)
+
+	operator.poll(context.Background())
+	defer operator.Stop()
+	waitForMessage(t, logReceived, "testlog")
+	require.NotContains(t, operator.inactiveFiles, path)
+}
+
+func TestPrioritizeRecentFiles(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, func(cfg *InputConfig) {
+		cfg.MaxConcurrentFiles = 1
+	}, nil)
+
+	for i, name := range []string{"a", "b", "c"} {
+		path := filepath.Join(tempDir, name+".log")
+		writeString(t, openFile(t, path), name+"\n")
+		setModTime(t, path, time.Duration(3-i)*time.Hour)
+	}
+
+	// The most recently modified files are read first
+	for _, expected := range []string{"c", "b", "a"} {
+		operator.poll(context.Background())
+		waitForMessage(t, logReceived, expected)
+		expectNoMessages(t, logReceived)
+	}
+	defer operator.Stop()
+}
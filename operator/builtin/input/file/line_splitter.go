@@ -5,66 +5,20 @@ import (
 	"bytes"
 	"regexp"
 
+	"github.com/observiq/stanza/operator/helper"
 	"golang.org/x/text/encoding"
 )
 
 // NewLineStartSplitFunc creates a bufio.SplitFunc that splits an incoming stream into
 // tokens that start with a match to the regex pattern provided
 func NewLineStartSplitFunc(re *regexp.Regexp) bufio.SplitFunc {
-	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
-		firstLoc := re.FindIndex(data)
-		if firstLoc == nil {
-			return 0, nil, nil // read more data and try again.
-		}
-		firstMatchStart := firstLoc[0]
-		firstMatchEnd := firstLoc[1]
-
-		if firstMatchStart != 0 {
-			// the beginning of the file does not match the start pattern, so return a token up to the first match so we don't lose data
-			advance = firstMatchStart
-			token = data[0:firstMatchStart]
-			return
-		}
-
-		if firstMatchEnd == len(data) {
-			// the first match goes to the end of the buffer, so don't look for a second match
-			return 0, nil, nil
-		}
-
-		secondLocOffset := firstMatchEnd + 1
-		secondLoc := re.FindIndex(data[secondLocOffset:])
-		if secondLoc == nil {
-			return 0, nil, nil // read more data and try again
-		}
-		secondMatchStart := secondLoc[0] + secondLocOffset
-
-		advance = secondMatchStart                     // start scanning at the beginning of the second match
-		token = data[firstMatchStart:secondMatchStart] // the token begins at the first match, and ends at the beginning of the second match
-		err = nil
-		return
-	}
+	return helper.NewLineStartSplitFunc(re)
 }
 
 // NewLineEndSplitFunc creates a bufio.SplitFunc that splits an incoming stream into
 // tokens that end with a match to the regex pattern provided
 func NewLineEndSplitFunc(re *regexp.Regexp) bufio.SplitFunc {
-	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
-		loc := re.FindIndex(data)
-		if loc == nil {
-			return 0, nil, nil // read more data and try again
-		}
-
-		// If the match goes up to the end of the current buffer, do another
-		// read until we can capture the entire match
-		if loc[1] == len(data)-1 && !atEOF {
-			return 0, nil, nil
-		}
-
-		advance = loc[1]
-		token = data[:loc[1]]
-		err = nil
-		return
-	}
+	return helper.NewLineEndSplitFunc(re)
 }
 
 // NewNewlineSplitFunc splits log lines by newline, just as bufio.ScanLines, but
@@ -85,7 +39,7 @@ func NewNewlineSplitFunc(encoding encoding.Encoding) (bufio.SplitFunc, error) {
 			return 0, nil, nil
 		}
 
-		if i := bytes.Index(data, newline); i >= 0 {
+		if i := indexAligned(data, newline); i >= 0 {
 			// We have a full newline-terminated line.
 			return i + len(newline), bytes.TrimSuffix(data[:i], carriageReturn), nil
 		}
@@ -95,6 +49,47 @@ func NewNewlineSplitFunc(encoding encoding.Encoding) (bufio.SplitFunc, error) {
 	}, nil
 }
 
+// NewFlushAtEOFSplitFunc wraps a bufio.SplitFunc so that any data left at EOF that the
+// wrapped function would wait for more data to complete is returned as a final token
+func NewFlushAtEOFSplitFunc(splitFunc bufio.SplitFunc) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		advance, token, err = splitFunc(data, atEOF)
+		if advance == 0 && token == nil && err == nil && atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return
+	}
+}
+
+// indexAligned returns the index of the first match of sep in data that starts on a
+// multiple of len(sep), or -1. This keeps a multi-byte newline, such as UTF-16's, from
+// matching the bytes of two neighbouring code units.
+func indexAligned(data, sep []byte) int {
+	if len(sep) <= 1 {
+		return bytes.Index(data, sep)
+	}
+	for i := 0; i+len(sep) <= len(data); i += len(sep) {
+		if bytes.Equal(data[i:i+len(sep)], sep) {
+			return i
+		}
+	}
+	return -1
+}
+
+// countAligned counts the matches of sep in data that start on a multiple of len(sep)
+func countAligned(data, sep []byte) int {
+	if len(sep) <= 1 {
+		return bytes.Count(data, sep)
+	}
+	n := 0
+	for i := 0; i+len(sep) <= len(data); i += len(sep) {
+		if bytes.Equal(data[i:i+len(sep)], sep) {
+			n++
+		}
+	}
+	return n
+}
+
 func encodedNewline(encoding encoding.Encoding) ([]byte, error) {
 	out := make([]byte, 10)
 	nDst, _, err := encoding.NewEncoder().Transform(out, []byte{'\n'}, true)
@@ -363,6 +363,18 @@ func TestNewlineSplitFunc_Encodings(t *testing.T) {
 				{0, 108, 0, 111, 0, 103, 0, 50}, // log2
 			},
 		},
+		{
+			"MisalignedNewlineUTF16",
+			unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM),
+			[]byte{1, 0, 10, 65, 0, 10}, // \u0100\u0a41\n
+			[][]byte{{1, 0, 10, 65}},
+		},
+		{
+			"MisalignedNewlineUTF16LE",
+			unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM),
+			[]byte{65, 10, 0, 1, 10, 0}, // \u0a41\u0100\n
+			[][]byte{{65, 10, 0, 1}},
+		},
 	}
 
 	for _, tc := range cases {
@@ -396,3 +408,61 @@ func generatedByteSliceOfLength(length int) []byte {
 	}
 	return newSlice
 }
+
+func TestFlushAtEOFSplitFunc(t *testing.T) {
+	lineStart := NewLineStartSplitFunc(regexp.MustCompile(`(?m)^LOGSTART \d+ `))
+	lineEnd := NewLineEndSplitFunc(regexp.MustCompile(`(?m)LOGEND \d+`))
+	newline, err := NewNewlineSplitFunc(unicode.UTF8)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		tokenizerTestCase
+		splitFunc bufio.SplitFunc
+	}{
+		{
+			tokenizerTestCase{
+				Name: "LineStart",
+				Raw:  []byte("LOGSTART 123 log1\nLOGSTART 234 log2\n  at stack\n"),
+				ExpectedTokenized: []string{
+					"LOGSTART 123 log1\n",
+					"LOGSTART 234 log2\n  at stack\n",
+				},
+			},
+			lineStart,
+		},
+		{
+			tokenizerTestCase{
+				Name: "LineEnd",
+				Raw:  []byte("log1 LOGEND 123log2 "),
+				ExpectedTokenized: []string{
+					"log1 LOGEND 123",
+					"log2 ",
+				},
+			},
+			lineEnd,
+		},
+		{
+			tokenizerTestCase{
+				Name: "Newline",
+				Raw:  []byte("log1\nlog2"),
+				ExpectedTokenized: []string{
+					"log1",
+					"log2",
+				},
+			},
+			newline,
+		},
+		{
+			tokenizerTestCase{
+				Name:              "Empty",
+				Raw:               []byte{},
+				ExpectedTokenized: []string{},
+			},
+			newline,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, tc.RunFunc(NewFlushAtEOFSplitFunc(tc.splitFunc)))
+	}
+}
@@ -0,0 +1,51 @@
+package file
+
+import (
+	"encoding/hex"
+	"hash/fnv"
+
+	"github.com/observiq/stanza/entry"
+	"go.uber.org/zap"
+)
+
+// readMetadata reads the metadata of the file that is added to each entry read from it
+func (f *Reader) readMetadata() {
+	f.inode, f.device = 0, 0
+	if info, err := f.file.Stat(); err != nil {
+		f.Debugw("Failed to stat file for its inode", zap.Error(err))
+	} else if inode, device, ok := fileID(f.file, info); ok {
+		f.inode, f.device = inode, device
+	}
+
+	f.fingerprintID = f.Fingerprint.ID()
+}
+
+// setMetadata sets the metadata of the file on an entry that starts at offset, on line
+// number line
+func (f *Reader) setMetadata(e *entry.Entry, offset, line int64) error {
+	values := []struct {
+		field entry.Field
+		value interface{}
+	}{
+		{f.fileInput.FileOffsetField, offset},
+		{f.fileInput.LineNumberField, line},
+		{f.fileInput.FileInodeField, f.inode},
+		{f.fileInput.FileDeviceField, f.device},
+		{f.fileInput.FingerprintIDField, f.fingerprintID},
+	}
+
+	for _, v := range values {
+		if err := e.Set(v.field, v.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ID returns a short identifier of the fingerprint. It changes while the first bytes
+// of the file are shorter than the fingerprint size.
+func (f Fingerprint) ID() string {
+	h := fnv.New64a()
+	_, _ = h.Write(f.FirstBytes)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,208 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/observiq/stanza/entry"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func includeMetadata(cfg *InputConfig) {
+	cfg.IncludeFileOffset = true
+	cfg.IncludeLineNumber = true
+	cfg.IncludeFileInode = true
+	cfg.IncludeFingerprintID = true
+}
+
+func TestFileMetadata(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, includeMetadata, nil)
+
+	path := filepath.Join(tempDir, "test.log")
+	temp := openFile(t, path)
+	writeString(t, temp, "testlog1\ntestlog2\n\ntestlog3\n")
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	inode, device, ok := fileID(temp, info)
+	require.True(t, ok)
+
+	operator.poll(context.Background())
+	defer operator.Stop()
+
+	expected := []struct {
+		record string
+		offset int64
+		line   int64
+	}{
+		{"testlog1", 0, 1},
+		{"testlog2", 9, 2},
+		{"testlog3", 19, 4},
+	}
+
+	var fingerprintID interface{}
+	for _, exp := range expected {
+		e := waitForOne(t, logReceived)
+		require.Equal(t, exp.record, e.Record)
+		require.Equal(t, exp.offset, e.Labels["file_offset"])
+		require.Equal(t, exp.line, e.Labels["line_number"])
+		require.Equal(t, inode, e.Labels["file_inode"])
+		require.Equal(t, device, e.Labels["file_device"])
+		require.NotEmpty(t, e.Labels["fingerprint_id"])
+		if fingerprintID != nil {
+			require.Equal(t, fingerprintID, e.Labels["fingerprint_id"])
+		}
+		fingerprintID = e.Labels["fingerprint_id"]
+	}
+}
+
+func TestFileMetadataNotIncluded(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, nil, nil)
+
+	writeString(t, openFile(t, filepath.Join(tempDir, "test.log")), "testlog\n")
+
+	operator.poll(context.Background())
+	defer operator.Stop()
+
+	e := waitForOne(t, logReceived)
+	for _, label := range []string{"file_offset", "line_number", "file_inode", "file_device", "fingerprint_id"} {
+		require.NotContains(t, e.Labels, label)
+	}
+}
+
+func TestFileMetadataFields(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, func(cfg *InputConfig) {
+		includeMetadata(cfg)
+		offsetField := entry.NewRecordField("offset")
+		lineField := entry.NewRecordField("line")
+		inodeField := entry.NewResourceField("file.inode")
+		deviceField := entry.NewResourceField("file.device")
+		fingerprintField := entry.NewLabelField("fp")
+		cfg.FileOffsetField = &offsetField
+		cfg.LineNumberField = &lineField
+		cfg.FileInodeField = &inodeField
+		cfg.FileDeviceField = &deviceField
+		cfg.FingerprintIDField = &fingerprintField
+		cfg.WriteTo = entry.NewRecordField("message")
+	}, nil)
+
+	path := filepath.Join(tempDir, "test.log")
+	temp := openFile(t, path)
+	writeString(t, temp, "testlog1\ntestlog2\n")
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	inode, device, ok := fileID(temp, info)
+	require.True(t, ok)
+
+	operator.poll(context.Background())
+	defer operator.Stop()
+
+	waitForOne(t, logReceived)
+	e := waitForOne(t, logReceived)
+	require.Equal(t, map[string]interface{}{
+		"message": "testlog2",
+		"offset":  int64(9),
+		"line":    int64(2),
+	}, e.Record)
+	require.Equal(t, inode, e.Resource["file.inode"])
+	require.Equal(t, device, e.Resource["file.device"])
+	require.NotEmpty(t, e.Labels["fp"])
+	for _, label := range []string{"file_offset", "line_number", "file_inode", "file_device", "fingerprint_id"} {
+		require.NotContains(t, e.Labels, label)
+	}
+}
+
+func TestLineNumberUTF16(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, func(cfg *InputConfig) {
+		cfg.Encoding = "utf-16le"
+		cfg.IncludeLineNumber = true
+	}, nil)
+
+	// In UTF-16LE, "\u0a41\u0100" is 41 0a 00 01, which contains the bytes of a
+	// newline across two code units
+	contents := encodeString(t, unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM), "\u0a41\u0100 log1\nlog2\n")
+	writeString(t, openFile(t, filepath.Join(tempDir, "test.log")), contents)
+
+	operator.poll(context.Background())
+	defer operator.Stop()
+
+	e := waitForOne(t, logReceived)
+	require.Equal(t, "\u0a41\u0100 log1", e.Record)
+	require.Equal(t, int64(1), e.Labels["line_number"])
+	e = waitForOne(t, logReceived)
+	require.Equal(t, "log2", e.Record)
+	require.Equal(t, int64(2), e.Labels["line_number"])
+}
+
+func TestLineNumberAfterRestart(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, includeMetadata, nil)
+
+	temp := openFile(t, filepath.Join(tempDir, "test.log"))
+	writeString(t, temp, "testlog1\ntestlog2\n")
+
+	require.NoError(t, operator.Start())
+	defer operator.Stop()
+	waitForMessages(t, logReceived, []string{"testlog1", "testlog2"})
+
+	// The line number is persisted with the offset
+	require.NoError(t, operator.Stop())
+	require.NoError(t, operator.Start())
+
+	writeString(t, temp, "testlog3\n")
+	e := waitForOne(t, logReceived)
+	require.Equal(t, "testlog3", e.Record)
+	require.Equal(t, int64(3), e.Labels["line_number"])
+	require.Equal(t, int64(18), e.Labels["file_offset"])
+}
+
+func TestLineNumberStartAtEnd(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, func(cfg *InputConfig) {
+		includeMetadata(cfg)
+		cfg.StartAt = "end"
+	}, nil)
+
+	temp := openFile(t, filepath.Join(tempDir, "test.log"))
+	writeString(t, temp, "testlog1\ntestlog2\n")
+
+	operator.poll(context.Background())
+	defer operator.Stop()
+	expectNoMessages(t, logReceived)
+
+	// The lines before the starting offset are counted
+	writeString(t, temp, "testlog3\n")
+	operator.poll(context.Background())
+
+	e := waitForOne(t, logReceived)
+	require.Equal(t, "testlog3", e.Record)
+	require.Equal(t, int64(3), e.Labels["line_number"])
+}
+
+func TestLineNumberMultiline(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, func(cfg *InputConfig) {
+		includeMetadata(cfg)
+		cfg.Multiline = &MultilineConfig{LineStartPattern: "^START"}
+	}, nil)
+
+	temp := openFile(t, filepath.Join(tempDir, "test.log"))
+	writeString(t, temp, "START 1\ncontinued\nSTART 2\nSTART 3\n")
+
+	operator.poll(context.Background())
+	defer operator.Stop()
+
+	// An entry's line number is the line it starts on
+	e := waitForOne(t, logReceived)
+	require.Equal(t, int64(1), e.Labels["line_number"])
+	e = waitForOne(t, logReceived)
+	require.Equal(t, int64(3), e.Labels["line_number"])
+}
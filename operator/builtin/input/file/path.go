@@ -0,0 +1,70 @@
+package file
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v2"
+	"github.com/observiq/stanza/entry"
+)
+
+// pathField is a named capture group of the path regex, and the field its value is written to
+type pathField struct {
+	group int
+	field entry.Field
+}
+
+// pathValues returns the values captured from a path by the path regex, or nil if the
+// path regex is not configured or does not match the path
+func (f *InputOperator) pathValues(path string) []string {
+	if f.PathRegex == nil {
+		return nil
+	}
+	return f.PathRegex.FindStringSubmatch(path)
+}
+
+// setPathFields sets the fields captured from the path of a file on an entry. Groups that
+// did not capture anything are skipped.
+func (f *InputOperator) setPathFields(e *entry.Entry, values []string) error {
+	if values == nil {
+		return nil
+	}
+
+	for _, pf := range f.pathFields {
+		if values[pf.group] == "" {
+			continue
+		}
+		if err := e.Set(pf.field, values[pf.group]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// watchPatterns returns the patterns of the directories that may contain files matching an
+// include pattern. A pattern with a ** may match files in any directory below the part of
+// the pattern before the **, so all of those directories are returned.
+func watchPatterns(include string) []string {
+	dir := filepath.Dir(include)
+	parts := strings.Split(dir, string(filepath.Separator))
+	for i, part := range parts {
+		if part == "**" {
+			base := strings.Join(parts[:i], string(filepath.Separator))
+			if base == "" {
+				base = string(filepath.Separator)
+			}
+			return []string{base, filepath.Join(base, "**")}
+		}
+	}
+	return []string{dir}
+}
+
+// matchesAny returns whether a path matches any of the patterns
+func matchesAny(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if matches, _ := doublestar.PathMatch(pattern, path); matches {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/observiq/stanza/entry"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecursiveInclude(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, func(cfg *InputConfig) {
+		dir := includeDir(cfg)
+		cfg.Include = []string{filepath.Join(dir, "**", "*.log")}
+		cfg.Exclude = []string{filepath.Join(dir, "**", "excluded", "*")}
+	}, nil)
+
+	for _, dir := range []string{"a/b/c", "a/excluded"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(tempDir, dir), 0755))
+	}
+	writeString(t, openFile(t, filepath.Join(tempDir, "top.log")), "top\n")
+	writeString(t, openFile(t, filepath.Join(tempDir, "a", "b", "c", "nested.log")), "nested\n")
+	writeString(t, openFile(t, filepath.Join(tempDir, "a", "b", "other.txt")), "other\n")
+	writeString(t, openFile(t, filepath.Join(tempDir, "a", "excluded", "excluded.log")), "excluded\n")
+
+	operator.poll(context.Background())
+	defer operator.Stop()
+
+	waitForMessages(t, logReceived, []string{"top", "nested"})
+	expectNoMessages(t, logReceived)
+}
+
+func TestPathFields(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, func(cfg *InputConfig) {
+		cfg.Include = []string{filepath.Join(includeDir(cfg), "**", "*.log")}
+		cfg.PathRegex = `/(?P<namespace>[^_/]+)_(?P<pod>[^_/]+)_(?P<uid>[^/]+)/(?P<container>[^/]+)/\d+\.log$`
+		cfg.PathFields = map[string]entry.Field{
+			"namespace": entry.NewResourceField("k8s.namespace.name"),
+			"pod":       entry.NewResourceField("k8s.pod.name"),
+		}
+	}, nil)
+
+	dir := filepath.Join(tempDir, "default_web-5d8f_1234", "nginx")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	writeString(t, openFile(t, filepath.Join(dir, "0.log")), "testlog\n")
+
+	operator.poll(context.Background())
+	defer operator.Stop()
+
+	e := waitForOne(t, logReceived)
+	require.Equal(t, map[string]interface{}{
+		"k8s.namespace.name": "default",
+		"k8s.pod.name":       "web-5d8f",
+	}, e.Resource)
+	require.Equal(t, "1234", e.Labels["uid"])
+	require.Equal(t, "nginx", e.Labels["container"])
+}
+
+func TestPathRegexNoMatch(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, func(cfg *InputConfig) {
+		cfg.PathRegex = `/(?P<app>[^/]+)\.app\.log$`
+	}, nil)
+
+	writeString(t, openFile(t, filepath.Join(tempDir, "test.log")), "testlog\n")
+
+	operator.poll(context.Background())
+	defer operator.Stop()
+
+	// Files that do not match the path regex are still read
+	e := waitForOne(t, logReceived)
+	require.Equal(t, "testlog", e.Record)
+	require.NotContains(t, e.Labels, "app")
+}
+
+func TestWatchPatterns(t *testing.T) {
+	cases := []struct {
+		include  string
+		expected []string
+	}{
+		{"/var/log/*.log", []string{"/var/log"}},
+		{"/var/log/*/*.log", []string{"/var/log/*"}},
+		{"/var/log/**/*.log", []string{"/var/log", "/var/log/**"}},
+		{"/var/log/**/app/*.log", []string{"/var/log", "/var/log/**"}},
+		{"/**/*.log", []string{"/", "/**"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.include, func(t *testing.T) {
+			require.Equal(t, tc.expected, watchPatterns(tc.include))
+		})
+	}
+}
+
+func TestUpdateWatchesRecursive(t *testing.T) {
+	operator, _, tempDir := newTestFileOperator(t, func(cfg *InputConfig) {
+		cfg.Include = []string{filepath.Join(includeDir(cfg), "**", "*.log")}
+	}, nil)
+
+	watcher, err := fsnotify.NewWatcher()
+	require.NoError(t, err)
+	defer watcher.Close()
+
+	nested := filepath.Join(tempDir, "a", "b")
+	require.NoError(t, os.MkdirAll(nested, 0755))
+
+	watched := map[string]struct{}{}
+	operator.updateWatches(watcher, watched)
+	require.Equal(t, map[string]struct{}{
+		tempDir:                     {},
+		filepath.Join(tempDir, "a"): {},
+		nested:                      {},
+	}, watched)
+}
+
+func TestIsMatchRecursive(t *testing.T) {
+	operator, _, _ := newTestFileOperator(t, func(cfg *InputConfig) {
+		cfg.Include = []string{"/var/log/**/*.log"}
+		cfg.Exclude = []string{"/var/log/**/debug/*"}
+	}, nil)
+
+	require.True(t, operator.isMatch("/var/log/test.log"))
+	require.True(t, operator.isMatch("/var/log/app/nested/test.log"))
+	require.False(t, operator.isMatch("/var/log/app/test.txt"))
+	require.False(t, operator.isMatch("/var/log/app/debug/test.log"))
+}
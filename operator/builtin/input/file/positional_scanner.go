@@ -7,7 +7,9 @@ import (
 
 // PositionalScanner is a scanner that maintains position
 type PositionalScanner struct {
-	pos int64
+	pos     int64
+	lines   int64
+	newline []byte
 	*bufio.Scanner
 }
 
@@ -24,6 +26,9 @@ func NewPositionalScanner(r io.Reader, maxLogSize int, startOffset int64, splitF
 	scanFunc := func(data []byte, atEOF bool) (advance int, token []byte, err error) {
 		advance, token, err = splitFunc(data, atEOF)
 		ps.pos += int64(advance)
+		if ps.newline != nil {
+			ps.lines += int64(countAligned(data[:advance], ps.newline))
+		}
 		return
 	}
 	ps.Scanner.Split(scanFunc)
@@ -34,3 +39,15 @@ func NewPositionalScanner(r io.Reader, maxLogSize int, startOffset int64, splitF
 func (ps *PositionalScanner) Pos() int64 {
 	return ps.pos
 }
+
+// CountLines makes the scanner count the newlines it advances past, starting from
+// the number of lines before its start offset
+func (ps *PositionalScanner) CountLines(newline []byte, startLines int64) {
+	ps.newline = newline
+	ps.lines = startLines
+}
+
+// Lines returns the number of lines the scanner has advanced past
+func (ps *PositionalScanner) Lines() int64 {
+	return ps.lines
+}
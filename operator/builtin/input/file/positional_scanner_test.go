@@ -0,0 +1,28 @@
+package file
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPositionalScannerCountLinesUTF16(t *testing.T) {
+	// \u0100\u0a41\n\u0100\n in UTF-16BE, which has the bytes of a newline at offset 1
+	data := []byte{1, 0, 10, 65, 0, 10, 1, 0, 0, 10}
+	newline := []byte{0, 10}
+
+	// Split the whole input into one token, as a multiline pattern could
+	splitFunc := func(data []byte, atEOF bool) (int, []byte, error) {
+		if !atEOF {
+			return 0, nil, nil
+		}
+		return len(data), data, nil
+	}
+
+	scanner := NewPositionalScanner(bytes.NewReader(data), 1024, 0, splitFunc)
+	scanner.CountLines(newline, 5)
+	require.True(t, scanner.Scan())
+	require.Equal(t, int64(len(data)), scanner.Pos())
+	require.Equal(t, int64(7), scanner.Lines())
+}
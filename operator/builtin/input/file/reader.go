@@ -7,25 +7,59 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"time"
 
+	"github.com/observiq/stanza/entry"
 	"github.com/observiq/stanza/errors"
 	"go.uber.org/zap"
 	"golang.org/x/text/encoding"
 	"golang.org/x/text/transform"
 )
 
+// emitBatchSize is the maximum number of entries a reader sends to the next operator at once
+const emitBatchSize = 100
+
 // Reader manages a single file
 type Reader struct {
 	Fingerprint *Fingerprint
 	Offset      int64
 	Path        string
 
+	// Header is the last header read from the file
+	Header string
+
+	// LineNumber is the number of lines before the offset
+	LineNumber int64
+
+	// Encoding is the encoding detected from the file's byte order mark, when the
+	// encoding is auto
+	Encoding string
+
 	generation int
 	fileInput  *InputOperator
 	file       *os.File
 
-	decoder      *encoding.Decoder
-	decodeBuffer []byte
+	// compressedSize is the size of a compressed file when it was last read to the end.
+	// Compressed files are only read again once their size changes.
+	compressedSize int64
+
+	// readInfo is the info of the file when it was read to the end during this poll, or
+	// nil if it was not
+	readInfo os.FileInfo
+
+	// pathValues are the values captured from the path by the path regex
+	pathValues []string
+
+	// inode, device and fingerprintID identify the file while it is read
+	inode         uint64
+	device        uint64
+	fingerprintID string
+
+	decoder          *encoding.Decoder
+	decodeBuffer     []byte
+	splitFunc        bufio.SplitFunc
+	newline          []byte
+	encodingResolved bool
 
 	*zap.SugaredLogger `json:"-"`
 }
@@ -40,6 +74,8 @@ func (f *InputOperator) NewReader(path string, file *os.File, fp *Fingerprint) (
 		SugaredLogger: f.SugaredLogger.With("path", path),
 		decoder:       f.encoding.NewDecoder(),
 		decodeBuffer:  make([]byte, 1<<12),
+		splitFunc:     f.SplitFunc,
+		newline:       f.newline,
 	}
 	return r, nil
 }
@@ -51,12 +87,32 @@ func (f *Reader) Copy(file *os.File) (*Reader, error) {
 		return nil, err
 	}
 	reader.Offset = f.Offset
+	reader.compressedSize = f.compressedSize
+	reader.Header = f.Header
+	reader.LineNumber = f.LineNumber
+	reader.Encoding = f.Encoding
 	return reader, nil
 }
 
 // InitializeOffset sets the starting offset
 func (f *Reader) InitializeOffset(startAtBeginning bool) error {
-	if !startAtBeginning {
+	if startAtBeginning {
+		return nil
+	}
+
+	c, err := f.fileInput.detectCompression(f.file)
+	if err != nil {
+		return err
+	}
+
+	if c != uncompressed {
+		// Offsets are tracked against the decompressed contents
+		size, err := decompressedSize(f.file, c)
+		if err != nil && !isIncomplete(err) {
+			return fmt.Errorf("decompress: %s", err)
+		}
+		f.Offset = size
+	} else {
 		info, err := f.file.Stat()
 		if err != nil {
 			return fmt.Errorf("stat: %s", err)
@@ -64,6 +120,51 @@ func (f *Reader) InitializeOffset(startAtBeginning bool) error {
 		f.Offset = info.Size()
 	}
 
+	if err := f.readBeforeOffset(c); err != nil {
+		return fmt.Errorf("read before offset: %s", err)
+	}
+	return nil
+}
+
+// readBeforeOffset reads the header lines and counts the lines of a file up to its offset.
+// It is used when a file is first read from somewhere other than its start.
+func (f *Reader) readBeforeOffset(c compression) error {
+	fi := f.fileInput
+	f.resolveEncoding()
+	if (fi.header == nil && f.newline == nil) || f.Offset == 0 {
+		return nil
+	}
+
+	var r io.Reader = io.NewSectionReader(f.file, 0, f.Offset)
+	if c != uncompressed {
+		dr, err := newDecompressor(f.file, c, 0)
+		if err != nil {
+			return fmt.Errorf("decompress: %s", err)
+		}
+		r = io.LimitReader(dr, f.Offset)
+	}
+
+	scanner := NewPositionalScanner(r, fi.MaxLogSize, 0, f.splitFunc)
+	if f.newline != nil {
+		scanner.CountLines(f.newline, 0)
+	}
+
+	for start := int64(0); scanner.Scan(); start = scanner.Pos() {
+		if fi.header == nil || len(scanner.Bytes()) == 0 {
+			continue
+		}
+		msg, err := f.decode(scanner.Bytes())
+		if err != nil {
+			f.Debugw("Failed to decode line while reading headers", zap.Error(err))
+			continue
+		}
+		f.Header, _ = f.readHeader(f.trimByteOrderMark(msg, start), start == 0, f.Header)
+	}
+
+	if err := scanner.Err(); err != nil && !isIncomplete(err) {
+		return err
+	}
+	f.LineNumber = scanner.Lines()
 	return nil
 }
 
@@ -71,15 +172,91 @@ func (f *Reader) InitializeOffset(startAtBeginning bool) error {
 func (f *Reader) ReadToEnd(ctx context.Context) {
 	defer f.file.Close()
 
-	if _, err := f.file.Seek(f.Offset, 0); err != nil {
-		f.Errorw("Failed to seek", zap.Error(err))
+	c, err := f.fileInput.detectCompression(f.file)
+	if err != nil {
+		f.Errorw("Failed to detect compression", zap.Error(err))
 		return
 	}
 
-	fr := NewFingerprintUpdatingReader(f.file, f.Offset, f.Fingerprint, f.fileInput.fingerprintSize)
-	scanner := NewPositionalScanner(fr, f.fileInput.MaxLogSize, f.Offset, f.fileInput.SplitFunc)
+	var r io.Reader = f.file
+	var compressedSize int64
+	if c == uncompressed {
+		if err := f.checkTruncation(); err != nil {
+			f.Errorw("Failed to stat", zap.Error(err))
+			return
+		}
+		if _, err := f.file.Seek(f.Offset, 0); err != nil {
+			f.Errorw("Failed to seek", zap.Error(err))
+			return
+		}
+	} else {
+		info, err := f.file.Stat()
+		if err != nil {
+			f.Errorw("Failed to stat", zap.Error(err))
+			return
+		}
+		if info.Size() == f.compressedSize {
+			f.readInfo = info
+			return
+		}
+		compressedSize = info.Size()
+
+		if r, err = newDecompressor(f.file, c, f.Offset); err != nil {
+			if isIncomplete(err) {
+				f.Debugw("Compressed file is incomplete. Retrying on the next poll", zap.Error(err))
+			} else {
+				f.Errorw("Failed to decompress", zap.Error(err))
+			}
+			return
+		}
+	}
+
+	f.pathValues = f.fileInput.pathValues(f.Path)
+	f.readMetadata()
+
+	fr := NewFingerprintUpdatingReader(r, f.Offset, f.Fingerprint, f.fileInput.fingerprintSize)
+	f.resolveEncoding()
+	splitFunc := f.splitFunc
+	if f.shouldForceFlush() {
+		splitFunc = NewFlushAtEOFSplitFunc(splitFunc)
+	}
+	scanner := NewPositionalScanner(fr, f.fileInput.MaxLogSize, f.Offset, splitFunc)
+	if f.newline != nil {
+		scanner.CountLines(f.newline, f.LineNumber)
+	}
+
+	// Iterate over the tokenized file, emitting entries in batches as we go. The
+	// offset, line number and header are only advanced past entries once they have
+	// been emitted. Once the context is cancelled, nothing more is emitted and they
+	// are left where they are, so that entries that may not have been accepted are
+	// read again with the header they were read with.
+	batch := make([]*entry.Entry, 0, emitBatchSize)
+	offset, line, header := f.Offset, f.LineNumber, f.Header
+	emit := func() bool {
+		if ctx.Err() != nil {
+			return false
+		}
+		f.fileInput.WriteBatch(ctx, batch)
+		batch = make([]*entry.Entry, 0, emitBatchSize)
+		if ctx.Err() != nil {
+			return false
+		}
+		f.Offset, f.LineNumber, f.Header = offset, line, header
+		return true
+	}
+
+	// The file only counts as read to the end once the last entries have been emitted.
+	// Until then, a compressed file is read again even if its size has not changed.
+	readToEnd := false
+	defer func() {
+		if emit() && readToEnd {
+			if c != uncompressed {
+				f.compressedSize = compressedSize
+			}
+			f.markReadToEnd(c)
+		}
+	}()
 
-	// Iterate over the tokenized file, emitting entries as we go
 	for {
 		select {
 		case <-ctx.Done():
@@ -89,45 +266,126 @@ func (f *Reader) ReadToEnd(ctx context.Context) {
 
 		ok := scanner.Scan()
 		if !ok {
-			if err := getScannerError(scanner); err != nil {
-				f.Errorw("Failed during scan", zap.Error(err))
+			readToEnd = scanner.Err() == nil
+			switch {
+			case c != uncompressed && isIncomplete(scanner.Err()):
+				f.Debugw("Compressed file is incomplete. Retrying on the next poll", zap.Error(scanner.Err()))
+			default:
+				if err := getScannerError(scanner); err != nil {
+					f.Errorw("Failed during scan", zap.Error(err))
+				}
 			}
 			break
 		}
 
-		if err := f.emit(ctx, scanner.Bytes()); err != nil {
+		var e *entry.Entry
+		e, header, err = f.newEntry(scanner.Bytes(), offset, line+1, header)
+		if err != nil {
 			f.Error("Failed to emit entry", zap.Error(err))
+		} else if e != nil {
+			batch = append(batch, e)
+		}
+		offset, line = scanner.Pos(), scanner.Lines()
+
+		if len(batch) == emitBatchSize {
+			emit()
 		}
-		f.Offset = scanner.Pos()
 	}
 }
 
-// Emit creates an entry with the decoded message and sends it to the next
-// operator in the pipeline
-func (f *Reader) emit(ctx context.Context, msgBuf []byte) error {
+// checkTruncation resets the reader to the start of the file if the file is shorter than
+// the offset, which happens when it is truncated by copy-truncate rotation
+func (f *Reader) checkTruncation() error {
+	info, err := f.file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() >= f.Offset {
+		return nil
+	}
+
+	f.Infow("File was truncated. Reading from the beginning", "offset", f.Offset, "size", info.Size())
+	f.Offset = 0
+	f.LineNumber = 0
+	f.Header = ""
+	f.Fingerprint.FirstBytes = f.Fingerprint.FirstBytes[:0]
+	return nil
+}
+
+// shouldForceFlush returns whether the data at the end of the file that is not a complete
+// entry yet should be read as an entry, because the file has not been modified for the
+// force flush period
+func (f *Reader) shouldForceFlush() bool {
+	if f.fileInput.ForceFlushPeriod == 0 {
+		return false
+	}
+
+	info, err := f.file.Stat()
+	if err != nil {
+		return false
+	}
+	return time.Since(info.ModTime()) >= f.fileInput.ForceFlushPeriod
+}
+
+// markReadToEnd records the info of a file that has been read to the end. An uncompressed
+// file is only read to the end if no partial entry is left after the offset.
+func (f *Reader) markReadToEnd(c compression) {
+	info, err := f.file.Stat()
+	if err != nil {
+		f.Errorw("Failed to stat", zap.Error(err))
+		return
+	}
+	if c == uncompressed && info.Size() != f.Offset {
+		return
+	}
+	f.readInfo = info
+}
+
+// newEntry creates an entry with the decoded message, which starts at offset on line
+// number line, and is read after the header. It returns the header after the message,
+// and a nil entry if the message is empty or is a header line.
+func (f *Reader) newEntry(msgBuf []byte, offset, line int64, header string) (*entry.Entry, string, error) {
 	// Skip the entry if it's empty
 	if len(msgBuf) == 0 {
-		return nil
+		return nil, header, nil
 	}
 
 	msg, err := f.decode(msgBuf)
 	if err != nil {
-		return fmt.Errorf("decode: %s", err)
+		return nil, header, fmt.Errorf("decode: %s", err)
+	}
+	msg = f.trimByteOrderMark(msg, offset)
+
+	if f.fileInput.header != nil {
+		var isHeader bool
+		if header, isHeader = f.readHeader(msg, offset == 0, header); isHeader {
+			return nil, header, nil
+		}
 	}
 
 	e, err := f.fileInput.NewEntry(msg)
 	if err != nil {
-		return fmt.Errorf("create entry: %s", err)
+		return nil, header, fmt.Errorf("create entry: %s", err)
 	}
 
 	if err := e.Set(f.fileInput.FilePathField, f.Path); err != nil {
-		return err
+		return nil, header, err
 	}
 	if err := e.Set(f.fileInput.FileNameField, filepath.Base(f.Path)); err != nil {
-		return err
+		return nil, header, err
 	}
-	f.fileInput.Write(ctx, e)
-	return nil
+	if err := f.fileInput.setPathFields(e, f.pathValues); err != nil {
+		return nil, header, err
+	}
+	if err := f.setMetadata(e, offset, line); err != nil {
+		return nil, header, err
+	}
+	if header != "" {
+		if err := e.Set(f.fileInput.header.field, header); err != nil {
+			return nil, header, err
+		}
+	}
+	return e, header, nil
 }
 
 // decode converts the bytes in msgBuf to utf-8 from the configured encoding
@@ -0,0 +1,141 @@
+package file
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/observiq/stanza/operator/helper"
+	"github.com/stretchr/testify/require"
+)
+
+// copyTruncate rotates a file the way logrotate's copytruncate mode does
+func copyTruncate(t *testing.T, path, dest string) {
+	contents, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(dest, contents, 0600))
+	require.NoError(t, os.Truncate(path, 0))
+}
+
+func TestCopyTruncateRotation(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, nil, nil)
+
+	path := filepath.Join(tempDir, "test.log")
+	temp := openFile(t, path)
+	writeString(t, temp, "testlog1\ntestlog2\n")
+
+	operator.poll(context.Background())
+	defer operator.Stop()
+	waitForMessages(t, logReceived, []string{"testlog1", "testlog2"})
+
+	// The copy has already been read, and the truncated file is read from its start
+	copyTruncate(t, path, filepath.Join(tempDir, "test.log.1"))
+	temp = openFile(t, path)
+	writeString(t, temp, "testlog3\n")
+
+	operator.poll(context.Background())
+	waitForMessage(t, logReceived, "testlog3")
+	expectNoMessages(t, logReceived)
+}
+
+func TestTruncateRefillWithSamePrefix(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, func(cfg *InputConfig) {
+		cfg.FingerprintSize = helper.ByteSize(minFingerprintSize)
+		cfg.IncludeLineNumber = true
+	}, nil)
+
+	path := filepath.Join(tempDir, "test.log")
+	temp := openFile(t, path)
+	writeString(t, temp, "0123456789abcdef\ntestlog1\n")
+
+	operator.poll(context.Background())
+	defer operator.Stop()
+	waitForMessages(t, logReceived, []string{"0123456789abcdef", "testlog1"})
+
+	// The file is truncated and refilled with contents that start with its fingerprint
+	require.NoError(t, temp.Truncate(0))
+	_, err := temp.WriteAt([]byte("0123456789abcdef\n"), 0)
+	require.NoError(t, err)
+
+	operator.poll(context.Background())
+	e := waitForOne(t, logReceived)
+	require.Equal(t, "0123456789abcdef", e.Record)
+	require.Equal(t, int64(1), e.Labels["line_number"])
+	expectNoMessages(t, logReceived)
+}
+
+func TestCreateRotation(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, nil, nil)
+
+	path := filepath.Join(tempDir, "test.log")
+	temp := openFile(t, path)
+	writeString(t, temp, "testlog1\n")
+
+	operator.poll(context.Background())
+	defer operator.Stop()
+	waitForMessage(t, logReceived, "testlog1")
+
+	// A line is written before the file is moved, and a new file is created in its place,
+	// the way logrotate's create mode does
+	writeString(t, temp, "testlog2\n")
+	require.NoError(t, temp.Close())
+	require.NoError(t, os.Rename(path, filepath.Join(tempDir, "test.log.1")))
+	writeString(t, openFile(t, path), "testlog3\n")
+
+	operator.poll(context.Background())
+	waitForMessages(t, logReceived, []string{"testlog2", "testlog3"})
+	expectNoMessages(t, logReceived)
+}
+
+func TestSymlinkDeduplication(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, func(cfg *InputConfig) {
+		dir := includeDir(cfg)
+		cfg.Include = []string{
+			filepath.Join(dir, "containers", "*.log"),
+			filepath.Join(dir, "pods", "*", "*.log"),
+		}
+		cfg.IncludeFilePath = true
+	}, nil)
+
+	podDir := filepath.Join(tempDir, "pods", "default_web")
+	require.NoError(t, os.MkdirAll(podDir, 0755))
+	require.NoError(t, os.Mkdir(filepath.Join(tempDir, "containers"), 0755))
+
+	realPath := filepath.Join(podDir, "0.log")
+	writeString(t, openFile(t, realPath), "testlog\n")
+	linkPath := filepath.Join(tempDir, "containers", "web.log")
+	require.NoError(t, os.Symlink(realPath, linkPath))
+
+	operator.poll(context.Background())
+	defer operator.Stop()
+
+	// The file is read once, through the path that was matched first
+	e := waitForOne(t, logReceived)
+	require.Equal(t, linkPath, e.Labels["file_path"])
+	expectNoMessages(t, logReceived)
+}
+
+func TestDistinctFilesWithSameContents(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, nil, nil)
+
+	writeString(t, openFile(t, filepath.Join(tempDir, "a.log")), "testlog\n")
+	writeString(t, openFile(t, filepath.Join(tempDir, "b.log")), "testlog\n")
+
+	files := []*os.File{
+		openFile(t, filepath.Join(tempDir, "a.log")),
+		openFile(t, filepath.Join(tempDir, "b.log")),
+		openFile(t, filepath.Join(tempDir, "a.log")),
+	}
+
+	// Only the same file opened twice is removed
+	deduped := operator.dedupeFiles(files)
+	require.Equal(t, files[:2], deduped)
+	expectNoMessages(t, logReceived)
+}
@@ -0,0 +1,133 @@
+package file
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/bmatcuk/doublestar/v2"
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+// These are the modes a file input can use to find changes to files
+const (
+	// PollWatchMode checks the files for changes every poll interval
+	PollWatchMode = "poll"
+
+	// FsnotifyWatchMode checks the files for changes when the filesystem reports an event
+	// for a matching path, and every fallback poll interval in case an event is missed
+	FsnotifyWatchMode = "fsnotify"
+)
+
+const defaultFallbackPollInterval = 10 * time.Second
+
+// startWatcher kicks off a goroutine that polls the files whenever the filesystem reports
+// a change to a matching path. Polls are at most one poll interval apart, and happen at
+// least every fallback poll interval.
+func (f *InputOperator) startWatcher(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	watched := make(map[string]struct{})
+	f.updateWatches(watcher, watched)
+
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		defer watcher.Close()
+
+		fallbackTicker := time.NewTicker(f.FallbackPollInterval)
+		defer fallbackTicker.Stop()
+
+		// Start with a poll after one poll interval, like the poller does
+		pending := false
+		pollTimer := time.NewTimer(f.PollInterval)
+		defer pollTimer.Stop()
+		timerRunning := true
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op&fsnotify.Create != 0 {
+					f.updateWatches(watcher, watched)
+				}
+				if f.isMatch(event.Name) {
+					pending = true
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				// Events may have been missed, so check everything
+				f.Warnw("File watcher reported an error", zap.Error(err))
+				pending = true
+			case <-fallbackTicker.C:
+				pending = true
+			case <-pollTimer.C:
+				timerRunning = false
+				f.poll(ctx)
+				f.updateWatches(watcher, watched)
+
+				// Keep polling until the queued matches have been read
+				if len(f.queuedMatches) > 0 {
+					pending = true
+				}
+			}
+
+			if pending && !timerRunning {
+				pollTimer.Reset(f.PollInterval)
+				timerRunning = true
+				pending = false
+			}
+		}
+	}()
+
+	return nil
+}
+
+// updateWatches watches the directories that may contain matching files, and stops
+// watching directories that no longer do
+func (f *InputOperator) updateWatches(watcher *fsnotify.Watcher, watched map[string]struct{}) {
+	dirs := make(map[string]struct{})
+	for _, include := range f.Include {
+		for _, pattern := range watchPatterns(include) {
+			matches, _ := doublestar.Glob(pattern) // compile error checked in build
+			for _, match := range matches {
+				if info, err := os.Stat(match); err == nil && info.IsDir() {
+					dirs[match] = struct{}{}
+				}
+			}
+		}
+	}
+
+	for dir := range dirs {
+		if _, ok := watched[dir]; ok {
+			continue
+		}
+		if err := watcher.Add(dir); err != nil {
+			f.Warnw("Failed to watch directory. Changes will be found by the fallback poll", "path", dir, zap.Error(err))
+			continue
+		}
+		watched[dir] = struct{}{}
+	}
+
+	for dir := range watched {
+		if _, ok := dirs[dir]; !ok {
+			_ = watcher.Remove(dir) // fails if the directory was deleted, which also removes the watch
+			delete(watched, dir)
+		}
+	}
+}
+
+// isMatch returns whether a path matches the include patterns and not the exclude patterns
+func (f *InputOperator) isMatch(path string) bool {
+	return !matchesAny(f.Exclude, path) && matchesAny(f.Include, path)
+}
@@ -0,0 +1,181 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/observiq/stanza/operator/helper"
+	"github.com/observiq/stanza/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func fsnotifyConfig(cfg *InputConfig) {
+	cfg.WatchMode = FsnotifyWatchMode
+	// The fallback poll is too slow for these tests, so entries are only found through events
+	cfg.FallbackPollInterval = helper.Duration{Duration: time.Hour}
+}
+
+func TestWatchModeBuild(t *testing.T) {
+	cases := []struct {
+		name      string
+		modify    func(*InputConfig)
+		expectErr bool
+		mode      string
+		fallback  time.Duration
+	}{
+		{"Default", func(cfg *InputConfig) {}, false, PollWatchMode, defaultFallbackPollInterval},
+		{"Empty", func(cfg *InputConfig) { cfg.WatchMode = "" }, false, PollWatchMode, defaultFallbackPollInterval},
+		{"Fsnotify", fsnotifyConfig, false, FsnotifyWatchMode, time.Hour},
+		{"Invalid", func(cfg *InputConfig) { cfg.WatchMode = "invalid" }, true, "", 0},
+		{"NegativeFallback", func(cfg *InputConfig) { cfg.FallbackPollInterval = helper.Duration{Duration: -time.Second} }, true, "", 0},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := newDefaultConfig("/var/log")
+			tc.modify(cfg)
+			ops, err := cfg.Build(testutil.NewBuildContext(t))
+			if tc.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			op := ops[0].(*InputOperator)
+			require.Equal(t, tc.mode, op.WatchMode)
+			require.Equal(t, tc.fallback, op.FallbackPollInterval)
+		})
+	}
+}
+
+func TestWatchModeReadExistingAndNewLogs(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, fsnotifyConfig, nil)
+
+	temp := openTemp(t, tempDir)
+	writeString(t, temp, "testlog1\n")
+
+	require.NoError(t, operator.Start())
+	defer operator.Stop()
+
+	waitForMessage(t, logReceived, "testlog1")
+
+	// Writes to an existing file are found
+	writeString(t, temp, "testlog2\n")
+	waitForMessage(t, logReceived, "testlog2")
+
+	// New files are found
+	temp2 := openTemp(t, tempDir)
+	writeString(t, temp2, "testlog3\n")
+	waitForMessage(t, logReceived, "testlog3")
+}
+
+func TestWatchModeRotation(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, func(cfg *InputConfig) {
+		fsnotifyConfig(cfg)
+		cfg.Include = []string{filepath.Join(includeDir(cfg), "*.log")}
+	}, nil)
+
+	path := filepath.Join(tempDir, "test.log")
+	temp := openFile(t, path)
+	writeString(t, temp, "testlog1\n")
+
+	require.NoError(t, operator.Start())
+	defer operator.Stop()
+	waitForMessage(t, logReceived, "testlog1")
+
+	// Rotate the file by moving it out of the include pattern
+	require.NoError(t, temp.Close())
+	require.NoError(t, os.Rename(path, filepath.Join(tempDir, "test.log.1")))
+
+	temp = openFile(t, path)
+	writeString(t, temp, "testlog2\n")
+	waitForMessage(t, logReceived, "testlog2")
+	expectNoMessages(t, logReceived)
+}
+
+func TestWatchModeNestedDirectory(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, func(cfg *InputConfig) {
+		fsnotifyConfig(cfg)
+		cfg.Include = []string{filepath.Join(includeDir(cfg), "*", "*.log")}
+	}, nil)
+
+	require.NoError(t, operator.Start())
+	defer operator.Stop()
+
+	// Directories created after starting are watched too
+	dir := filepath.Join(tempDir, "app")
+	require.NoError(t, os.Mkdir(dir, 0755))
+	time.Sleep(100 * time.Millisecond)
+
+	temp := openFile(t, filepath.Join(dir, "test.log"))
+	writeString(t, temp, "testlog\n")
+	waitForMessage(t, logReceived, "testlog")
+}
+
+func TestWatchModeExclude(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, func(cfg *InputConfig) {
+		fsnotifyConfig(cfg)
+		cfg.Exclude = []string{filepath.Join(includeDir(cfg), "*.excluded")}
+	}, nil)
+
+	require.NoError(t, operator.Start())
+	defer operator.Stop()
+
+	excluded := openFile(t, filepath.Join(tempDir, "test.excluded"))
+	writeString(t, excluded, "excluded\n")
+	expectNoMessages(t, logReceived)
+}
+
+func TestUpdateWatches(t *testing.T) {
+	tempDir := testutil.NewTempDir(t)
+	operator, _, _ := newTestFileOperator(t, func(cfg *InputConfig) {
+		cfg.Include = []string{
+			filepath.Join(tempDir, "*", "*.log"),
+			filepath.Join(tempDir, "missing", "*.log"),
+		}
+	}, nil)
+
+	watcher, err := fsnotify.NewWatcher()
+	require.NoError(t, err)
+	defer watcher.Close()
+
+	dir1 := filepath.Join(tempDir, "dir1")
+	dir2 := filepath.Join(tempDir, "dir2")
+	require.NoError(t, os.Mkdir(dir1, 0755))
+	require.NoError(t, os.Mkdir(dir2, 0755))
+
+	// Files that match the directory pattern are not watched
+	temp := openFile(t, filepath.Join(tempDir, "file"))
+	require.NoError(t, temp.Close())
+
+	watched := map[string]struct{}{}
+	operator.updateWatches(watcher, watched)
+	require.Equal(t, map[string]struct{}{dir1: {}, dir2: {}}, watched)
+
+	require.NoError(t, os.Remove(dir2))
+	operator.updateWatches(watcher, watched)
+	require.Equal(t, map[string]struct{}{dir1: {}}, watched)
+}
+
+func TestIsMatch(t *testing.T) {
+	operator, _, _ := newTestFileOperator(t, func(cfg *InputConfig) {
+		cfg.Include = []string{"/var/log/*.log", "/var/log/app/*"}
+		cfg.Exclude = []string{"/var/log/app/*.gz"}
+	}, nil)
+
+	require.True(t, operator.isMatch("/var/log/test.log"))
+	require.True(t, operator.isMatch("/var/log/app/test"))
+	require.False(t, operator.isMatch("/var/log/test.txt"))
+	require.False(t, operator.isMatch("/var/log/app/test.gz"))
+}
+
+// includeDir returns the directory a config created by newDefaultConfig includes
+func includeDir(cfg *InputConfig) string {
+	return filepath.Dir(cfg.Include[0])
+}
@@ -1,8 +1,11 @@
 package forward
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
+	"io"
+	"mime"
 	"net"
 	"net/http"
 
@@ -17,18 +20,23 @@ func init() {
 	operator.Register("forward_input", func() operator.Builder { return NewForwardInputConfig("") })
 }
 
+// DefaultMaxRequestSize is the default size of the largest request body that is accepted
+const DefaultMaxRequestSize = 100 << 20
+
 // NewForwardInputConfig creates a new stdin input config with default values
 func NewForwardInputConfig(operatorID string) *ForwardInputConfig {
 	return &ForwardInputConfig{
-		InputConfig: helper.NewInputConfig(operatorID, "stdin"),
+		InputConfig:    helper.NewInputConfig(operatorID, "stdin"),
+		MaxRequestSize: DefaultMaxRequestSize,
 	}
 }
 
 // ForwardInputConfig is the configuration of a forward input operator
 type ForwardInputConfig struct {
 	helper.InputConfig `yaml:",inline"`
-	ListenAddress      string     `json:"listen_address" yaml:"listen_address"`
-	TLS                *TLSConfig `json:"tls"            yaml:"tls"`
+	ListenAddress      string          `json:"listen_address"             yaml:"listen_address"`
+	TLS                *TLSConfig      `json:"tls"                        yaml:"tls"`
+	MaxRequestSize     helper.ByteSize `json:"max_request_size,omitempty" yaml:"max_request_size,omitempty"`
 }
 
 // TLSConfig is a configuration struct for forward input TLS
@@ -44,9 +52,17 @@ func (c *ForwardInputConfig) Build(context operator.BuildContext) ([]operator.Op
 		return nil, err
 	}
 
+	if c.MaxRequestSize <= 0 {
+		return nil, errors.NewError(
+			"invalid value for 'max_request_size'",
+			"ensure that 'max_request_size' is a positive size",
+		)
+	}
+
 	forwardInput := &ForwardInput{
-		InputOperator: inputOperator,
-		tls:           c.TLS,
+		InputOperator:  inputOperator,
+		tls:            c.TLS,
+		maxRequestSize: int64(c.MaxRequestSize),
 	}
 
 	forwardInput.srv = &http.Server{
@@ -61,9 +77,10 @@ func (c *ForwardInputConfig) Build(context operator.BuildContext) ([]operator.Op
 type ForwardInput struct {
 	helper.InputOperator
 
-	srv *http.Server
-	ln  net.Listener
-	tls *TLSConfig
+	srv            *http.Server
+	ln             net.Listener
+	tls            *TLSConfig
+	maxRequestSize int64
 }
 
 // Start will start generating log entries.
@@ -101,10 +118,26 @@ func (f *ForwardInput) Stop() error {
 }
 
 func (f *ForwardInput) ServeHTTP(wr http.ResponseWriter, req *http.Request) {
-	dec := json.NewDecoder(req.Body)
+	if req.ContentLength > f.maxRequestSize {
+		wr.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	// Bodies without a content length are cut off at the max request size and fail to decode
+	body := http.MaxBytesReader(wr, req.Body, f.maxRequestSize)
 
 	var entries []*entry.Entry
-	if err := dec.Decode(&entries); err != nil {
+	var err error
+	if mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type")); mediaType == entry.BinaryContentType {
+		entries, err = decodeBinary(body)
+	} else {
+		err = json.NewDecoder(body).Decode(&entries)
+	}
+
+	if _, ok := err.(entry.UnsupportedVersionError); ok {
+		wr.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	} else if err != nil {
 		wr.WriteHeader(http.StatusBadRequest)
 		return
 	}
@@ -113,3 +146,20 @@ func (f *ForwardInput) ServeHTTP(wr http.ResponseWriter, req *http.Request) {
 		f.Write(req.Context(), entry)
 	}
 }
+
+// decodeBinary decodes a sequence of entries in the binary entry format
+func decodeBinary(body io.Reader) ([]*entry.Entry, error) {
+	r := bufio.NewReader(body)
+	var entries []*entry.Entry
+	for {
+		if _, err := r.Peek(1); err == io.EOF {
+			return entries, nil
+		}
+
+		e, _, err := entry.ReadBinary(r)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+}
@@ -6,10 +6,12 @@ import (
 	"crypto/x509"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -61,6 +63,124 @@ func TestForwardInput(t *testing.T) {
 	}
 }
 
+func TestForwardInputBinary(t *testing.T) {
+	cfg := NewForwardInputConfig("test")
+	cfg.ListenAddress = "0.0.0.0:0"
+	cfg.OutputIDs = []string{"fake"}
+
+	ops, err := cfg.Build(testutil.NewBuildContext(t))
+	require.NoError(t, err)
+	forwardInput := ops[0].(*ForwardInput)
+
+	fake := testutil.NewFakeOutput(t)
+	err = forwardInput.SetOutputs([]operator.Operator{fake})
+	require.NoError(t, err)
+
+	require.NoError(t, forwardInput.Start())
+	defer forwardInput.Stop()
+
+	_, port, err := net.SplitHostPort(forwardInput.ln.Addr().String())
+	require.NoError(t, err)
+	address := fmt.Sprintf("http://127.0.0.1:%s", port)
+
+	first := entry.New()
+	first.Record = "first"
+	second := entry.New()
+	second.Record = map[string]interface{}{"message": "second"}
+	body, err := first.MarshalBinary()
+	require.NoError(t, err)
+	body, err = second.AppendBinary(body)
+	require.NoError(t, err)
+
+	res, err := http.Post(address, entry.BinaryContentType, bytes.NewReader(body))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	for _, expected := range []*entry.Entry{first, second} {
+		select {
+		case <-time.After(time.Second):
+			require.FailNow(t, "Timed out waiting for entry to be received")
+		case e := <-fake.Received:
+			require.True(t, expected.Timestamp.Equal(e.Timestamp))
+			require.Equal(t, expected.Record, e.Record)
+		}
+	}
+
+	t.Run("UnsupportedVersion", func(t *testing.T) {
+		newer := append([]byte{}, body...)
+		newer[1] = entry.BinaryVersion + 1
+		res, err := http.Post(address, entry.BinaryContentType, bytes.NewReader(newer))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusUnsupportedMediaType, res.StatusCode)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		res, err := http.Post(address, entry.BinaryContentType, bytes.NewReader(body[:len(body)-1]))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+}
+
+func TestForwardInputMaxRequestSize(t *testing.T) {
+	cfg := NewForwardInputConfig("test")
+	cfg.ListenAddress = "0.0.0.0:0"
+	cfg.OutputIDs = []string{"fake"}
+	cfg.MaxRequestSize = 1024
+
+	ops, err := cfg.Build(testutil.NewBuildContext(t))
+	require.NoError(t, err)
+	forwardInput := ops[0].(*ForwardInput)
+
+	fake := testutil.NewFakeOutput(t)
+	err = forwardInput.SetOutputs([]operator.Operator{fake})
+	require.NoError(t, err)
+
+	require.NoError(t, forwardInput.Start())
+	defer forwardInput.Stop()
+
+	_, port, err := net.SplitHostPort(forwardInput.ln.Addr().String())
+	require.NoError(t, err)
+	address := fmt.Sprintf("http://127.0.0.1:%s", port)
+
+	large := entry.New()
+	large.Record = strings.Repeat("a", 2048)
+	body, err := large.MarshalBinary()
+	require.NoError(t, err)
+
+	t.Run("ContentLength", func(t *testing.T) {
+		res, err := http.Post(address, entry.BinaryContentType, bytes.NewReader(body))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusRequestEntityTooLarge, res.StatusCode)
+	})
+
+	t.Run("Chunked", func(t *testing.T) {
+		// A reader of unknown length is sent without a content length
+		res, err := http.Post(address, entry.BinaryContentType, ioutil.NopCloser(bytes.NewReader(body)))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("MaliciousLength", func(t *testing.T) {
+		malicious := []byte{entry.BinaryMagic, entry.BinaryVersion, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}
+		res, err := http.Post(address, entry.BinaryContentType, bytes.NewReader(malicious))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("InvalidConfig", func(t *testing.T) {
+		cfg := NewForwardInputConfig("test")
+		cfg.MaxRequestSize = 0
+		_, err := cfg.Build(testutil.NewBuildContext(t))
+		require.Error(t, err)
+	})
+
+	select {
+	case e := <-fake.Received:
+		require.FailNow(t, "Unexpected entry received", "%v", e)
+	default:
+	}
+}
+
 func TestForwardInputTLS(t *testing.T) {
 	certFile, keyFile := createCertFiles(t)
 
@@ -38,6 +38,9 @@ func (c *GenerateInputConfig) Build(context operator.BuildContext) ([]operator.O
 	}
 
 	c.Entry.Record = recursiveMapInterfaceToMapString(c.Entry.Record)
+	if c.Entry.ObservedTimestamp.IsZero() {
+		c.Entry.ObservedTimestamp = c.Entry.Timestamp
+	}
 
 	generateInput := &GenerateInput{
 		InputOperator: inputOperator,
@@ -77,6 +80,7 @@ func (g *GenerateInput) Start() error {
 			entry := g.entry.Copy()
 			if !g.static {
 				entry.Timestamp = time.Now()
+				entry.ObservedTimestamp = entry.Timestamp
 			}
 			g.Write(ctx, entry)
 
@@ -87,6 +87,7 @@ func (g *StdinInput) Start() error {
 			}
 
 			e := entry.New()
+			e.ObservedTimestamp = e.Timestamp
 			e.Record = scanner.Text()
 			g.Write(ctx, e)
 		}
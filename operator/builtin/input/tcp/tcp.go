@@ -1,17 +1,21 @@
 package tcp
 
 import (
-	"bufio"
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
 	"sync"
+	"time"
 
 	"github.com/observiq/stanza/operator"
 	"github.com/observiq/stanza/operator/helper"
 	"go.uber.org/zap"
 )
 
+// handshakeTimeout is how long a client has to complete a TLS handshake
+const handshakeTimeout = 10 * time.Second
+
 func init() {
 	operator.Register("tcp_input", func() operator.Builder { return NewTCPInputConfig("") })
 }
@@ -19,15 +23,18 @@ func init() {
 // NewTCPInputConfig creates a new TCP input config with default values
 func NewTCPInputConfig(operatorID string) *TCPInputConfig {
 	return &TCPInputConfig{
-		InputConfig: helper.NewInputConfig(operatorID, "tcp_input"),
+		InputConfig:   helper.NewInputConfig(operatorID, "tcp_input"),
+		FramingConfig: helper.NewFramingConfig(helper.NewlineFraming),
 	}
 }
 
 // TCPInputConfig is the configuration of a tcp input operator.
 type TCPInputConfig struct {
-	helper.InputConfig `yaml:",inline"`
+	helper.InputConfig   `yaml:",inline"`
+	helper.FramingConfig `yaml:",inline"`
 
-	ListenAddress string `json:"listen_address,omitempty" yaml:"listen_address,omitempty"`
+	ListenAddress string     `json:"listen_address,omitempty" yaml:"listen_address,omitempty"`
+	TLS           *TLSConfig `json:"tls,omitempty"            yaml:"tls,omitempty"`
 }
 
 // Build will build a tcp input operator.
@@ -46,9 +53,29 @@ func (c TCPInputConfig) Build(context operator.BuildContext) ([]operator.Operato
 		return nil, fmt.Errorf("failed to resolve listen_address: %s", err)
 	}
 
+	framer, err := c.FramingConfig.Build(
+		helper.NewlineFraming,
+		helper.OctetCountingFraming,
+		helper.NULFraming,
+		helper.DelimiterFraming,
+		helper.MultilineFraming,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var tlsLoader *tlsLoader
+	if c.TLS != nil {
+		if tlsLoader, err = c.TLS.Build(inputOperator.SugaredLogger); err != nil {
+			return nil, err
+		}
+	}
+
 	tcpInput := &TCPInput{
 		InputOperator: inputOperator,
 		address:       address,
+		framer:        framer,
+		tls:           tlsLoader,
 	}
 	return []operator.Operator{tcpInput}, nil
 }
@@ -57,8 +84,10 @@ func (c TCPInputConfig) Build(context operator.BuildContext) ([]operator.Operato
 type TCPInput struct {
 	helper.InputOperator
 	address *net.TCPAddr
+	framer  *helper.Framer
+	tls     *tlsLoader
 
-	listener *net.TCPListener
+	listener net.Listener
 	cancel   context.CancelFunc
 	wg       sync.WaitGroup
 }
@@ -71,6 +100,9 @@ func (t *TCPInput) Start() error {
 	}
 
 	t.listener = listener
+	if t.tls != nil {
+		t.listener = tls.NewListener(listener, t.tls.listenerConfig())
+	}
 	ctx, cancel := context.WithCancel(context.Background())
 	t.cancel = cancel
 	t.goListen(ctx)
@@ -85,13 +117,14 @@ func (t *TCPInput) goListen(ctx context.Context) {
 		defer t.wg.Done()
 
 		for {
-			conn, err := t.listener.AcceptTCP()
+			conn, err := t.listener.Accept()
 			if err != nil {
 				select {
 				case <-ctx.Done():
 					return
 				default:
 					t.Debugw("Listener accept error", zap.Error(err))
+					continue
 				}
 			}
 
@@ -125,13 +158,22 @@ func (t *TCPInput) goHandleMessages(ctx context.Context, conn net.Conn, cancel c
 		defer t.wg.Done()
 		defer cancel()
 
-		scanner := bufio.NewScanner(conn)
+		clientSubject, err := t.handshake(conn)
+		if err != nil {
+			t.Errorw("TLS handshake failed", "remote_address", conn.RemoteAddr().String(), zap.Error(err))
+			return
+		}
+
+		scanner := t.framer.NewScanner(conn)
 		for scanner.Scan() {
 			entry, err := t.NewEntry(scanner.Text())
 			if err != nil {
 				t.Errorw("Failed to create entry", zap.Error(err))
 				continue
 			}
+			if clientSubject != "" {
+				entry.AddLabel(clientSubjectLabel, clientSubject)
+			}
 			t.Write(ctx, entry)
 		}
 		if err := scanner.Err(); err != nil {
@@ -140,6 +182,31 @@ func (t *TCPInput) goHandleMessages(ctx context.Context, conn net.Conn, cancel c
 	}()
 }
 
+// handshake completes the TLS handshake of a connection, and returns the subject of the
+// verified client certificate if it is included in entries
+func (t *TCPInput) handshake(conn net.Conn) (string, error) {
+	tlsConn, ok := conn.(*tls.Conn)
+	if !ok {
+		return "", nil
+	}
+
+	if err := tlsConn.SetDeadline(time.Now().Add(handshakeTimeout)); err != nil {
+		return "", err
+	}
+	if err := tlsConn.Handshake(); err != nil {
+		return "", err
+	}
+	if err := tlsConn.SetDeadline(time.Time{}); err != nil {
+		return "", err
+	}
+
+	state := tlsConn.ConnectionState()
+	if !t.tls.IncludeClientSubject || len(state.VerifiedChains) == 0 {
+		return "", nil
+	}
+	return state.VerifiedChains[0][0].Subject.String(), nil
+}
+
 // Stop will stop listening for log entries over TCP.
 func (t *TCPInput) Stop() error {
 	t.cancel()
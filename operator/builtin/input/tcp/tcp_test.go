@@ -7,15 +7,21 @@ import (
 
 	"github.com/observiq/stanza/entry"
 	"github.com/observiq/stanza/operator"
+	"github.com/observiq/stanza/operator/helper"
 	"github.com/observiq/stanza/testutil"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
 func tcpInputTest(input []byte, expected []string) func(t *testing.T) {
+	return tcpInputTestWithConfig(func(cfg *TCPInputConfig) {}, input, expected)
+}
+
+func tcpInputTestWithConfig(modify func(*TCPInputConfig), input []byte, expected []string) func(t *testing.T) {
 	return func(t *testing.T) {
 		cfg := NewTCPInputConfig("test_id")
 		cfg.ListenAddress = ":0"
+		modify(cfg)
 
 		ops, err := cfg.Build(testutil.NewBuildContext(t))
 		require.NoError(t, err)
@@ -64,6 +70,38 @@ func TestTcpInput(t *testing.T) {
 	t.Run("CarriageReturn", tcpInputTest([]byte("message\r\n"), []string{"message"}))
 }
 
+func TestTcpInputFraming(t *testing.T) {
+	t.Run("OctetCounting", tcpInputTestWithConfig(func(cfg *TCPInputConfig) {
+		cfg.Framing = helper.OctetCountingFraming
+	}, []byte("9 message\n19 message2\n"), []string{"message\n1", "message2\n"}))
+
+	t.Run("NUL", tcpInputTestWithConfig(func(cfg *TCPInputConfig) {
+		cfg.Framing = helper.NULFraming
+	}, []byte("message\n1\x00message2\x00"), []string{"message\n1", "message2"}))
+
+	t.Run("Multiline", tcpInputTestWithConfig(func(cfg *TCPInputConfig) {
+		cfg.Framing = helper.MultilineFraming
+		cfg.Multiline = &helper.MultilineConfig{LineEndPattern: `;\n`}
+	}, []byte("message\n1;\nmessage2;\n"), []string{"message\n1;\n", "message2;\n"}))
+
+	t.Run("Truncate", tcpInputTestWithConfig(func(cfg *TCPInputConfig) {
+		cfg.MaxLogSize = 8
+	}, []byte("message1\nmessage22\nmessage3\n"), []string{"message1", "message2", "message3"}))
+
+	t.Run("Drop", tcpInputTestWithConfig(func(cfg *TCPInputConfig) {
+		cfg.MaxLogSize = 8
+		cfg.OversizedLogs = helper.DropOversized
+	}, []byte("message1\nmessage22\nmessage3\n"), []string{"message1", "message3"}))
+}
+
+func TestTcpInputBuildFraming(t *testing.T) {
+	cfg := NewTCPInputConfig("test_id")
+	cfg.ListenAddress = ":0"
+	cfg.Framing = helper.NoFraming
+	_, err := cfg.Build(testutil.NewBuildContext(t))
+	require.Error(t, err)
+}
+
 func BenchmarkTcpInput(b *testing.B) {
 	cfg := NewTCPInputConfig("test_id")
 	cfg.ListenAddress = ":0"
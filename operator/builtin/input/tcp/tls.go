@@ -0,0 +1,209 @@
+package tcp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// clientSubjectLabel is the label the subject of a verified client certificate is added to
+const clientSubjectLabel = "tls_client_subject"
+
+const defaultMinTLSVersion = "1.2"
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// TLSConfig is the configuration of TLS for a tcp input operator
+type TLSConfig struct {
+	CertFile             string   `json:"cert_file"                        yaml:"cert_file"`
+	KeyFile              string   `json:"key_file"                         yaml:"key_file"`
+	ClientCAFile         string   `json:"client_ca_file,omitempty"         yaml:"client_ca_file,omitempty"`
+	MinVersion           string   `json:"min_version,omitempty"            yaml:"min_version,omitempty"`
+	CipherSuites         []string `json:"cipher_suites,omitempty"          yaml:"cipher_suites,omitempty"`
+	IncludeClientSubject bool     `json:"include_client_subject,omitempty" yaml:"include_client_subject,omitempty"`
+}
+
+// Build validates the config and loads the certificates it refers to
+func (c TLSConfig) Build(logger *zap.SugaredLogger) (*tlsLoader, error) {
+	if c.CertFile == "" || c.KeyFile == "" {
+		return nil, fmt.Errorf("`cert_file` and `key_file` are required for tls")
+	}
+
+	if c.MinVersion == "" {
+		c.MinVersion = defaultMinTLSVersion
+	}
+	minVersion, ok := tlsVersions[c.MinVersion]
+	if !ok {
+		return nil, fmt.Errorf("invalid tls min_version '%s'", c.MinVersion)
+	}
+
+	cipherSuites, err := lookupCipherSuites(c.CipherSuites)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.IncludeClientSubject && c.ClientCAFile == "" {
+		return nil, fmt.Errorf("`include_client_subject` requires `client_ca_file`")
+	}
+
+	loader := &tlsLoader{
+		TLSConfig:     c,
+		minVersion:    minVersion,
+		cipherSuites:  cipherSuites,
+		SugaredLogger: logger,
+	}
+	if err := loader.load(); err != nil {
+		return nil, err
+	}
+	return loader, nil
+}
+
+// lookupCipherSuites returns the IDs of the named cipher suites. Only suites without
+// known security issues are allowed.
+func lookupCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	available := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		available[suite.Name] = suite.ID
+	}
+
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := available[name]
+		if !ok {
+			return nil, fmt.Errorf("unsupported tls cipher suite '%s'", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// tlsLoader creates the TLS config for each connection, reloading the certificate, key and
+// client CA when their files change
+type tlsLoader struct {
+	TLSConfig
+	minVersion   uint16
+	cipherSuites []uint16
+
+	mux      sync.Mutex
+	config   *tls.Config
+	modTimes []time.Time
+
+	*zap.SugaredLogger
+}
+
+// files returns the files the TLS config is loaded from
+func (l *tlsLoader) files() []string {
+	files := []string{l.CertFile, l.KeyFile}
+	if l.ClientCAFile != "" {
+		files = append(files, l.ClientCAFile)
+	}
+	return files
+}
+
+// load loads the TLS config from the files
+func (l *tlsLoader) load() error {
+	modTimes, err := l.readModTimes()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(l.CertFile, l.KeyFile)
+	if err != nil {
+		return fmt.Errorf("load tls certificate: %s", err)
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   l.minVersion,
+		CipherSuites: l.cipherSuites,
+	}
+
+	if l.ClientCAFile != "" {
+		pem, err := ioutil.ReadFile(l.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("read tls client_ca_file: %s", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in tls client_ca_file")
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	l.mux.Lock()
+	l.config = config
+	l.modTimes = modTimes
+	l.mux.Unlock()
+	return nil
+}
+
+// readModTimes returns the modification times of the files
+func (l *tlsLoader) readModTimes() ([]time.Time, error) {
+	files := l.files()
+	modTimes := make([]time.Time, 0, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		modTimes = append(modTimes, info.ModTime())
+	}
+	return modTimes, nil
+}
+
+// changed returns whether any of the files have been modified since they were loaded
+func (l *tlsLoader) changed() bool {
+	modTimes, err := l.readModTimes()
+	if err != nil {
+		// Keep using the loaded files until they are replaced
+		return false
+	}
+
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	for i, modTime := range modTimes {
+		if !modTime.Equal(l.modTimes[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetConfigForClient returns the TLS config for a new connection. It is used as the
+// tls.Config callback of the listener.
+func (l *tlsLoader) GetConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	if l.changed() {
+		if err := l.load(); err != nil {
+			l.Errorw("Failed to reload tls files. Using the previously loaded files", zap.Error(err))
+		} else {
+			l.Infow("Reloaded tls files")
+		}
+	}
+
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	return l.config, nil
+}
+
+// listenerConfig returns the config of a TLS listener that loads the config for each connection
+func (l *tlsLoader) listenerConfig() *tls.Config {
+	return &tls.Config{
+		GetConfigForClient: l.GetConfigForClient,
+	}
+}
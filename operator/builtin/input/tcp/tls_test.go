@@ -0,0 +1,298 @@
+package tcp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/observiq/stanza/entry"
+	"github.com/observiq/stanza/operator"
+	"github.com/observiq/stanza/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+// newTestCert creates a certificate signed by parent, or a self-signed CA if parent is nil
+func newTestCert(t *testing.T, commonName string, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName, Organization: []string{"stanza"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCert{cert: cert, key: key, der: der}
+}
+
+// write writes the certificate and key in PEM format, and returns their paths
+func (c *testCert) write(t *testing.T, dir, name string) (string, string) {
+	certPath := filepath.Join(dir, name+".crt")
+	require.NoError(t, ioutil.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.der}), 0600))
+
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+	keyPath := filepath.Join(dir, name+".key")
+	require.NoError(t, ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certPath, keyPath
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.der}, PrivateKey: c.key}
+}
+
+func (c *testCert) pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(c.cert)
+	return pool
+}
+
+type tlsTestSetup struct {
+	dir        string
+	ca         *testCert
+	caPath     string
+	serverCert string
+	serverKey  string
+}
+
+func newTLSTestSetup(t *testing.T) *tlsTestSetup {
+	dir := testutil.NewTempDir(t)
+	ca := newTestCert(t, "ca", nil)
+	caPath, _ := ca.write(t, dir, "ca")
+	serverCert, serverKey := newTestCert(t, "server", ca).write(t, dir, "server")
+	return &tlsTestSetup{dir, ca, caPath, serverCert, serverKey}
+}
+
+func startTLSInput(t *testing.T, tlsConfig *TLSConfig) (*TCPInput, chan *entry.Entry) {
+	cfg := NewTCPInputConfig("test_id")
+	cfg.ListenAddress = "127.0.0.1:0"
+	cfg.TLS = tlsConfig
+
+	ops, err := cfg.Build(testutil.NewBuildContext(t))
+	require.NoError(t, err)
+
+	fakeOutput := testutil.NewFakeOutput(t)
+	tcpInput := ops[0].(*TCPInput)
+	tcpInput.InputOperator.OutputOperators = []operator.Operator{fakeOutput}
+
+	require.NoError(t, tcpInput.Start())
+	t.Cleanup(func() { tcpInput.Stop() })
+	return tcpInput, fakeOutput.Received
+}
+
+func expectEntry(t *testing.T, received chan *entry.Entry) *entry.Entry {
+	select {
+	case e := <-received:
+		return e
+	case <-time.After(time.Second):
+		require.FailNow(t, "Timed out waiting for entry")
+		return nil
+	}
+}
+
+func expectNoEntry(t *testing.T, received chan *entry.Entry) {
+	select {
+	case e := <-received:
+		require.FailNow(t, "Unexpected entry", "%v", e)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestTLSConfigBuild(t *testing.T) {
+	setup := newTLSTestSetup(t)
+
+	cases := []struct {
+		name      string
+		config    TLSConfig
+		expectErr bool
+	}{
+		{"Valid", TLSConfig{CertFile: setup.serverCert, KeyFile: setup.serverKey}, false},
+		{"MutualTLS", TLSConfig{CertFile: setup.serverCert, KeyFile: setup.serverKey, ClientCAFile: setup.caPath, IncludeClientSubject: true}, false},
+		{"MinVersion", TLSConfig{CertFile: setup.serverCert, KeyFile: setup.serverKey, MinVersion: "1.3"}, false},
+		{"CipherSuites", TLSConfig{CertFile: setup.serverCert, KeyFile: setup.serverKey, CipherSuites: []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"}}, false},
+		{"MissingKey", TLSConfig{CertFile: setup.serverCert}, true},
+		{"MissingCertFile", TLSConfig{CertFile: filepath.Join(setup.dir, "missing.crt"), KeyFile: setup.serverKey}, true},
+		{"MismatchedKey", TLSConfig{CertFile: setup.caPath, KeyFile: setup.serverKey}, true},
+		{"InvalidClientCA", TLSConfig{CertFile: setup.serverCert, KeyFile: setup.serverKey, ClientCAFile: setup.serverKey}, true},
+		{"InvalidMinVersion", TLSConfig{CertFile: setup.serverCert, KeyFile: setup.serverKey, MinVersion: "1.4"}, true},
+		{"InsecureCipherSuite", TLSConfig{CertFile: setup.serverCert, KeyFile: setup.serverKey, CipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"}}, true},
+		{"ClientSubjectWithoutClientCA", TLSConfig{CertFile: setup.serverCert, KeyFile: setup.serverKey, IncludeClientSubject: true}, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := NewTCPInputConfig("test_id")
+			cfg.ListenAddress = "127.0.0.1:0"
+			cfg.TLS = &tc.config
+			_, err := cfg.Build(testutil.NewBuildContext(t))
+			if tc.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestTCPInputTLS(t *testing.T) {
+	setup := newTLSTestSetup(t)
+	tcpInput, received := startTLSInput(t, &TLSConfig{
+		CertFile: setup.serverCert,
+		KeyFile:  setup.serverKey,
+	})
+
+	conn, err := tls.Dial("tcp", tcpInput.listener.Addr().String(), &tls.Config{RootCAs: setup.ca.pool()})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("message\n"))
+	require.NoError(t, err)
+
+	e := expectEntry(t, received)
+	require.Equal(t, "message", e.Record)
+	require.NotContains(t, e.Labels, clientSubjectLabel)
+}
+
+func TestTCPInputTLSMinVersion(t *testing.T) {
+	setup := newTLSTestSetup(t)
+	tcpInput, _ := startTLSInput(t, &TLSConfig{
+		CertFile:   setup.serverCert,
+		KeyFile:    setup.serverKey,
+		MinVersion: "1.3",
+	})
+
+	_, err := tls.Dial("tcp", tcpInput.listener.Addr().String(), &tls.Config{
+		RootCAs:    setup.ca.pool(),
+		MaxVersion: tls.VersionTLS12,
+	})
+	require.Error(t, err)
+}
+
+func TestTCPInputMutualTLS(t *testing.T) {
+	setup := newTLSTestSetup(t)
+	tcpInput, received := startTLSInput(t, &TLSConfig{
+		CertFile:             setup.serverCert,
+		KeyFile:              setup.serverKey,
+		ClientCAFile:         setup.caPath,
+		IncludeClientSubject: true,
+	})
+	client := newTestCert(t, "client", setup.ca)
+
+	t.Run("ClientCertificate", func(t *testing.T) {
+		conn, err := tls.Dial("tcp", tcpInput.listener.Addr().String(), &tls.Config{
+			RootCAs:      setup.ca.pool(),
+			Certificates: []tls.Certificate{client.tlsCertificate()},
+		})
+		require.NoError(t, err)
+		defer conn.Close()
+
+		_, err = conn.Write([]byte("message\n"))
+		require.NoError(t, err)
+
+		e := expectEntry(t, received)
+		require.Equal(t, "message", e.Record)
+		require.Equal(t, "CN=client,O=stanza", e.Labels[clientSubjectLabel])
+	})
+
+	t.Run("NoClientCertificate", func(t *testing.T) {
+		conn, err := tls.Dial("tcp", tcpInput.listener.Addr().String(), &tls.Config{RootCAs: setup.ca.pool()})
+		if err == nil {
+			// With TLS 1.3, the client finds out that it was rejected on its first read
+			defer conn.Close()
+			_, _ = conn.Write([]byte("message\n"))
+			_, err = conn.Read(make([]byte, 1))
+		}
+		require.Error(t, err)
+		expectNoEntry(t, received)
+	})
+
+	t.Run("UntrustedClientCertificate", func(t *testing.T) {
+		untrusted := newTestCert(t, "untrusted", newTestCert(t, "other ca", nil))
+		conn, err := tls.Dial("tcp", tcpInput.listener.Addr().String(), &tls.Config{
+			RootCAs:      setup.ca.pool(),
+			Certificates: []tls.Certificate{untrusted.tlsCertificate()},
+		})
+		if err == nil {
+			defer conn.Close()
+			_, _ = conn.Write([]byte("message\n"))
+			_, err = conn.Read(make([]byte, 1))
+		}
+		require.Error(t, err)
+		expectNoEntry(t, received)
+	})
+}
+
+func TestTCPInputTLSReload(t *testing.T) {
+	setup := newTLSTestSetup(t)
+	tcpInput, _ := startTLSInput(t, &TLSConfig{
+		CertFile: setup.serverCert,
+		KeyFile:  setup.serverKey,
+	})
+
+	serverName := func() string {
+		conn, err := tls.Dial("tcp", tcpInput.listener.Addr().String(), &tls.Config{RootCAs: setup.ca.pool()})
+		require.NoError(t, err)
+		defer conn.Close()
+		return conn.ConnectionState().PeerCertificates[0].Subject.CommonName
+	}
+	require.Equal(t, "server", serverName())
+
+	// Replace the certificate and key, and make sure their modification times change
+	newTestCert(t, "renewed", setup.ca).write(t, setup.dir, "server")
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(setup.serverCert, future, future))
+	require.NoError(t, os.Chtimes(setup.serverKey, future, future))
+
+	require.Equal(t, "renewed", serverName())
+}
+
+func TestTCPInputTLSReloadFailure(t *testing.T) {
+	setup := newTLSTestSetup(t)
+	tcpInput, _ := startTLSInput(t, &TLSConfig{
+		CertFile: setup.serverCert,
+		KeyFile:  setup.serverKey,
+	})
+
+	// A broken certificate is not used
+	require.NoError(t, ioutil.WriteFile(setup.serverCert, []byte("invalid"), 0600))
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(setup.serverCert, future, future))
+
+	conn, err := tls.Dial("tcp", tcpInput.listener.Addr().String(), &tls.Config{RootCAs: setup.ca.pool()})
+	require.NoError(t, err)
+	defer conn.Close()
+	require.Equal(t, "server", conn.ConnectionState().PeerCertificates[0].Subject.CommonName)
+}
@@ -11,6 +11,9 @@ import (
 	"go.uber.org/zap"
 )
 
+// defaultReadBufferSize is the default size of the buffer datagrams are read into
+const defaultReadBufferSize = 8192
+
 func init() {
 	operator.Register("udp_input", func() operator.Builder { return NewUDPInputConfig("") })
 }
@@ -18,15 +21,19 @@ func init() {
 // NewUDPInputConfig creates a new UDP input config with default values
 func NewUDPInputConfig(operatorID string) *UDPInputConfig {
 	return &UDPInputConfig{
-		InputConfig: helper.NewInputConfig(operatorID, "udp_input"),
+		InputConfig:    helper.NewInputConfig(operatorID, "udp_input"),
+		FramingConfig:  helper.NewFramingConfig(helper.NoFraming),
+		ReadBufferSize: defaultReadBufferSize,
 	}
 }
 
 // UDPInputConfig is the configuration of a udp input operator.
 type UDPInputConfig struct {
-	helper.InputConfig `yaml:",inline"`
+	helper.InputConfig   `yaml:",inline"`
+	helper.FramingConfig `yaml:",inline"`
 
-	ListenAddress string `json:"listen_address,omitempty" yaml:"listen_address,omitempty"`
+	ListenAddress  string          `json:"listen_address,omitempty"   yaml:"listen_address,omitempty"`
+	ReadBufferSize helper.ByteSize `json:"read_buffer_size,omitempty" yaml:"read_buffer_size,omitempty"`
 }
 
 // Build will build a udp input operator.
@@ -45,10 +52,28 @@ func (c UDPInputConfig) Build(context operator.BuildContext) ([]operator.Operato
 		return nil, fmt.Errorf("failed to resolve listen_address: %s", err)
 	}
 
+	if c.ReadBufferSize <= 0 {
+		return nil, fmt.Errorf("`read_buffer_size` must be positive")
+	}
+
+	framer, err := c.FramingConfig.Build(
+		helper.NoFraming,
+		helper.NewlineFraming,
+		helper.OctetCountingFraming,
+		helper.NULFraming,
+		helper.DelimiterFraming,
+		helper.MultilineFraming,
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	udpInput := &UDPInput{
 		InputOperator: inputOperator,
 		address:       address,
-		buffer:        make([]byte, 8192),
+		buffer:        make([]byte, c.ReadBufferSize),
+		framer:        framer,
+		trim:          c.Framing == helper.NoFraming,
 	}
 	return []operator.Operator{udpInput}, nil
 }
@@ -58,6 +83,8 @@ type UDPInput struct {
 	buffer []byte
 	helper.InputOperator
 	address *net.UDPAddr
+	framer  *helper.Framer
+	trim    bool
 
 	connection net.PacketConn
 	cancel     context.CancelFunc
@@ -87,7 +114,7 @@ func (u *UDPInput) goHandleMessages(ctx context.Context) {
 		defer u.wg.Done()
 
 		for {
-			message, err := u.readMessage()
+			messages, err := u.readMessages()
 			if err != nil {
 				select {
 				case <-ctx.Done():
@@ -98,29 +125,38 @@ func (u *UDPInput) goHandleMessages(ctx context.Context) {
 				break
 			}
 
-			entry, err := u.NewEntry(message)
-			if err != nil {
-				u.Errorw("Failed to create entry", zap.Error(err))
-				continue
-			}
+			for _, message := range messages {
+				entry, err := u.NewEntry(message)
+				if err != nil {
+					u.Errorw("Failed to create entry", zap.Error(err))
+					continue
+				}
 
-			u.Write(ctx, entry)
+				u.Write(ctx, entry)
+			}
 		}
 	}()
 }
 
-// readMessage will read log messages from the connection.
-func (u *UDPInput) readMessage() (string, error) {
+// readMessages will read log messages from the connection.
+func (u *UDPInput) readMessages() ([]string, error) {
 	n, _, err := u.connection.ReadFrom(u.buffer)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	// Remove trailing characters and NULs
-	for ; (n > 0) && (u.buffer[n-1] < 32); n-- {
+	// Remove trailing characters and NULs from datagrams that are a single message
+	if u.trim {
+		for ; (n > 0) && (u.buffer[n-1] < 32); n-- {
+		}
 	}
 
-	return string(u.buffer[:n]), nil
+	messages, err := u.framer.Split(u.buffer[:n])
+	if err != nil {
+		// The messages before the framing error are still kept
+		u.Errorw("Failed to split datagram", zap.Error(err))
+	}
+	return messages, nil
 }
 
 // Stop will stop listening for udp messages.
@@ -2,20 +2,27 @@ package udp
 
 import (
 	"net"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/observiq/stanza/entry"
 	"github.com/observiq/stanza/operator"
+	"github.com/observiq/stanza/operator/helper"
 	"github.com/observiq/stanza/testutil"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
 func udpInputTest(input []byte, expected []string) func(t *testing.T) {
+	return udpInputTestWithConfig(func(cfg *UDPInputConfig) {}, input, expected)
+}
+
+func udpInputTestWithConfig(modify func(*UDPInputConfig), input []byte, expected []string) func(t *testing.T) {
 	return func(t *testing.T) {
 		cfg := NewUDPInputConfig("test_input")
 		cfg.ListenAddress = ":0"
+		modify(cfg)
 
 		ops, err := cfg.Build(testutil.NewBuildContext(t))
 		require.NoError(t, err)
@@ -68,6 +75,54 @@ func TestUDPInput(t *testing.T) {
 	t.Run("NewlineInMessage", udpInputTest([]byte("message1\nmessage2\n"), []string{"message1\nmessage2"}))
 }
 
+func TestUDPInputFraming(t *testing.T) {
+	t.Run("Newline", udpInputTestWithConfig(func(cfg *UDPInputConfig) {
+		cfg.Framing = helper.NewlineFraming
+	}, []byte("message1\nmessage2\n"), []string{"message1", "message2"}))
+
+	t.Run("OctetCounting", udpInputTestWithConfig(func(cfg *UDPInputConfig) {
+		cfg.Framing = helper.OctetCountingFraming
+	}, []byte("9 message1\n8 message2"), []string{"message1\n", "message2"}))
+
+	t.Run("Truncate", udpInputTestWithConfig(func(cfg *UDPInputConfig) {
+		cfg.MaxLogSize = 7
+	}, []byte("message1"), []string{"message"}))
+
+	t.Run("Drop", udpInputTestWithConfig(func(cfg *UDPInputConfig) {
+		cfg.MaxLogSize = 7
+		cfg.OversizedLogs = helper.DropOversized
+	}, []byte("message1"), []string{}))
+
+	t.Run("LargeDatagram", udpInputTestWithConfig(func(cfg *UDPInputConfig) {
+		cfg.ReadBufferSize = 16384
+	}, []byte(strings.Repeat("a", 10000)), []string{strings.Repeat("a", 10000)}))
+
+	t.Run("SmallReadBuffer", udpInputTestWithConfig(func(cfg *UDPInputConfig) {
+		cfg.ReadBufferSize = 7
+	}, []byte("message1"), []string{"message"}))
+}
+
+func TestUDPInputBuild(t *testing.T) {
+	cases := []struct {
+		name   string
+		modify func(*UDPInputConfig)
+	}{
+		{"ZeroReadBufferSize", func(cfg *UDPInputConfig) { cfg.ReadBufferSize = 0 }},
+		{"InvalidFraming", func(cfg *UDPInputConfig) { cfg.Framing = "invalid" }},
+		{"ZeroMaxLogSize", func(cfg *UDPInputConfig) { cfg.MaxLogSize = 0 }},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := NewUDPInputConfig("test_input")
+			cfg.ListenAddress = ":0"
+			tc.modify(cfg)
+			_, err := cfg.Build(testutil.NewBuildContext(t))
+			require.Error(t, err)
+		})
+	}
+}
+
 func BenchmarkUdpInput(b *testing.B) {
 	cfg := NewUDPInputConfig("test_id")
 	cfg.ListenAddress = ":0"
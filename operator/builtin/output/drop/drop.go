@@ -47,3 +47,8 @@ type DropOutput struct {
 func (p *DropOutput) Process(ctx context.Context, entry *entry.Entry) error {
 	return nil
 }
+
+// ProcessBatch will drop a batch of entries.
+func (p *DropOutput) ProcessBatch(ctx context.Context, entries []*entry.Entry) error {
+	return nil
+}
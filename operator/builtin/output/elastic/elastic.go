@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"strconv"
 	"sync"
+	"time"
 
 	elasticsearch "github.com/elastic/go-elasticsearch/v7"
 	"github.com/elastic/go-elasticsearch/v7/esapi"
@@ -19,6 +20,10 @@ import (
 	"go.uber.org/zap"
 )
 
+// maxPayloadBytes is the default maximum size of an HTTP request body accepted
+// by Elasticsearch (http.max_content_length)
+const maxPayloadBytes = 100 << 20
+
 func init() {
 	operator.Register("elastic_output", func() operator.Builder { return NewElasticOutputConfig("") })
 }
@@ -71,7 +76,7 @@ func (c ElasticOutputConfig) Build(bc operator.BuildContext) ([]operator.Operato
 		)
 	}
 
-	buffer, err := c.BufferConfig.Build(bc, c.ID())
+	buffer, err := c.BufferConfig.BuildWithPayloadLimit(bc, c.ID(), maxPayloadBytes)
 	if err != nil {
 		return nil, err
 	}
@@ -120,6 +125,11 @@ func (e *ElasticOutput) Start() error {
 	return nil
 }
 
+// Status returns the state of the output's flusher
+func (e *ElasticOutput) Status() map[string]interface{} {
+	return e.flusher.Status()
+}
+
 // Stop tells the ElasticOutput to stop gracefully
 func (e *ElasticOutput) Stop() error {
 	e.cancel()
@@ -133,6 +143,48 @@ func (e *ElasticOutput) Process(ctx context.Context, entry *entry.Entry) error {
 	return e.buffer.Add(ctx, entry)
 }
 
+// ProcessBatch adds a batch of entries to the outputs buffer
+func (e *ElasticOutput) ProcessBatch(ctx context.Context, entries []*entry.Entry) error {
+	return buffer.AddBatch(ctx, e.buffer, entries)
+}
+
+// ecsID is an object holding an ID, such as the trace and span objects of the Elastic Common Schema
+type ecsID struct {
+	ID string `json:"id"`
+}
+
+// entryFields has the fields of an entry without its JSON marshaller, so that they can be
+// embedded in a document alongside other fields
+type entryFields entry.Entry
+
+// elasticDocument is the document indexed for an entry. The trace ID and span ID of
+// the entry are moved to the trace.id and span.id fields of the Elastic Common Schema.
+type elasticDocument struct {
+	*entryFields
+
+	// These hide the fields of the same name on the entry
+	ObservedTimestamp *time.Time    `json:"observed_timestamp,omitempty"`
+	TraceID           entry.TraceID `json:"trace_id,omitempty"`
+	SpanID            entry.SpanID  `json:"span_id,omitempty"`
+
+	Trace *ecsID `json:"trace,omitempty"`
+	Span  *ecsID `json:"span,omitempty"`
+}
+
+func newElasticDocument(e *entry.Entry) elasticDocument {
+	doc := elasticDocument{entryFields: (*entryFields)(e)}
+	if !e.ObservedTimestamp.IsZero() {
+		doc.ObservedTimestamp = &e.ObservedTimestamp
+	}
+	if len(e.TraceID) > 0 {
+		doc.Trace = &ecsID{ID: e.TraceID.String()}
+	}
+	if len(e.SpanID) > 0 {
+		doc.Span = &ecsID{ID: e.SpanID.String()}
+	}
+	return doc
+}
+
 // ProcessMulti will send entries to elasticsearch.
 func (e *ElasticOutput) createRequest(entries []*entry.Entry) *esapi.BulkRequest {
 	type indexDirective struct {
@@ -167,7 +219,7 @@ func (e *ElasticOutput) createRequest(entries []*entry.Entry) *esapi.BulkRequest
 			continue
 		}
 
-		entryJSON, err := json.Marshal(entry)
+		entryJSON, err := json.Marshal(newElasticDocument(entry))
 		if err != nil {
 			e.Warnw("Failed to marshal entry JSON", zap.Any("error", err))
 			continue
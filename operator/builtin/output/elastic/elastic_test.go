@@ -109,6 +109,9 @@ func TestElastic(t *testing.T) {
 	op := ops[0]
 	e := entry.New()
 	e.Record = "test"
+	e.TraceID = entry.TraceID{0x0a, 0xf7, 0x65, 0x19, 0x16, 0xcd, 0x43, 0xdd, 0x84, 0x48, 0xeb, 0x21, 0x1c, 0x80, 0x31, 0x9c}
+	e.SpanID = entry.SpanID{0xb7, 0xad, 0x6b, 0x71, 0x69, 0x20, 0x33, 0x31}
+	e.TraceFlags = entry.TraceFlags{0x01}
 
 	require.NoError(t, op.Start())
 	op.Process(context.Background(), e)
@@ -129,5 +132,11 @@ func TestElastic(t *testing.T) {
 		require.Equal(t, "default", meta["index"]["_index"])
 		require.Equal(t, float64(0), entry["severity"])
 		require.Equal(t, "test", entry["record"])
+		require.Equal(t, map[string]interface{}{"id": "0af7651916cd43dd8448eb211c80319c"}, entry["trace"])
+		require.Equal(t, map[string]interface{}{"id": "b7ad6b7169203331"}, entry["span"])
+		require.Equal(t, "01", entry["trace_flags"])
+		require.NotContains(t, entry, "trace_id")
+		require.NotContains(t, entry, "span_id")
+		require.NotContains(t, entry, "observed_timestamp")
 	}
 }
@@ -98,17 +98,26 @@ func (fo *FileOutput) Process(ctx context.Context, entry *entry.Entry) error {
 	fo.mux.Lock()
 	defer fo.mux.Unlock()
 
-	if fo.tmpl != nil {
-		err := fo.tmpl.Execute(fo.file, entry)
-		if err != nil {
-			return err
-		}
-	} else {
-		err := fo.encoder.Encode(entry)
-		if err != nil {
+	return fo.write(entry)
+}
+
+// ProcessBatch will write a batch of entries to a file
+func (fo *FileOutput) ProcessBatch(ctx context.Context, entries []*entry.Entry) error {
+	fo.mux.Lock()
+	defer fo.mux.Unlock()
+
+	for _, entry := range entries {
+		if err := fo.write(entry); err != nil {
 			return err
 		}
 	}
-
 	return nil
 }
+
+// write writes an entry to the file. The lock must be held when calling it.
+func (fo *FileOutput) write(entry *entry.Entry) error {
+	if fo.tmpl != nil {
+		return fo.tmpl.Execute(fo.file, entry)
+	}
+	return fo.encoder.Encode(entry)
+}
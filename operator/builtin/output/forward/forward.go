@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"sync"
+	"sync/atomic"
 
 	"github.com/observiq/stanza/entry"
 	"github.com/observiq/stanza/errors"
@@ -36,8 +38,22 @@ type ForwardOutputConfig struct {
 	BufferConfig        buffer.Config  `json:"buffer"  yaml:"buffer"`
 	FlusherConfig       flusher.Config `json:"flusher" yaml:"flusher"`
 	Address             string         `json:"address" yaml:"address"`
+	Codec               string         `json:"codec,omitempty" yaml:"codec,omitempty"`
 }
 
+// These are the codecs that entries can be forwarded with
+const (
+	// AutoCodec sends entries in the binary entry format, falling back to JSON if the
+	// receiver doesn't accept it
+	AutoCodec = "auto"
+
+	// BinaryCodec sends entries in the binary entry format
+	BinaryCodec = "binary"
+
+	// JSONCodec sends entries as a JSON array
+	JSONCodec = "json"
+)
+
 // Build will build an forward output operator.
 func (c ForwardOutputConfig) Build(bc operator.BuildContext) ([]operator.Operator, error) {
 	outputOperator, err := c.OutputConfig.Build(bc)
@@ -54,6 +70,15 @@ func (c ForwardOutputConfig) Build(bc operator.BuildContext) ([]operator.Operato
 		return nil, errors.NewError("missing required parameter 'address'", "")
 	}
 
+	codec := c.Codec
+	switch codec {
+	case "":
+		codec = AutoCodec
+	case AutoCodec, BinaryCodec, JSONCodec:
+	default:
+		return nil, fmt.Errorf("invalid value '%s' for 'codec'", c.Codec)
+	}
+
 	flusher := c.FlusherConfig.Build(bc.Logger.SugaredLogger)
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -66,6 +91,7 @@ func (c ForwardOutputConfig) Build(bc operator.BuildContext) ([]operator.Operato
 		cancel:         cancel,
 		client:         &http.Client{},
 		address:        c.Address,
+		codec:          codec,
 	}
 
 	return []operator.Operator{forwardOutput}, nil
@@ -79,6 +105,11 @@ type ForwardOutput struct {
 
 	client  *http.Client
 	address string
+	codec   string
+
+	// jsonFallback is set to 1 once the receiver rejects entries in the binary
+	// entry format while using the auto codec
+	jsonFallback int32
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -96,6 +127,11 @@ func (f *ForwardOutput) Start() error {
 	return nil
 }
 
+// Status returns the state of the output's flusher
+func (f *ForwardOutput) Status() map[string]interface{} {
+	return f.flusher.Status()
+}
+
 // Stop tells the ForwardOutput to stop gracefully
 func (f *ForwardOutput) Stop() error {
 	f.cancel()
@@ -109,16 +145,71 @@ func (f *ForwardOutput) Process(ctx context.Context, entry *entry.Entry) error {
 	return f.buffer.Add(ctx, entry)
 }
 
-// ProcessMulti will send entries to elasticsearch.
-func (f *ForwardOutput) createRequest(ctx context.Context, entries []*entry.Entry) (*http.Request, error) {
-	var b bytes.Buffer
-	enc := json.NewEncoder(&b)
-	err := enc.Encode(entries)
+// ProcessBatch adds a batch of entries to the outputs buffer
+func (f *ForwardOutput) ProcessBatch(ctx context.Context, entries []*entry.Entry) error {
+	return buffer.AddBatch(ctx, f.buffer, entries)
+}
+
+// requestCodec returns the codec to send the next request with
+func (f *ForwardOutput) requestCodec() string {
+	if f.codec == AutoCodec {
+		if atomic.LoadInt32(&f.jsonFallback) == 1 {
+			return JSONCodec
+		}
+		return BinaryCodec
+	}
+	return f.codec
+}
+
+// createRequest creates a request that sends entries encoded with codec
+func (f *ForwardOutput) createRequest(ctx context.Context, entries []*entry.Entry, codec string) (*http.Request, error) {
+	if codec == JSONCodec {
+		var b bytes.Buffer
+		enc := json.NewEncoder(&b)
+		err := enc.Encode(entries)
+		if err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "POST", f.address, &b)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	}
+
+	var b []byte
+	for _, e := range entries {
+		var err error
+		if b, err = e.AppendBinary(b); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", f.address, bytes.NewReader(b))
 	if err != nil {
 		return nil, err
 	}
+	req.Header.Set("Content-Type", entry.BinaryContentType)
+	return req, nil
+}
+
+// rejectedBinary returns whether a receiver that may not support the binary entry format
+// rejected a request sent with it. Receivers that predate the format respond with
+// 400 Bad Request, and receivers with an older version of it respond with 415 Unsupported
+// Media Type.
+func (f *ForwardOutput) rejectedBinary(codec string, res *http.Response) bool {
+	return f.codec == AutoCodec && codec == BinaryCodec &&
+		(res.StatusCode == http.StatusBadRequest || res.StatusCode == http.StatusUnsupportedMediaType)
+}
 
-	return http.NewRequestWithContext(ctx, "POST", f.address, &b)
+// fallBackToJSON switches the auto codec to JSON after the receiver rejected the binary entry format
+func (f *ForwardOutput) fallBackToJSON(res *http.Response) {
+	res.Body.Close()
+	if atomic.CompareAndSwapInt32(&f.jsonFallback, 0, 1) {
+		f.Warnw("Receiver rejected entries in the binary format. Falling back to JSON", "status", res.Status)
+	}
 }
 
 func (f *ForwardOutput) feedFlusher(ctx context.Context) {
@@ -132,29 +223,38 @@ func (f *ForwardOutput) feedFlusher(ctx context.Context) {
 		}
 
 		f.flusher.Do(func(ctx context.Context) error {
-			req, err := f.createRequest(ctx, entries)
-			if err != nil {
-				f.Errorf("Failed to create request", zap.Error(err))
-				// drop these logs because we couldn't creat a request and a retry won't help
-				if err := clearer.MarkAllAsFlushed(); err != nil {
-					f.Errorf("Failed to mark entries as flushed after failing to create a request", zap.Error(err))
+			for {
+				codec := f.requestCodec()
+				req, err := f.createRequest(ctx, entries, codec)
+				if err != nil {
+					f.Errorf("Failed to create request", zap.Error(err))
+					// drop these logs because we couldn't creat a request and a retry won't help
+					if err := clearer.MarkAllAsFlushed(); err != nil {
+						f.Errorf("Failed to mark entries as flushed after failing to create a request", zap.Error(err))
+					}
+					return nil
 				}
-				return nil
-			}
 
-			res, err := f.client.Do(req)
-			if err != nil {
-				return errors.Wrap(err, "send request")
-			}
+				res, err := f.client.Do(req)
+				if err != nil {
+					return errors.Wrap(err, "send request")
+				}
 
-			if err := f.handleResponse(res); err != nil {
-				return err
-			}
+				// Resend the entries as JSON if the receiver doesn't support the binary format
+				if f.rejectedBinary(codec, res) {
+					f.fallBackToJSON(res)
+					continue
+				}
+
+				if err := f.handleResponse(res); err != nil {
+					return err
+				}
 
-			if err = clearer.MarkAllAsFlushed(); err != nil {
-				f.Errorw("Failed to mark entries as flushed", zap.Error(err))
+				if err = clearer.MarkAllAsFlushed(); err != nil {
+					f.Errorw("Failed to mark entries as flushed", zap.Error(err))
+				}
+				return nil
 			}
-			return nil
 		})
 	}
 }
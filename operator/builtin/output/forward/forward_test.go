@@ -1,6 +1,8 @@
 package forward
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"io/ioutil"
@@ -30,6 +32,7 @@ func TestForwardOutput(t *testing.T) {
 		Builder: memoryCfg,
 	}
 	cfg.Address = srv.URL
+	cfg.Codec = JSONCodec
 
 	ops, err := cfg.Build(testutil.NewBuildContext(t))
 	require.NoError(t, err)
@@ -58,3 +61,101 @@ func TestForwardOutput(t *testing.T) {
 		require.Equal(t, newEntry.Resource, e.Resource)
 	}
 }
+
+func newTestForwardOutput(t *testing.T, address, codec string) *ForwardOutput {
+	cfg := NewForwardOutputConfig("test")
+	memoryCfg := buffer.NewMemoryBufferConfig()
+	memoryCfg.MaxChunkDelay = helper.NewDuration(50 * time.Millisecond)
+	cfg.BufferConfig = buffer.Config{
+		Builder: memoryCfg,
+	}
+	cfg.Address = address
+	cfg.Codec = codec
+
+	ops, err := cfg.Build(testutil.NewBuildContext(t))
+	require.NoError(t, err)
+	return ops[0].(*ForwardOutput)
+}
+
+type testRequest struct {
+	contentType string
+	body        []byte
+}
+
+func TestForwardOutputCodecs(t *testing.T) {
+	newEntry := entry.New()
+	newEntry.Record = "test"
+	newEntry.Timestamp = newEntry.Timestamp.Round(time.Second)
+
+	cases := []struct {
+		name string
+		// acceptBinary is whether the receiver accepts the binary entry format
+		acceptBinary bool
+		codec        string
+		expected     []string
+	}{
+		{"AutoBinary", true, AutoCodec, []string{entry.BinaryContentType}},
+		{"AutoFallback", false, AutoCodec, []string{entry.BinaryContentType, "application/json"}},
+		{"JSON", true, JSONCodec, []string{"application/json"}},
+		{"Binary", true, BinaryCodec, []string{entry.BinaryContentType}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			received := make(chan testRequest, 10)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				body, _ := ioutil.ReadAll(req.Body)
+				contentType := req.Header.Get("Content-Type")
+				received <- testRequest{contentType, body}
+				if contentType == entry.BinaryContentType && !tc.acceptBinary {
+					w.WriteHeader(http.StatusBadRequest)
+				}
+			}))
+			defer srv.Close()
+
+			forwardOutput := newTestForwardOutput(t, srv.URL, tc.codec)
+			require.NoError(t, forwardOutput.Start())
+			defer forwardOutput.Stop()
+			require.NoError(t, forwardOutput.Process(context.Background(), newEntry))
+
+			for _, expected := range tc.expected {
+				select {
+				case <-time.After(time.Second):
+					require.FailNow(t, "Timed out waiting for server to receive entry")
+				case req := <-received:
+					require.Equal(t, expected, req.contentType)
+					if !tc.acceptBinary && expected == entry.BinaryContentType {
+						continue
+					}
+
+					var entries []*entry.Entry
+					if expected == entry.BinaryContentType {
+						r := bufio.NewReader(bytes.NewReader(req.body))
+						e, _, err := entry.ReadBinary(r)
+						require.NoError(t, err)
+						entries = append(entries, e)
+					} else {
+						require.NoError(t, json.Unmarshal(req.body, &entries))
+					}
+					require.Len(t, entries, 1)
+					require.True(t, newEntry.Timestamp.Equal(entries[0].Timestamp))
+					require.Equal(t, newEntry.Record, entries[0].Record)
+				}
+			}
+
+			select {
+			case req := <-received:
+				require.FailNow(t, "Unexpected request", "content type %s", req.contentType)
+			case <-time.After(100 * time.Millisecond):
+			}
+		})
+	}
+}
+
+func TestForwardOutputInvalidCodec(t *testing.T) {
+	cfg := NewForwardOutputConfig("test")
+	cfg.Address = "http://localhost"
+	cfg.Codec = "xml"
+	_, err := cfg.Build(testutil.NewBuildContext(t))
+	require.Error(t, err)
+}
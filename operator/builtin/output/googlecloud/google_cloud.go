@@ -26,6 +26,9 @@ import (
 	"google.golang.org/grpc/encoding/gzip"
 )
 
+// maxPayloadBytes is the maximum size of a WriteLogEntries request accepted by Cloud Logging
+const maxPayloadBytes = 10 * 1000 * 1000
+
 func init() {
 	operator.Register("google_cloud_output", func() operator.Builder { return NewGoogleCloudOutputConfig("") })
 }
@@ -63,7 +66,7 @@ func (c GoogleCloudOutputConfig) Build(bc operator.BuildContext) ([]operator.Ope
 		return nil, err
 	}
 
-	newBuffer, err := c.BufferConfig.Build(bc, c.ID())
+	newBuffer, err := c.BufferConfig.BuildWithPayloadLimit(bc, c.ID(), maxPayloadBytes)
 	if err != nil {
 		return nil, err
 	}
@@ -185,6 +188,11 @@ func (g *GoogleCloudOutput) startFlushing() {
 	}()
 }
 
+// Status returns the state of the output's flusher
+func (g *GoogleCloudOutput) Status() map[string]interface{} {
+	return g.flusher.Status()
+}
+
 // Stop will flush the google cloud logger and close the underlying connection
 func (g *GoogleCloudOutput) Stop() error {
 	g.cancel()
@@ -201,6 +209,11 @@ func (g *GoogleCloudOutput) Process(ctx context.Context, e *entry.Entry) error {
 	return g.buffer.Add(ctx, e)
 }
 
+// ProcessBatch processes a batch of entries
+func (g *GoogleCloudOutput) ProcessBatch(ctx context.Context, entries []*entry.Entry) error {
+	return buffer.AddBatch(ctx, g.buffer, entries)
+}
+
 // testConnection will attempt to send a test entry to google cloud logging
 func (g *GoogleCloudOutput) testConnection(ctx context.Context) error {
 	testEntry := entry.New()
@@ -267,6 +280,22 @@ func (g *GoogleCloudOutput) toLogNamePath(logName string) string {
 	return fmt.Sprintf("projects/%s/logs/%s", g.projectID, url.PathEscape(logName))
 }
 
+// observedTimestampLabel is the label holding the observed timestamp of an entry
+const observedTimestampLabel = "observed_timestamp"
+
+// stringLabels converts the labels of an entry to strings, since Cloud Logging only supports string labels
+func stringLabels(labels map[string]interface{}) map[string]string {
+	if labels == nil {
+		return nil
+	}
+
+	converted := make(map[string]string, len(labels))
+	for k, v := range labels {
+		converted[k] = entry.AttributeString(v)
+	}
+	return converted
+}
+
 func (g *GoogleCloudOutput) createProtobufEntry(e *entry.Entry) (newEntry *logpb.LogEntry, err error) {
 	ts, err := ptypes.TimestampProto(e.Timestamp)
 	if err != nil {
@@ -275,7 +304,17 @@ func (g *GoogleCloudOutput) createProtobufEntry(e *entry.Entry) (newEntry *logpb
 
 	newEntry = &logpb.LogEntry{
 		Timestamp: ts,
-		Labels:    e.Labels,
+		Labels:    stringLabels(e.Labels),
+	}
+
+	// Cloud Logging has no field for the time a log was observed, so it is sent as a label
+	if !e.ObservedTimestamp.IsZero() {
+		if _, ok := newEntry.Labels[observedTimestampLabel]; !ok {
+			if newEntry.Labels == nil {
+				newEntry.Labels = make(map[string]string, 1)
+			}
+			newEntry.Labels[observedTimestampLabel] = e.ObservedTimestamp.UTC().Format(time.RFC3339Nano)
+		}
 	}
 
 	if g.logNameField != nil {
@@ -307,6 +346,15 @@ func (g *GoogleCloudOutput) createProtobufEntry(e *entry.Entry) (newEntry *logpb
 		}
 	}
 
+	// The trace context of the entry is used unless it was set from the configured fields
+	if newEntry.Trace == "" && len(e.TraceID) > 0 {
+		newEntry.Trace = fmt.Sprintf("projects/%s/traces/%s", g.projectID, e.TraceID)
+	}
+	if newEntry.SpanId == "" && len(e.SpanID) > 0 {
+		newEntry.SpanId = e.SpanID.String()
+	}
+	newEntry.TraceSampled = e.TraceFlags.Sampled()
+
 	newEntry.Severity = convertSeverity(e.Severity)
 	err = setPayload(newEntry, e.Record)
 	if err != nil {
@@ -121,8 +121,10 @@ func TestGoogleCloudOutput(t *testing.T) {
 			}(),
 			&entry.Entry{
 				Timestamp: now,
-				Labels: map[string]string{
+				Labels: map[string]interface{}{
 					"label1": "value1",
+					"label2": 200,
+					"label3": []interface{}{"a", "b"},
 				},
 				Record: map[string]interface{}{
 					"message": "test message",
@@ -134,6 +136,66 @@ func TestGoogleCloudOutput(t *testing.T) {
 					{
 						Labels: map[string]string{
 							"label1": "value1",
+							"label2": "200",
+							"label3": `["a","b"]`,
+						},
+						Timestamp: protoTs,
+						Payload: &logpb.LogEntry_JsonPayload{JsonPayload: jsonMapToProtoStruct(map[string]interface{}{
+							"message": "test message",
+						})},
+					},
+				}
+				return req
+			}(),
+		},
+		{
+			"TraceContext",
+			func() *GoogleCloudOutputConfig {
+				return googleCloudBasicConfig()
+			}(),
+			&entry.Entry{
+				Timestamp:  now,
+				TraceID:    entry.TraceID{0x0a, 0xf7, 0x65, 0x19, 0x16, 0xcd, 0x43, 0xdd, 0x84, 0x48, 0xeb, 0x21, 0x1c, 0x80, 0x31, 0x9c},
+				SpanID:     entry.SpanID{0xb7, 0xad, 0x6b, 0x71, 0x69, 0x20, 0x33, 0x31},
+				TraceFlags: entry.TraceFlags{0x01},
+				Record: map[string]interface{}{
+					"message": "test message",
+				},
+			},
+			func() *logpb.WriteLogEntriesRequest {
+				req := googleCloudBasicWriteEntriesRequest()
+				req.Entries = []*logpb.LogEntry{
+					{
+						Trace:        "projects/test_project_id/traces/0af7651916cd43dd8448eb211c80319c",
+						SpanId:       "b7ad6b7169203331",
+						TraceSampled: true,
+						Timestamp:    protoTs,
+						Payload: &logpb.LogEntry_JsonPayload{JsonPayload: jsonMapToProtoStruct(map[string]interface{}{
+							"message": "test message",
+						})},
+					},
+				}
+				return req
+			}(),
+		},
+		{
+			"ObservedTimestamp",
+			func() *GoogleCloudOutputConfig {
+				return googleCloudBasicConfig()
+			}(),
+			&entry.Entry{
+				Timestamp:         now,
+				ObservedTimestamp: time.Unix(1591042865, 123),
+				Record: map[string]interface{}{
+					"message": "test message",
+				},
+			},
+			func() *logpb.WriteLogEntriesRequest {
+				req := googleCloudBasicWriteEntriesRequest()
+				req.Entries = []*logpb.LogEntry{
+					{
+						Labels: map[string]string{
+							"observed_timestamp": "2020-06-01T20:21:05.000000123Z",
 						},
 						Timestamp: protoTs,
 						Payload: &logpb.LogEntry_JsonPayload{JsonPayload: jsonMapToProtoStruct(map[string]interface{}{
@@ -471,7 +533,7 @@ func BenchmarkGoogleCloudOutput(b *testing.B) {
 			&entry.Entry{
 				Timestamp: t,
 				Record:    "test",
-				Labels: map[string]string{
+				Labels: map[string]interface{}{
 					"test": "val",
 				},
 			},
@@ -57,13 +57,22 @@ func hasResource(key string, e *entry.Entry) bool {
 	return ok
 }
 
+// getResourceValue returns a resource value of the entry as a string, or an empty string if it is missing
+func getResourceValue(key string, e *entry.Entry) string {
+	val, ok := e.Resource[key]
+	if !ok {
+		return ""
+	}
+	return entry.AttributeString(val)
+}
+
 func k8sPodResource(e *entry.Entry) *mrpb.MonitoredResource {
 	return &mrpb.MonitoredResource{
 		Type: "k8s_pod",
 		Labels: map[string]string{
-			"pod_name":       e.Resource["k8s.pod.name"],
-			"namespace_name": e.Resource["k8s.namespace.name"],
-			"cluster_name":   e.Resource["k8s.cluster.name"],
+			"pod_name":       getResourceValue("k8s.pod.name", e),
+			"namespace_name": getResourceValue("k8s.namespace.name", e),
+			"cluster_name":   getResourceValue("k8s.cluster.name", e),
 			// TODO project id
 		},
 	}
@@ -73,10 +82,10 @@ func k8sContainerResource(e *entry.Entry) *mrpb.MonitoredResource {
 	return &mrpb.MonitoredResource{
 		Type: "k8s_container",
 		Labels: map[string]string{
-			"container_name": e.Resource["container.name"],
-			"pod_name":       e.Resource["k8s.pod.name"],
-			"namespace_name": e.Resource["k8s.namespace.name"],
-			"cluster_name":   e.Resource["k8s.cluster.name"],
+			"container_name": getResourceValue("container.name", e),
+			"pod_name":       getResourceValue("k8s.pod.name", e),
+			"namespace_name": getResourceValue("k8s.namespace.name", e),
+			"cluster_name":   getResourceValue("k8s.cluster.name", e),
 			// TODO project id
 		},
 	}
@@ -86,8 +95,8 @@ func k8sNodeResource(e *entry.Entry) *mrpb.MonitoredResource {
 	return &mrpb.MonitoredResource{
 		Type: "k8s_node",
 		Labels: map[string]string{
-			"cluster_name": e.Resource["k8s.cluster.name"],
-			"node_name":    e.Resource["host.name"],
+			"cluster_name": getResourceValue("k8s.cluster.name", e),
+			"node_name":    getResourceValue("host.name", e),
 			// TODO project id
 		},
 	}
@@ -97,7 +106,7 @@ func k8sClusterResource(e *entry.Entry) *mrpb.MonitoredResource {
 	return &mrpb.MonitoredResource{
 		Type: "k8s_cluster",
 		Labels: map[string]string{
-			"cluster_name": e.Resource["k8s.cluster.name"],
+			"cluster_name": getResourceValue("k8s.cluster.name", e),
 			// TODO project id
 		},
 	}
@@ -107,7 +116,7 @@ func genericNodeResource(e *entry.Entry) *mrpb.MonitoredResource {
 	return &mrpb.MonitoredResource{
 		Type: "generic_node",
 		Labels: map[string]string{
-			"node_id": e.Resource["host.name"],
+			"node_id": getResourceValue("host.name", e),
 			// TODO project id
 		},
 	}
@@ -21,6 +21,9 @@ import (
 	"go.uber.org/zap"
 )
 
+// maxPayloadBytes is the maximum size of a payload accepted by the New Relic Log API
+const maxPayloadBytes = 1000 * 1000
+
 func init() {
 	operator.Register("newrelic_output", func() operator.Builder { return NewNewRelicOutputConfig("") })
 }
@@ -62,7 +65,7 @@ func (c NewRelicOutputConfig) Build(bc operator.BuildContext) ([]operator.Operat
 		return nil, err
 	}
 
-	buffer, err := c.BufferConfig.Build(bc, c.ID())
+	buffer, err := c.BufferConfig.BuildWithPayloadLimit(bc, c.ID(), maxPayloadBytes)
 	if err != nil {
 		return nil, err
 	}
@@ -140,6 +143,11 @@ func (nro *NewRelicOutput) Start() error {
 	return nil
 }
 
+// Status returns the state of the output's flusher
+func (nro *NewRelicOutput) Status() map[string]interface{} {
+	return nro.flusher.Status()
+}
+
 // Stop tells the NewRelicOutput to stop gracefully
 func (nro *NewRelicOutput) Stop() error {
 	nro.cancel()
@@ -153,6 +161,11 @@ func (nro *NewRelicOutput) Process(ctx context.Context, entry *entry.Entry) erro
 	return nro.buffer.Add(ctx, entry)
 }
 
+// ProcessBatch adds a batch of entries to the output's buffer
+func (nro *NewRelicOutput) ProcessBatch(ctx context.Context, entries []*entry.Entry) error {
+	return buffer.AddBatch(ctx, nro.buffer, entries)
+}
+
 func (nro *NewRelicOutput) testConnection() error {
 	ctx, cancel := context.WithTimeout(context.Background(), nro.timeout)
 	defer cancel()
@@ -9,6 +9,10 @@ import (
 	"go.opentelemetry.io/collector/consumer/pdata"
 )
 
+// observedTimeAttribute is the log attribute holding the observed timestamp of an entry,
+// since this version of the OTLP log data model has no field for it
+const observedTimeAttribute = "observed_time_unix_nano"
+
 // Convert converts a slice of entries to pdata.Logs format
 func Convert(entries []*entry.Entry) pdata.Logs {
 
@@ -26,7 +30,7 @@ func Convert(entries []*entry.Entry) pdata.Logs {
 
 		resourceAtts := resource.Attributes()
 		for k, v := range resourceEntries[0].Resource {
-			resourceAtts.InsertString(k, v)
+			resourceAtts.Insert(k, toAttributeValue(v))
 		}
 
 		rls.InstrumentationLibraryLogs().Resize(1)
@@ -46,13 +50,28 @@ func Convert(entries []*entry.Entry) pdata.Logs {
 			lr.SetSeverityNumber(convertSeverity(entry.Severity))
 			lr.SetSeverityText(entry.SeverityText)
 
+			if len(entry.TraceID) > 0 {
+				lr.SetTraceID(pdata.NewTraceID(entry.TraceID))
+			}
+			if len(entry.SpanID) > 0 {
+				lr.SetSpanID(pdata.NewSpanID(entry.SpanID))
+			}
+			if len(entry.TraceFlags) > 0 {
+				lr.SetFlags(uint32(entry.TraceFlags[0]))
+			}
+
 			if len(entry.Labels) > 0 {
 				attributes := lr.Attributes()
 				for k, v := range entry.Labels {
-					attributes.InsertString(k, v)
+					attributes.Insert(k, toAttributeValue(v))
 				}
 			}
 
+			if !entry.ObservedTimestamp.IsZero() {
+				// Insert does not replace a label of the same name
+				lr.Attributes().Insert(observedTimeAttribute, pdata.NewAttributeValueInt(entry.ObservedTimestamp.UnixNano()))
+			}
+
 			lr.Body().InitEmpty()
 			insertToAttributeVal(entry.Record, lr.Body())
 
@@ -89,6 +108,12 @@ func groupByResource(entries []*entry.Entry) [][]*entry.Entry {
 	return entriesByResource
 }
 
+func toAttributeValue(value interface{}) pdata.AttributeValue {
+	attVal := pdata.NewAttributeValue()
+	insertToAttributeVal(value, attVal)
+	return attVal
+}
+
 func insertToAttributeVal(value interface{}, dest pdata.AttributeValue) {
 	switch t := value.(type) {
 	case bool:
@@ -94,6 +94,68 @@ func TestConvertMetadata(t *testing.T) {
 	require.True(t, bod.BoolVal())
 }
 
+func TestConvertTypedAttributes(t *testing.T) {
+	e := entry.New()
+	e.AddResourceKey("k8s.pod.uid", []interface{}{"a", "b"})
+	e.AddLabel("http.status_code", 200)
+	e.AddLabel("retried", true)
+	e.AddLabel("duration", 1.5)
+
+	result := Convert([]*entry.Entry{e})
+
+	resourceAtts := result.ResourceLogs().At(0).Resource().Attributes()
+	uids, ok := resourceAtts.Get("k8s.pod.uid")
+	require.True(t, ok)
+	require.Equal(t, pdata.AttributeValueARRAY, uids.Type())
+	require.Equal(t, 2, uids.ArrayVal().Len())
+	require.Equal(t, "b", uids.ArrayVal().At(1).StringVal())
+
+	atts := result.ResourceLogs().At(0).InstrumentationLibraryLogs().At(0).Logs().At(0).Attributes()
+	statusCode, ok := atts.Get("http.status_code")
+	require.True(t, ok)
+	require.Equal(t, int64(200), statusCode.IntVal())
+
+	retried, ok := atts.Get("retried")
+	require.True(t, ok)
+	require.True(t, retried.BoolVal())
+
+	duration, ok := atts.Get("duration")
+	require.True(t, ok)
+	require.Equal(t, 1.5, duration.DoubleVal())
+}
+
+func TestConvertTraceContext(t *testing.T) {
+	e := entry.New()
+	e.TraceID = entry.TraceID{0x0a, 0xf7, 0x65, 0x19, 0x16, 0xcd, 0x43, 0xdd, 0x84, 0x48, 0xeb, 0x21, 0x1c, 0x80, 0x31, 0x9c}
+	e.SpanID = entry.SpanID{0xb7, 0xad, 0x6b, 0x71, 0x69, 0x20, 0x33, 0x31}
+	e.TraceFlags = entry.TraceFlags{0x01}
+
+	result := Convert([]*entry.Entry{e})
+
+	log := result.ResourceLogs().At(0).InstrumentationLibraryLogs().At(0).Logs().At(0)
+	require.Equal(t, "0af7651916cd43dd8448eb211c80319c", log.TraceID().HexString())
+	require.Equal(t, "b7ad6b7169203331", log.SpanID().HexString())
+	require.Equal(t, uint32(1), log.Flags())
+}
+
+func TestConvertObservedTimestamp(t *testing.T) {
+	observed := time.Unix(1591042865, 123)
+
+	e := entry.New()
+	e.ObservedTimestamp = observed
+	result := Convert([]*entry.Entry{e})
+
+	log := result.ResourceLogs().At(0).InstrumentationLibraryLogs().At(0).Logs().At(0)
+	value, ok := log.Attributes().Get("observed_time_unix_nano")
+	require.True(t, ok)
+	require.Equal(t, observed.UnixNano(), value.IntVal())
+
+	result = Convert([]*entry.Entry{entry.New()})
+	log = result.ResourceLogs().At(0).InstrumentationLibraryLogs().At(0).Logs().At(0)
+	_, ok = log.Attributes().Get("observed_time_unix_nano")
+	require.False(t, ok)
+}
+
 func TestConvertSimpleBody(t *testing.T) {
 
 	require.True(t, recordToBody(true).BoolVal())
@@ -18,6 +18,10 @@ import (
 	"go.uber.org/zap"
 )
 
+// maxPayloadBytes is the default maximum size of a message accepted by the
+// OpenTelemetry Collector's OTLP receiver
+const maxPayloadBytes = 4 << 20
+
 func init() {
 	operator.Register("otlp_output", func() operator.Builder { return NewOTLPOutputConfig("") })
 }
@@ -48,7 +52,7 @@ func (c OTLPOutputConfig) Build(bc operator.BuildContext) ([]operator.Operator,
 		return nil, err
 	}
 
-	buffer, err := c.BufferConfig.Build(bc, c.ID())
+	buffer, err := c.BufferConfig.BuildWithPayloadLimit(bc, c.ID(), maxPayloadBytes)
 	if err != nil {
 		return nil, err
 	}
@@ -108,6 +112,11 @@ func (o *OTLPOutput) Start() error {
 	return nil
 }
 
+// Status returns the state of the output's flusher
+func (o *OTLPOutput) Status() map[string]interface{} {
+	return o.flusher.Status()
+}
+
 // Stop tells the OTLPOutput to stop gracefully
 func (o *OTLPOutput) Stop() error {
 	o.cancel()
@@ -159,6 +168,11 @@ func (o *OTLPOutput) Process(ctx context.Context, entry *entry.Entry) error {
 	return o.buffer.Add(ctx, entry)
 }
 
+// ProcessBatch adds a batch of entries to the output's buffer
+func (o *OTLPOutput) ProcessBatch(ctx context.Context, entries []*entry.Entry) error {
+	return buffer.AddBatch(ctx, o.buffer, entries)
+}
+
 // ProcessMulti will send a chunk of entries
 func (o *OTLPOutput) createRequest(ctx context.Context, entries []*entry.Entry) (*http.Request, error) {
 	logs := Convert(entries)
@@ -58,9 +58,23 @@ func (o *StdoutOperator) Process(ctx context.Context, entry *entry.Entry) error
 	err := o.encoder.Encode(entry)
 	if err != nil {
 		o.mux.Unlock()
-		o.Errorf("Failed to process entry: %s, $s", err, entry.Record)
+		o.Errorf("Failed to process entry: %s, %v", err, entry.Record)
 		return err
 	}
 	o.mux.Unlock()
 	return nil
 }
+
+// ProcessBatch will log a batch of entries to stdout
+func (o *StdoutOperator) ProcessBatch(ctx context.Context, entries []*entry.Entry) error {
+	o.mux.Lock()
+	defer o.mux.Unlock()
+
+	for _, entry := range entries {
+		if err := o.encoder.Encode(entry); err != nil {
+			o.Errorf("Failed to process entry: %s, %v", err, entry.Record)
+			return err
+		}
+	}
+	return nil
+}
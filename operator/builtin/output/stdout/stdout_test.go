@@ -31,9 +31,11 @@ func TestStdoutOperator(t *testing.T) {
 	op.(*StdoutOperator).encoder = json.NewEncoder(&buf)
 
 	ts := time.Unix(1591042864, 0)
+	observed := time.Unix(1591042865, 0)
 	e := &entry.Entry{
-		Timestamp: ts,
-		Record:    "test record",
+		Timestamp:         ts,
+		ObservedTimestamp: observed,
+		Record:            "test record",
 	}
 	err = op.Process(context.Background(), e)
 	require.NoError(t, err)
@@ -41,6 +43,9 @@ func TestStdoutOperator(t *testing.T) {
 	marshalledTimestamp, err := json.Marshal(ts)
 	require.NoError(t, err)
 
-	expected := `{"timestamp":` + string(marshalledTimestamp) + `,"severity":0,"record":"test record"}` + "\n"
+	marshalledObserved, err := json.Marshal(observed)
+	require.NoError(t, err)
+
+	expected := `{"timestamp":` + string(marshalledTimestamp) + `,"observed_timestamp":` + string(marshalledObserved) + `,"severity":0,"record":"test record"}` + "\n"
 	require.Equal(t, expected, buf.String())
 }
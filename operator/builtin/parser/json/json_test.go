@@ -8,6 +8,7 @@ import (
 	jsoniter "github.com/json-iterator/go"
 	"github.com/observiq/stanza/entry"
 	"github.com/observiq/stanza/operator"
+	"github.com/observiq/stanza/operator/builtin/output/drop"
 	"github.com/observiq/stanza/operator/helper"
 	"github.com/observiq/stanza/testutil"
 
@@ -217,3 +218,57 @@ func TestJSONParserWithEmbeddedTimeParser(t *testing.T) {
 		})
 	}
 }
+
+func BenchmarkJSONParser(b *testing.B) {
+	newBatch := func() []*entry.Entry {
+		entries := make([]*entry.Entry, 100)
+		for i := range entries {
+			entries[i] = entry.New()
+			entries[i].Record = `{"message":"test message","severity":"info","count":10}`
+		}
+		return entries
+	}
+
+	newParser := func(b *testing.B) *JSONParser {
+		cfg := NewJSONParserConfig("test")
+		ops, err := cfg.Build(testutil.NewBuildContext(b))
+		require.NoError(b, err)
+		parser := ops[0].(*JSONParser)
+
+		dropCfg := drop.NewDropOutputConfig("drop")
+		dropOps, err := dropCfg.Build(testutil.NewBuildContext(b))
+		require.NoError(b, err)
+		parser.OutputOperators = []operator.Operator{dropOps[0]}
+		return parser
+	}
+
+	b.Run("Process", func(b *testing.B) {
+		parser := newParser(b)
+		ctx := context.Background()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			entries := newBatch()
+			b.StartTimer()
+			for _, e := range entries {
+				if err := parser.Process(ctx, e); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+
+	b.Run("ProcessBatch", func(b *testing.B) {
+		parser := newParser(b)
+		ctx := context.Background()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			entries := newBatch()
+			b.StartTimer()
+			if err := parser.ProcessBatch(ctx, entries); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -77,6 +77,11 @@ func (r *RegexParser) Process(ctx context.Context, entry *entry.Entry) error {
 	return r.ParserOperator.ProcessWith(ctx, entry, r.parse)
 }
 
+// ProcessBatch will parse a batch of entries for regex.
+func (r *RegexParser) ProcessBatch(ctx context.Context, entries []*entry.Entry) error {
+	return r.ParserOperator.ProcessBatchWith(ctx, entries, r.parse)
+}
+
 // parse will parse a value using the supplied regex.
 func (r *RegexParser) parse(value interface{}) (interface{}, error) {
 	var matches []string
@@ -61,3 +61,9 @@ func (t *TimeParserOperator) Process(ctx context.Context, entry *entry.Entry) er
 	t.ProcessWith(ctx, entry, t.TimeParser.Parse)
 	return nil
 }
+
+// ProcessBatch will parse the time of a batch of entries.
+func (t *TimeParserOperator) ProcessBatch(ctx context.Context, entries []*entry.Entry) error {
+	_ = t.ProcessBatchWith(ctx, entries, t.TimeParser.Parse)
+	return nil
+}
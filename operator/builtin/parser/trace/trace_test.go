@@ -0,0 +1,83 @@
+package trace
+
+import (
+	"context"
+	"testing"
+
+	"github.com/observiq/stanza/entry"
+	"github.com/observiq/stanza/operator"
+	"github.com/observiq/stanza/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTraceParserBuildMissingParseFrom(t *testing.T) {
+	cfg := NewTraceParserConfig("test")
+	_, err := cfg.Build(testutil.NewBuildContext(t))
+	require.Error(t, err)
+}
+
+func TestTraceParser(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    interface{}
+		traceID  string
+		spanID   string
+		sampled  bool
+		expected interface{}
+	}{
+		{
+			"String",
+			"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+			"0af7651916cd43dd8448eb211c80319c",
+			"b7ad6b7169203331",
+			true,
+			map[string]interface{}{},
+		},
+		{
+			"Bytes",
+			[]byte("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-00"),
+			"0af7651916cd43dd8448eb211c80319c",
+			"b7ad6b7169203331",
+			false,
+			map[string]interface{}{},
+		},
+		{
+			"InvalidIsSentUnchanged",
+			"not a traceparent",
+			"",
+			"",
+			false,
+			map[string]interface{}{"traceparent": "not a traceparent"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := NewTraceParserConfig("test")
+			cfg.OutputIDs = []string{"fake"}
+			parseFrom := entry.NewRecordField("traceparent")
+			cfg.ParseFrom = &parseFrom
+
+			ops, err := cfg.Build(testutil.NewBuildContext(t))
+			require.NoError(t, err)
+			op := ops[0]
+
+			fake := testutil.NewFakeOutput(t)
+			require.NoError(t, op.SetOutputs([]operator.Operator{fake}))
+
+			e := entry.New()
+			e.Record = map[string]interface{}{"traceparent": tc.input}
+			require.NoError(t, op.Process(context.Background(), e))
+
+			select {
+			case result := <-fake.Received:
+				require.Equal(t, tc.traceID, result.TraceID.String())
+				require.Equal(t, tc.spanID, result.SpanID.String())
+				require.Equal(t, tc.sampled, result.TraceFlags.Sampled())
+				require.Equal(t, tc.expected, result.Record)
+			default:
+				require.FailNow(t, "expected entry to be written")
+			}
+		})
+	}
+}
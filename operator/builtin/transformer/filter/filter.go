@@ -69,21 +69,43 @@ func (f *FilterOperator) Process(ctx context.Context, entry *entry.Entry) error
 	env := helper.GetExprEnv(entry)
 	defer helper.PutExprEnv(env)
 
+	if f.keep(env) {
+		f.Write(ctx, entry)
+	}
+
+	return nil
+}
+
+// ProcessBatch will drop the entries of a batch that match the filter expression
+func (f *FilterOperator) ProcessBatch(ctx context.Context, entries []*entry.Entry) error {
+	env := helper.GetExprEnv(nil)
+	defer helper.PutExprEnv(env)
+
+	kept := make([]*entry.Entry, 0, len(entries))
+	for _, entry := range entries {
+		helper.SetExprEnv(env, entry)
+		if f.keep(env) {
+			kept = append(kept, entry)
+		}
+	}
+
+	f.WriteBatch(ctx, kept)
+	return nil
+}
+
+// keep returns whether the entry in env should be sent on
+func (f *FilterOperator) keep(env map[string]interface{}) bool {
 	matches, err := vm.Run(f.expression, env)
 	if err != nil {
 		f.Errorf("Running expressing returned an error", zap.Error(err))
-		return nil
+		return false
 	}
 
 	filtered, ok := matches.(bool)
 	if !ok {
 		f.Errorf("Expression did not compile as a boolean")
-		return nil
+		return false
 	}
 
-	if !filtered || rand.Float64() > f.dropRatio {
-		f.Write(ctx, entry)
-	}
-
-	return nil
+	return !filtered || rand.Float64() > f.dropRatio
 }
@@ -49,7 +49,7 @@ func TestFilterOperator(t *testing.T) {
 				Record: map[string]interface{}{
 					"message": "test_message",
 				},
-				Labels: map[string]string{
+				Labels: map[string]interface{}{
 					"key": "value",
 				},
 			},
@@ -155,3 +155,34 @@ func TestFilterDropRatio(t *testing.T) {
 
 	require.Equal(t, 10, processedEntries)
 }
+
+func TestFilterProcessBatch(t *testing.T) {
+	cfg := NewFilterOperatorConfig("test")
+	cfg.Expression = `$.message == "drop"`
+	ops, err := cfg.Build(testutil.NewBuildContext(t))
+	require.NoError(t, err)
+	filterOperator := ops[0].(*FilterOperator)
+
+	var received []interface{}
+	mockOutput := testutil.NewMockOperator("output")
+	mockOutput.On("Process", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		received = append(received, args[1].(*entry.Entry).Record)
+	})
+	filterOperator.OutputOperators = []operator.Operator{mockOutput}
+
+	entries := []*entry.Entry{}
+	for _, message := range []string{"keep1", "drop", "keep2", "drop"} {
+		e := entry.New()
+		e.Record = map[string]interface{}{"message": message}
+		entries = append(entries, e)
+	}
+
+	err = filterOperator.ProcessBatch(context.Background(), entries)
+	require.NoError(t, err)
+
+	expected := []interface{}{
+		map[string]interface{}{"message": "keep1"},
+		map[string]interface{}{"message": "keep2"},
+	}
+	require.Equal(t, expected, received)
+}
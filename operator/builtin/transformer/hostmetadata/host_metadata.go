@@ -58,6 +58,11 @@ func (h *HostMetadata) Process(ctx context.Context, entry *entry.Entry) error {
 	return h.ProcessWith(ctx, entry, h.Transform)
 }
 
+// ProcessBatch will process a batch of entries.
+func (h *HostMetadata) ProcessBatch(ctx context.Context, entries []*entry.Entry) error {
+	return h.ProcessBatchWith(ctx, entries, h.Transform)
+}
+
 // Transform will transform an entry, adding the configured host metadata.
 func (h *HostMetadata) Transform(entry *entry.Entry) error {
 	h.HostIdentifier.Identify(entry)
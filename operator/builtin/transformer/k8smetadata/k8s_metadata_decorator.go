@@ -306,45 +306,37 @@ func (k *K8sMetadataDecorator) refreshPodMetadata(ctx context.Context, namespace
 }
 
 func (k *K8sMetadataDecorator) decorateEntryWithNamespaceMetadata(nsMeta MetadataCacheEntry, entry *entry.Entry) {
-	if entry.Labels == nil {
-		entry.Labels = make(map[string]string)
-	}
-
 	for k, v := range nsMeta.Annotations {
-		entry.Labels["k8s-ns-annotation/"+k] = v
+		entry.AddLabel("k8s-ns-annotation/"+k, v)
 	}
 
 	for k, v := range nsMeta.Labels {
-		entry.Labels["k8s-ns/"+k] = v
+		entry.AddLabel("k8s-ns/"+k, v)
 	}
 
-	entry.Resource["k8s.namespace.uid"] = nsMeta.UID
+	entry.AddResourceKey("k8s.namespace.uid", nsMeta.UID)
 	if nsMeta.ClusterName != "" {
-		entry.Resource["k8s.cluster.name"] = nsMeta.ClusterName
+		entry.AddResourceKey("k8s.cluster.name", nsMeta.ClusterName)
 	}
 }
 
 func (k *K8sMetadataDecorator) decorateEntryWithPodMetadata(podMeta MetadataCacheEntry, entry *entry.Entry) {
-	if entry.Labels == nil {
-		entry.Labels = make(map[string]string)
-	}
-
 	for k, v := range podMeta.Annotations {
-		entry.Labels["k8s-pod-annotation/"+k] = v
+		entry.AddLabel("k8s-pod-annotation/"+k, v)
 	}
 
 	for k, v := range podMeta.Labels {
-		entry.Labels["k8s-pod/"+k] = v
+		entry.AddLabel("k8s-pod/"+k, v)
 	}
 
-	entry.Resource["k8s.pod.uid"] = podMeta.UID
+	entry.AddResourceKey("k8s.pod.uid", podMeta.UID)
 	if podMeta.ClusterName != "" {
-		entry.Resource["k8s.cluster.name"] = podMeta.ClusterName
+		entry.AddResourceKey("k8s.cluster.name", podMeta.ClusterName)
 	}
 
 	for key, value := range podMeta.AdditionalResourceValues {
 		if value != "" {
-			entry.Resource[key] = value
+			entry.AddResourceKey(key, value)
 		}
 	}
 }
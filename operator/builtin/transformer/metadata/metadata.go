@@ -67,6 +67,11 @@ func (p *MetadataOperator) Process(ctx context.Context, entry *entry.Entry) erro
 	return p.ProcessWith(ctx, entry, p.Transform)
 }
 
+// ProcessBatch will process a batch of entries using the metadata transform.
+func (p *MetadataOperator) ProcessBatch(ctx context.Context, entries []*entry.Entry) error {
+	return p.ProcessBatchWith(ctx, entries, p.Transform)
+}
+
 // Transform will transform an entry using the labeler and tagger.
 func (p *MetadataOperator) Transform(entry *entry.Entry) error {
 	if err := p.Label(entry); err != nil {
@@ -48,3 +48,9 @@ func (p *NoopOperator) Process(ctx context.Context, entry *entry.Entry) error {
 	p.Write(ctx, entry)
 	return nil
 }
+
+// ProcessBatch will forward a batch of entries to the next output without any alterations.
+func (p *NoopOperator) ProcessBatch(ctx context.Context, entries []*entry.Entry) error {
+	p.WriteBatch(ctx, entries)
+	return nil
+}
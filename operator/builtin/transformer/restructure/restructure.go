@@ -57,6 +57,11 @@ func (p *RestructureOperator) Process(ctx context.Context, entry *entry.Entry) e
 	return p.ProcessWith(ctx, entry, p.Transform)
 }
 
+// ProcessBatch will process a batch of entries with a restructure transformation.
+func (p *RestructureOperator) ProcessBatch(ctx context.Context, entries []*entry.Entry) error {
+	return p.ProcessBatchWith(ctx, entries, p.Transform)
+}
+
 // Transform will apply the restructure operations to an entry
 func (p *RestructureOperator) Transform(entry *entry.Entry) error {
 	for _, op := range p.ops {
@@ -346,6 +351,7 @@ type OpRetain struct {
 func (op *OpRetain) Apply(e *entry.Entry) error {
 	newEntry := entry.New()
 	newEntry.Timestamp = e.Timestamp
+	newEntry.ObservedTimestamp = e.ObservedTimestamp
 	for _, field := range op.Fields {
 		val, ok := e.Get(field)
 		if !ok {
@@ -397,6 +403,13 @@ type OpMove struct {
 
 // Apply will perform the move operation on an entry
 func (op *OpMove) Apply(e *entry.Entry) error {
+	from, ok := op.From.FieldInterface.(entry.RecordField)
+	if ok && from.Wildcards() > 0 {
+		if to, ok := op.To.FieldInterface.(entry.RecordField); ok && to.Wildcards() > 0 {
+			return op.applyEach(e, from, to)
+		}
+	}
+
 	val, ok := e.Delete(op.From)
 	if !ok {
 		return fmt.Errorf("apply move: field %s does not exist on record", op.From)
@@ -405,6 +418,42 @@ func (op *OpMove) Apply(e *entry.Entry) error {
 	return e.Set(op.To, val)
 }
 
+// applyEach moves every value matched by from to the field made by replacing the
+// wildcards of to with the indexes and keys that they matched
+func (op *OpMove) applyEach(e *entry.Entry, from, to entry.RecordField) error {
+	if from.Wildcards() != to.Wildcards() {
+		return fmt.Errorf("apply move: fields %s and %s must have the same number of wildcards", op.From, op.To)
+	}
+
+	matches := from.Expand(e)
+	if len(matches) == 0 {
+		return fmt.Errorf("apply move: field %s does not exist on record", op.From)
+	}
+
+	destinations := make([]entry.RecordField, len(matches))
+	values := make([]interface{}, len(matches))
+	for i, match := range matches {
+		destination, err := to.Fill(from, match)
+		if err != nil {
+			return fmt.Errorf("apply move: %s", err)
+		}
+		destinations[i] = destination
+		values[i], _ = match.Get(e)
+	}
+
+	// Delete in reverse so that removing array elements doesn't shift the later matches
+	for i := len(matches) - 1; i >= 0; i-- {
+		matches[i].Delete(e)
+	}
+
+	for i, destination := range destinations {
+		if err := destination.Set(e, values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Type will return the type of operation
 func (op *OpMove) Type() string {
 	return "move"
@@ -35,6 +35,12 @@ func NewFakeRestructureOperator() (*RestructureOperator, *testutil.Operator) {
 	}, &mock
 }
 
+func newField(t *testing.T, s string) entry.Field {
+	var field entry.Field
+	require.NoError(t, json.Unmarshal([]byte(`"`+s+`"`), &field))
+	return field
+}
+
 func TestRestructureOperator(t *testing.T) {
 	os.Setenv("TEST_RESTRUCTURE_PLUGIN_ENV", "foo")
 	defer os.Unsetenv("TEST_RESTRUCTURE_PLUGIN_ENV")
@@ -51,6 +57,18 @@ func TestRestructureOperator(t *testing.T) {
 		return e
 	}
 
+	newArrayEntry := func() *entry.Entry {
+		e := entry.New()
+		e.Timestamp = time.Unix(1586632809, 0)
+		e.Record = map[string]interface{}{
+			"items": []interface{}{
+				map[string]interface{}{"id": "a", "secret": "x"},
+				map[string]interface{}{"id": "b", "secret": "y"},
+			},
+		}
+		return e
+	}
+
 	cases := []struct {
 		name   string
 		ops    []Op
@@ -175,6 +193,92 @@ func TestRestructureOperator(t *testing.T) {
 				return e
 			}(),
 		},
+		{
+			name: "RemoveWildcard",
+			ops: []Op{
+				{
+					&OpRemove{newField(t, "items.*.secret")},
+				},
+			},
+			input: newArrayEntry(),
+			output: func() *entry.Entry {
+				e := newArrayEntry()
+				e.Record = map[string]interface{}{
+					"items": []interface{}{
+						map[string]interface{}{"id": "a"},
+						map[string]interface{}{"id": "b"},
+					},
+				}
+				return e
+			}(),
+		},
+		{
+			name: "MoveWildcard",
+			ops: []Op{
+				{
+					&OpMove{
+						From: newField(t, "items[*].id"),
+						To:   newField(t, "items[*].item_id"),
+					},
+				},
+			},
+			input: newArrayEntry(),
+			output: func() *entry.Entry {
+				e := newArrayEntry()
+				e.Record = map[string]interface{}{
+					"items": []interface{}{
+						map[string]interface{}{"item_id": "a", "secret": "x"},
+						map[string]interface{}{"item_id": "b", "secret": "y"},
+					},
+				}
+				return e
+			}(),
+		},
+		{
+			name: "MoveWildcardToList",
+			ops: []Op{
+				{
+					&OpMove{
+						From: newField(t, "items.*.id"),
+						To:   newField(t, "ids"),
+					},
+				},
+			},
+			input: newArrayEntry(),
+			output: func() *entry.Entry {
+				e := newArrayEntry()
+				e.Record = map[string]interface{}{
+					"ids": []interface{}{"a", "b"},
+					"items": []interface{}{
+						map[string]interface{}{"secret": "x"},
+						map[string]interface{}{"secret": "y"},
+					},
+				}
+				return e
+			}(),
+		},
+		{
+			name: "MoveIndex",
+			ops: []Op{
+				{
+					&OpMove{
+						From: newField(t, "items[0]"),
+						To:   newField(t, "first"),
+					},
+				},
+			},
+			input: newArrayEntry(),
+			output: func() *entry.Entry {
+				e := newArrayEntry()
+				e.Record = map[string]interface{}{
+					"first": map[string]interface{}{"id": "a", "secret": "x"},
+					"items": []interface{}{
+						map[string]interface{}{"id": "b", "secret": "y"},
+					},
+				}
+				return e
+			}(),
+		},
 		{
 			name: "Flatten",
 			ops: []Op{
@@ -118,8 +118,12 @@ func (p *RouterOperator) Process(ctx context.Context, entry *entry.Entry) error
 				return err
 			}
 
-			for _, output := range route.OutputOperators {
-				_ = output.Process(ctx, entry)
+			for i, output := range route.OutputOperators {
+				if i == len(route.OutputOperators)-1 {
+					_ = output.Process(ctx, entry)
+					break
+				}
+				_ = output.Process(ctx, entry.Share())
 			}
 			break
 		}
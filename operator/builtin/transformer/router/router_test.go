@@ -32,7 +32,7 @@ func TestRouterOperator(t *testing.T) {
 		routes         []*RouterOperatorRouteConfig
 		defaultOutput  helper.OutputIDs
 		expectedCounts map[string]int
-		expectedLabels map[string]string
+		expectedLabels map[string]interface{}
 	}{
 		{
 			"DefaultRoute",
@@ -110,7 +110,7 @@ func TestRouterOperator(t *testing.T) {
 			},
 			nil,
 			map[string]int{"output2": 1},
-			map[string]string{
+			map[string]interface{}{
 				"label-key": "label-value",
 			},
 		},
@@ -187,7 +187,7 @@ func TestRouterOperator(t *testing.T) {
 			op := ops[0]
 
 			results := map[string]int{}
-			var labels map[string]string
+			var labels map[string]interface{}
 
 			mock1 := testutil.NewMockOperator("$.output1")
 			mock1.On("Process", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
@@ -217,3 +217,38 @@ func TestRouterOperator(t *testing.T) {
 		})
 	}
 }
+
+func TestRouterOperatorIsolatesOutputs(t *testing.T) {
+	cfg := NewRouterOperatorConfig("test_operator_id")
+	cfg.Routes = []*RouterOperatorRouteConfig{
+		{
+			Expression: "true",
+			OutputIDs:  []string{"output1", "output2"},
+		},
+	}
+
+	ops, err := cfg.Build(testutil.NewBuildContext(t))
+	require.NoError(t, err)
+
+	var received []*entry.Entry
+	mock1 := testutil.NewMockOperator("$.output1")
+	mock1.On("Process", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		received = append(received, args[1].(*entry.Entry))
+	})
+	mock2 := testutil.NewMockOperator("$.output2")
+	mock2.On("Process", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		received = append(received, args[1].(*entry.Entry))
+	})
+
+	routerOperator := ops[0].(*RouterOperator)
+	require.NoError(t, routerOperator.SetOutputs([]operator.Operator{mock1, mock2}))
+
+	input := entry.New()
+	input.Record = map[string]interface{}{"message": "test"}
+	require.NoError(t, routerOperator.Process(context.Background(), input))
+	require.Len(t, received, 2)
+
+	require.NoError(t, received[0].Set(entry.NewRecordField("message"), "modified"))
+	require.Equal(t, map[string]interface{}{"message": "modified"}, received[0].Record)
+	require.Equal(t, map[string]interface{}{"message": "test"}, received[1].Record)
+}
@@ -0,0 +1,139 @@
+package flusher
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of a flusher's circuit breaker
+type BreakerState int
+
+const (
+	// BreakerClosed means flushes are attempted normally
+	BreakerClosed BreakerState = iota
+
+	// BreakerOpen means the destination is considered down, so no flushes are attempted
+	BreakerOpen
+
+	// BreakerHalfOpen means a single probe flush is being attempted to check
+	// whether the destination has recovered
+	BreakerHalfOpen
+)
+
+// String returns the name of the state
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// circuitBreaker stops flush attempts after a number of consecutive failures.
+// Once it has been open for the configured timeout, it lets a single probe flush
+// through. If the probe succeeds it closes again, otherwise it reopens.
+type circuitBreaker struct {
+	mux     sync.Mutex
+	state   BreakerState
+	changed chan struct{}
+
+	threshold int
+	timeout   time.Duration
+	failures  int
+	openedAt  time.Time
+
+	onChange func(oldState, newState BreakerState)
+}
+
+// newCircuitBreaker creates a circuit breaker that opens after threshold consecutive
+// failures. A threshold of 0 disables the breaker.
+func newCircuitBreaker(threshold int, timeout time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		changed:   make(chan struct{}),
+		threshold: threshold,
+		timeout:   timeout,
+		onChange:  func(BreakerState, BreakerState) {},
+	}
+}
+
+// State returns the current state of the breaker
+func (c *circuitBreaker) State() BreakerState {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.state
+}
+
+// Wait blocks until a flush may be attempted. It returns true if the
+// caller had to wait for the breaker to allow it through.
+func (c *circuitBreaker) Wait(ctx context.Context) (bool, error) {
+	waited := false
+	for {
+		c.mux.Lock()
+		var timer <-chan time.Time
+		switch c.state {
+		case BreakerClosed:
+			c.mux.Unlock()
+			return waited, nil
+		case BreakerOpen:
+			remaining := c.timeout - time.Since(c.openedAt)
+			if remaining <= 0 {
+				// This caller becomes the probe
+				c.setState(BreakerHalfOpen)
+				c.mux.Unlock()
+				return waited, nil
+			}
+			timer = time.After(remaining)
+		}
+		changed := c.changed
+		c.mux.Unlock()
+
+		waited = true
+		select {
+		case <-ctx.Done():
+			return waited, ctx.Err()
+		case <-changed:
+		case <-timer:
+		}
+	}
+}
+
+// Observe records the result of a flush attempt
+func (c *circuitBreaker) Observe(err error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if err == nil {
+		c.failures = 0
+		if c.state != BreakerClosed {
+			c.setState(BreakerClosed)
+		}
+		return
+	}
+
+	c.failures++
+	switch c.state {
+	case BreakerClosed:
+		if c.threshold > 0 && c.failures >= c.threshold {
+			c.openedAt = time.Now()
+			c.setState(BreakerOpen)
+		}
+	case BreakerHalfOpen:
+		c.openedAt = time.Now()
+		c.setState(BreakerOpen)
+	}
+}
+
+// setState changes the state and wakes up any waiters. The lock must be held when calling this.
+func (c *circuitBreaker) setState(state BreakerState) {
+	oldState := c.state
+	c.state = state
+	close(c.changed)
+	c.changed = make(chan struct{})
+	c.onChange(oldState, state)
+}
@@ -0,0 +1,119 @@
+package flusher
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/observiq/stanza/operator/helper"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	failed := errors.New("failed")
+
+	t.Run("Disabled", func(t *testing.T) {
+		c := newCircuitBreaker(0, time.Minute)
+		for i := 0; i < 100; i++ {
+			c.Observe(failed)
+		}
+		require.Equal(t, BreakerClosed, c.State())
+	})
+
+	t.Run("OpensAfterThreshold", func(t *testing.T) {
+		c := newCircuitBreaker(3, time.Minute)
+		c.Observe(failed)
+		c.Observe(failed)
+		c.Observe(nil)
+		c.Observe(failed)
+		c.Observe(failed)
+		require.Equal(t, BreakerClosed, c.State())
+
+		c.Observe(failed)
+		require.Equal(t, BreakerOpen, c.State())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		waited, err := c.Wait(ctx)
+		require.True(t, waited)
+		require.Error(t, err)
+	})
+
+	t.Run("HalfOpenProbe", func(t *testing.T) {
+		c := newCircuitBreaker(1, 10*time.Millisecond)
+		c.Observe(failed)
+		require.Equal(t, BreakerOpen, c.State())
+
+		// The first waiter after the timeout becomes the probe
+		waited, err := c.Wait(context.Background())
+		require.NoError(t, err)
+		require.True(t, waited)
+		require.Equal(t, BreakerHalfOpen, c.State())
+
+		// Other waiters are blocked while the probe is in progress
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		_, err = c.Wait(ctx)
+		require.Error(t, err)
+
+		// A failed probe reopens the breaker
+		c.Observe(failed)
+		require.Equal(t, BreakerOpen, c.State())
+
+		// A successful probe closes it
+		_, err = c.Wait(context.Background())
+		require.NoError(t, err)
+		c.Observe(nil)
+		require.Equal(t, BreakerClosed, c.State())
+
+		waited, err = c.Wait(context.Background())
+		require.NoError(t, err)
+		require.False(t, waited)
+	})
+}
+
+func TestFlusherCircuitBreaker(t *testing.T) {
+
+	// Override setting for test
+	maxElapsedTime = 5 * time.Second
+
+	cfg := NewConfig()
+	cfg.BreakerThreshold = 2
+	cfg.BreakerTimeout = helper.NewDuration(50 * time.Millisecond)
+	flusher := cfg.Build(zaptest.NewLogger(t).Sugar())
+	defer flusher.Stop()
+
+	var healthy int32
+	var attempts int32
+	done := make(chan struct{})
+	flusher.Do(func(_ context.Context) error {
+		atomic.AddInt32(&attempts, 1)
+		if atomic.LoadInt32(&healthy) == 0 {
+			return errors.New("destination down")
+		}
+		close(done)
+		return nil
+	})
+
+	require.Eventually(t, func() bool {
+		return flusher.BreakerState() == BreakerOpen
+	}, time.Second, time.Millisecond)
+	require.Equal(t, BreakerOpen.String(), flusher.Status()["circuit_breaker"])
+
+	// No attempts are made while the breaker is open
+	n := atomic.LoadInt32(&attempts)
+	time.Sleep(20 * time.Millisecond)
+	require.Equal(t, n, atomic.LoadInt32(&attempts))
+
+	atomic.StoreInt32(&healthy, 1)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out")
+	}
+	require.Equal(t, BreakerClosed, flusher.BreakerState())
+	require.Equal(t, BreakerClosed.String(), flusher.Status()["circuit_breaker"])
+}
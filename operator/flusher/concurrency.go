@@ -0,0 +1,148 @@
+package flusher
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// concurrencyLimiter limits the number of flushes that are in progress at once
+type concurrencyLimiter interface {
+	// Acquire blocks until a flush may start or the context is cancelled
+	Acquire(context.Context) error
+
+	// Release frees the slot taken by a call to Acquire
+	Release()
+
+	// Observe records the outcome of a single flush attempt that started at the given time
+	Observe(start time.Time, latency time.Duration, err error)
+
+	// Limit returns the current maximum number of concurrent flushes
+	Limit() int
+}
+
+// fixedLimiter is a concurrencyLimiter with a constant limit
+type fixedLimiter struct {
+	sem   *semaphore.Weighted
+	limit int
+}
+
+func newFixedLimiter(limit int) *fixedLimiter {
+	return &fixedLimiter{
+		sem:   semaphore.NewWeighted(int64(limit)),
+		limit: limit,
+	}
+}
+
+func (f *fixedLimiter) Acquire(ctx context.Context) error       { return f.sem.Acquire(ctx, 1) }
+func (f *fixedLimiter) Release()                                { f.sem.Release(1) }
+func (f *fixedLimiter) Observe(time.Time, time.Duration, error) {}
+func (f *fixedLimiter) Limit() int                              { return f.limit }
+
+// adaptiveLimiter is a concurrencyLimiter that adjusts its limit between a minimum
+// and maximum using additive increase and multiplicative decrease (AIMD).
+//
+// Every successful flush faster than the target latency raises the limit by 1/limit,
+// so the limit grows by roughly one for every round of flushes at the current limit.
+// A failed or slow flush multiplies the limit by the decrease factor. Flushes that
+// started before the most recent decrease are ignored when deciding to decrease again,
+// so a single burst of failures only backs off once.
+type adaptiveLimiter struct {
+	mux  sync.Mutex
+	cond *sync.Cond
+
+	limit          float64
+	min            float64
+	max            float64
+	inFlight       int
+	targetLatency  time.Duration
+	decreaseFactor float64
+	lastDecrease   time.Time
+
+	onChange func(oldLimit, newLimit int)
+}
+
+func newAdaptiveLimiter(min, max int, targetLatency time.Duration, decreaseFactor float64) *adaptiveLimiter {
+	l := &adaptiveLimiter{
+		limit:          float64(min),
+		min:            float64(min),
+		max:            float64(max),
+		targetLatency:  targetLatency,
+		decreaseFactor: decreaseFactor,
+		onChange:       func(int, int) {},
+	}
+	l.cond = sync.NewCond(&l.mux)
+	return l
+}
+
+func (a *adaptiveLimiter) Acquire(ctx context.Context) error {
+	// Wake up waiters when the context is cancelled so they can return
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			a.mux.Lock()
+			a.cond.Broadcast()
+			a.mux.Unlock()
+		case <-stop:
+		}
+	}()
+
+	a.mux.Lock()
+	defer a.mux.Unlock()
+	for a.inFlight >= int(a.limit) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		a.cond.Wait()
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	a.inFlight++
+	return nil
+}
+
+func (a *adaptiveLimiter) Release() {
+	a.mux.Lock()
+	a.inFlight--
+	a.mux.Unlock()
+	a.cond.Signal()
+}
+
+func (a *adaptiveLimiter) Observe(start time.Time, latency time.Duration, err error) {
+	a.mux.Lock()
+	oldLimit := int(a.limit)
+
+	if err == nil && latency <= a.targetLatency {
+		a.limit += 1 / a.limit
+		if a.limit > a.max {
+			a.limit = a.max
+		}
+	} else if start.After(a.lastDecrease) {
+		a.limit *= a.decreaseFactor
+		if a.limit < a.min {
+			a.limit = a.min
+		}
+		a.lastDecrease = time.Now()
+	}
+
+	newLimit := int(a.limit)
+	a.mux.Unlock()
+
+	if newLimit != oldLimit {
+		if newLimit > oldLimit {
+			a.cond.Broadcast()
+		}
+		a.onChange(oldLimit, newLimit)
+	}
+}
+
+func (a *adaptiveLimiter) Limit() int {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+	return int(a.limit)
+}
@@ -0,0 +1,119 @@
+package flusher
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/observiq/stanza/operator/helper"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestAdaptiveLimiter(t *testing.T) {
+	t.Run("AdditiveIncrease", func(t *testing.T) {
+		l := newAdaptiveLimiter(1, 4, time.Second, 0.5)
+		require.Equal(t, 1, l.Limit())
+
+		// One success at limit 1 raises the limit to 2
+		l.Observe(time.Now(), time.Millisecond, nil)
+		require.Equal(t, 2, l.Limit())
+
+		// It takes about one round of successes at limit 2 to raise the limit to 3
+		l.Observe(time.Now(), time.Millisecond, nil)
+		l.Observe(time.Now(), time.Millisecond, nil)
+		require.Equal(t, 2, l.Limit())
+		l.Observe(time.Now(), time.Millisecond, nil)
+		require.Equal(t, 3, l.Limit())
+
+		// The limit never exceeds the max
+		for i := 0; i < 100; i++ {
+			l.Observe(time.Now(), time.Millisecond, nil)
+		}
+		require.Equal(t, 4, l.Limit())
+	})
+
+	t.Run("MultiplicativeDecrease", func(t *testing.T) {
+		l := newAdaptiveLimiter(1, 16, time.Second, 0.5)
+		l.limit = 16
+
+		l.Observe(time.Now(), time.Millisecond, errors.New("failed"))
+		require.Equal(t, 8, l.Limit())
+
+		l.Observe(time.Now(), 2*time.Second, nil)
+		require.Equal(t, 4, l.Limit())
+
+		// The limit never drops below the min
+		for i := 0; i < 10; i++ {
+			l.Observe(time.Now(), time.Millisecond, errors.New("failed"))
+		}
+		require.Equal(t, 1, l.Limit())
+	})
+
+	t.Run("DecreaseOncePerCohort", func(t *testing.T) {
+		l := newAdaptiveLimiter(1, 16, time.Second, 0.5)
+		l.limit = 16
+
+		start := time.Now()
+		time.Sleep(time.Millisecond)
+		l.Observe(start, time.Millisecond, errors.New("failed"))
+		require.Equal(t, 8, l.Limit())
+
+		// A flush that started before the decrease doesn't decrease again
+		l.Observe(start, time.Millisecond, errors.New("failed"))
+		require.Equal(t, 8, l.Limit())
+	})
+
+	t.Run("AcquireBlocksAtLimit", func(t *testing.T) {
+		l := newAdaptiveLimiter(1, 1, time.Second, 0.5)
+		require.NoError(t, l.Acquire(context.Background()))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		require.Error(t, l.Acquire(ctx))
+
+		acquired := make(chan struct{})
+		go func() {
+			require.NoError(t, l.Acquire(context.Background()))
+			close(acquired)
+		}()
+
+		l.Release()
+		select {
+		case <-acquired:
+		case <-time.After(time.Second):
+			require.FailNow(t, "timed out waiting for acquire")
+		}
+	})
+}
+
+func TestAdaptiveFlusher(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Adaptive = true
+	cfg.MinConcurrent = 2
+	cfg.MaxConcurrent = 8
+	cfg.TargetLatency = helper.NewDuration(time.Second)
+	flusher := cfg.Build(zaptest.NewLogger(t).Sugar())
+	defer flusher.Stop()
+
+	require.Equal(t, 2, flusher.Concurrency())
+
+	done := make(chan struct{}, 100)
+	for i := 0; i < 100; i++ {
+		flusher.Do(func(_ context.Context) error {
+			done <- struct{}{}
+			return nil
+		})
+	}
+
+	for i := 0; i < 100; i++ {
+		select {
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "timed out")
+		case <-done:
+		}
+	}
+
+	require.Equal(t, 8, flusher.Concurrency())
+}
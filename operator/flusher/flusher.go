@@ -7,27 +7,54 @@ import (
 	"time"
 
 	backoff "github.com/cenkalti/backoff/v4"
+	"github.com/observiq/stanza/operator/helper"
 	"go.uber.org/zap"
-	"golang.org/x/sync/semaphore"
 )
 
 // These are vars so they can be overridden in tests
 var maxRetryInterval = time.Minute
 var maxElapsedTime = time.Hour
 
+// adaptiveDecreaseFactor is the factor the concurrency limit is multiplied by
+// when a flush fails or is slower than the target latency
+const adaptiveDecreaseFactor = 0.5
+
 // Config holds the configuration to build a new flusher
 type Config struct {
 	// MaxConcurrent is the maximum number of goroutines flushing entries concurrently.
 	// Defaults to 16.
+	// When Adaptive is set, it is the upper bound of the adaptive concurrency limit.
 	MaxConcurrent int `json:"max_concurrent" yaml:"max_concurrent"`
 
+	// Adaptive enables adjusting the number of concurrent flushes between MinConcurrent
+	// and MaxConcurrent based on the latency and errors of recent flushes.
+	Adaptive bool `json:"adaptive,omitempty" yaml:"adaptive,omitempty"`
+
+	// MinConcurrent is the lower bound of the adaptive concurrency limit. Defaults to 1.
+	MinConcurrent int `json:"min_concurrent,omitempty" yaml:"min_concurrent,omitempty"`
+
+	// TargetLatency is the flush latency above which the adaptive concurrency limit
+	// is decreased. Defaults to 2 seconds.
+	TargetLatency helper.Duration `json:"target_latency,omitempty" yaml:"target_latency,omitempty"`
+
+	// BreakerThreshold is the number of consecutive failed flushes after which the
+	// circuit breaker opens and flushing is paused. Defaults to 0, which disables it.
+	BreakerThreshold int `json:"circuit_breaker_threshold,omitempty" yaml:"circuit_breaker_threshold,omitempty"`
+
+	// BreakerTimeout is how long the circuit breaker stays open before a single
+	// probe flush is attempted. Defaults to 30 seconds.
+	BreakerTimeout helper.Duration `json:"circuit_breaker_timeout,omitempty" yaml:"circuit_breaker_timeout,omitempty"`
+
 	// TODO configurable retry
 }
 
 // NewConfig creates a new default flusher config
 func NewConfig() Config {
 	return Config{
-		MaxConcurrent: 16,
+		MaxConcurrent:  16,
+		MinConcurrent:  1,
+		TargetLatency:  helper.NewDuration(2 * time.Second),
+		BreakerTimeout: helper.NewDuration(30 * time.Second),
 	}
 }
 
@@ -40,12 +67,52 @@ func (c *Config) Build(logger *zap.SugaredLogger) *Flusher {
 
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &Flusher{
+	breakerTimeout := c.BreakerTimeout.Raw()
+	if breakerTimeout == 0 {
+		breakerTimeout = 30 * time.Second
+	}
+
+	f := &Flusher{
 		ctx:           ctx,
 		cancel:        cancel,
-		sem:           semaphore.NewWeighted(int64(maxConcurrent)),
+		breaker:       newCircuitBreaker(c.BreakerThreshold, breakerTimeout),
 		SugaredLogger: logger,
 	}
+	f.breaker.onChange = func(oldState, newState BreakerState) {
+		switch newState {
+		case BreakerOpen:
+			f.Warnw("Circuit breaker opened. Pausing flushes", "previous_state", oldState, "timeout", breakerTimeout)
+		case BreakerHalfOpen:
+			f.Infow("Circuit breaker half-open. Probing destination")
+		case BreakerClosed:
+			f.Infow("Circuit breaker closed. Resuming flushes")
+		}
+	}
+
+	if !c.Adaptive {
+		f.limiter = newFixedLimiter(maxConcurrent)
+		return f
+	}
+
+	minConcurrent := c.MinConcurrent
+	if minConcurrent <= 0 {
+		minConcurrent = 1
+	}
+	if minConcurrent > maxConcurrent {
+		minConcurrent = maxConcurrent
+	}
+
+	targetLatency := c.TargetLatency.Raw()
+	if targetLatency == 0 {
+		targetLatency = 2 * time.Second
+	}
+
+	limiter := newAdaptiveLimiter(minConcurrent, maxConcurrent, targetLatency, adaptiveDecreaseFactor)
+	limiter.onChange = func(oldLimit, newLimit int) {
+		f.Debugw("Adjusted flush concurrency", "old_limit", oldLimit, "new_limit", newLimit)
+	}
+	f.limiter = limiter
+	return f
 }
 
 // Flusher is used to flush entries from a buffer concurrently. It handles max concurrency,
@@ -53,7 +120,8 @@ func (c *Config) Build(logger *zap.SugaredLogger) *Flusher {
 type Flusher struct {
 	ctx            context.Context
 	cancel         context.CancelFunc
-	sem            *semaphore.Weighted
+	limiter        concurrencyLimiter
+	breaker        *circuitBreaker
 	wg             sync.WaitGroup
 	chunkIDCounter uint64
 	*zap.SugaredLogger
@@ -65,7 +133,7 @@ type FlushFunc func(context.Context) error
 // Do executes the flusher function in a goroutine
 func (f *Flusher) Do(flush FlushFunc) {
 	// Wait until we have free flusher goroutines
-	if err := f.sem.Acquire(f.ctx, 1); err != nil {
+	if err := f.limiter.Acquire(f.ctx); err != nil {
 		// Context cancelled
 		return
 	}
@@ -74,11 +142,30 @@ func (f *Flusher) Do(flush FlushFunc) {
 	f.wg.Add(1)
 	go func() {
 		defer f.wg.Done()
-		defer f.sem.Release(1)
+		defer f.limiter.Release()
 		f.flushWithRetry(f.ctx, flush)
 	}()
 }
 
+// Concurrency returns the current maximum number of concurrent flushes
+func (f *Flusher) Concurrency() int {
+	return f.limiter.Limit()
+}
+
+// BreakerState returns the current state of the circuit breaker
+func (f *Flusher) BreakerState() BreakerState {
+	return f.breaker.State()
+}
+
+// Status returns the current flush concurrency and circuit breaker state, to be reported as
+// part of an output's status
+func (f *Flusher) Status() map[string]interface{} {
+	return map[string]interface{}{
+		"flush_concurrency": f.Concurrency(),
+		"circuit_breaker":   f.BreakerState().String(),
+	}
+}
+
 // Stop cancels all the in-progress flushers and waits until they have returned
 func (f *Flusher) Stop() {
 	f.cancel()
@@ -93,7 +180,20 @@ func (f *Flusher) flushWithRetry(ctx context.Context, flush FlushFunc) {
 	chunkID := f.nextChunkID()
 	b := newExponentialBackoff()
 	for {
-		err := flush(ctx)
+		// Wait for the circuit breaker to allow a flush. Time spent waiting on an
+		// open breaker does not count towards the max elapsed time of the backoff.
+		waited, err := f.breaker.Wait(ctx)
+		if err != nil {
+			return
+		}
+		if waited {
+			b.Reset()
+		}
+
+		start := time.Now()
+		err = flush(ctx)
+		f.limiter.Observe(start, time.Since(start), err)
+		f.breaker.Observe(err)
 		if err == nil {
 			return
 		}
@@ -110,7 +210,10 @@ func (f *Flusher) flushWithRetry(ctx context.Context, flush FlushFunc) {
 		case <-ctx.Done():
 			return
 		default:
-			f.Warnw("Failed flushing chunk. Waiting before retry", "error", err, "wait_time", waitTime)
+			// While the breaker is open, its state change is logged instead of every failure
+			if f.breaker.State() == BreakerClosed {
+				f.Warnw("Failed flushing chunk. Waiting before retry", "error", err, "wait_time", waitTime)
+			}
 		}
 
 		select {
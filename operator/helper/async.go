@@ -0,0 +1,267 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/observiq/stanza/entry"
+	"github.com/observiq/stanza/operator"
+	"go.uber.org/zap"
+)
+
+// These are the default values of an async config
+const (
+	DefaultAsyncWorkers   = 1
+	DefaultAsyncQueueSize = 100
+)
+
+// AsyncConfig is the configuration of an asynchronous operator stage. An async stage puts a
+// bounded queue in front of an operator and processes the queued entries with a pool of workers.
+type AsyncConfig struct {
+	Workers   int          `json:"workers,omitempty"    yaml:"workers,omitempty"`
+	QueueSize int          `json:"queue_size,omitempty" yaml:"queue_size,omitempty"`
+	OrderBy   *entry.Field `json:"order_by,omitempty"   yaml:"order_by,omitempty"`
+}
+
+// AsyncBuilder is implemented by operator configs that can be run as an async stage
+type AsyncBuilder interface {
+	// AsyncOptions returns the async config of the operator, or nil if it runs synchronously
+	AsyncOptions() *AsyncConfig
+}
+
+// Build will wrap an operator in an async stage
+func (c AsyncConfig) Build(op operator.Operator) (*AsyncOperator, error) {
+	workers := c.Workers
+	if workers == 0 {
+		workers = DefaultAsyncWorkers
+	}
+	if workers < 0 {
+		return nil, fmt.Errorf("invalid value %d for 'workers'", c.Workers)
+	}
+
+	queueSize := c.QueueSize
+	if queueSize == 0 {
+		queueSize = DefaultAsyncQueueSize
+	}
+	if queueSize < 0 {
+		return nil, fmt.Errorf("invalid value %d for 'queue_size'", c.QueueSize)
+	}
+
+	// Ordered stages give each worker its own queue, so that entries with the same
+	// order_by value are always processed in order by the same worker
+	queueCount := 1
+	if c.OrderBy != nil {
+		queueCount = workers
+		queueSize = (queueSize + workers - 1) / workers
+	}
+
+	queues := make([]chan []*entry.Entry, queueCount)
+	for i := range queues {
+		queues[i] = make(chan []*entry.Entry, queueSize)
+	}
+
+	return &AsyncOperator{
+		Operator: op,
+		workers:  workers,
+		orderBy:  c.OrderBy,
+		queues:   queues,
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// AsyncOperator is an operator that queues the entries it receives and processes them with a
+// pool of workers. When the queue is full, callers block until there is room, so that a slow
+// operator applies backpressure to the operators in front of it.
+type AsyncOperator struct {
+	operator.Operator
+	workers int
+	orderBy *entry.Field
+
+	queues  []chan []*entry.Entry
+	wg      sync.WaitGroup
+	pending sync.WaitGroup
+	done    chan struct{}
+	mux     sync.RWMutex
+	started bool
+	stopped bool
+
+	processed uint64
+	blocked   uint64
+}
+
+// AsyncStats is a snapshot of the state of an async stage
+type AsyncStats struct {
+	// QueueDepth is the number of queued batches waiting for a worker
+	QueueDepth int
+	// QueueCapacity is the number of batches that can be queued before callers block
+	QueueCapacity int
+	// Processed is the number of batches processed by the workers
+	Processed uint64
+	// Blocked is the number of times a caller blocked because the queue was full
+	Blocked uint64
+}
+
+// Start will start the wrapped operator, then the workers
+func (a *AsyncOperator) Start() error {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+	if a.started {
+		return nil
+	}
+
+	if err := a.Operator.Start(); err != nil {
+		return err
+	}
+
+	a.started = true
+	for i := 0; i < a.workers; i++ {
+		a.wg.Add(1)
+		go a.work(a.queues[i%len(a.queues)])
+	}
+	return nil
+}
+
+// Stop will process the entries left in the queue, then stop the wrapped operator. Callers
+// that are blocked on a full queue are released with an error.
+func (a *AsyncOperator) Stop() error {
+	a.mux.Lock()
+	if !a.stopped {
+		a.stopped = true
+		close(a.done)
+
+		// The queues can only be closed once no caller is sending to them
+		a.pending.Wait()
+		for _, queue := range a.queues {
+			close(queue)
+		}
+	}
+	a.mux.Unlock()
+
+	a.wg.Wait()
+	return a.Operator.Stop()
+}
+
+// Process will queue an entry for the workers
+func (a *AsyncOperator) Process(ctx context.Context, e *entry.Entry) error {
+	return a.enqueue(ctx, a.queueFor(e), []*entry.Entry{e})
+}
+
+// ProcessBatch will queue a batch of entries for the workers. Ordered stages split the
+// batch between the queues of the workers that own each entry.
+func (a *AsyncOperator) ProcessBatch(ctx context.Context, entries []*entry.Entry) error {
+	if len(a.queues) == 1 {
+		return a.enqueue(ctx, 0, entries)
+	}
+
+	parts := make([][]*entry.Entry, len(a.queues))
+	for _, e := range entries {
+		i := a.queueFor(e)
+		parts[i] = append(parts[i], e)
+	}
+
+	for i, part := range parts {
+		if len(part) == 0 {
+			continue
+		}
+		if err := a.enqueue(ctx, i, part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Status returns the status of the wrapped operator, if it has one, along with the stats of the stage
+func (a *AsyncOperator) Status() map[string]interface{} {
+	status := map[string]interface{}{}
+	if reporter, ok := a.Operator.(operator.StatusReporter); ok {
+		for key, value := range reporter.Status() {
+			status[key] = value
+		}
+	}
+
+	stats := a.Stats()
+	status["async_queue_depth"] = stats.QueueDepth
+	status["async_queue_capacity"] = stats.QueueCapacity
+	status["async_processed"] = stats.Processed
+	status["async_blocked"] = stats.Blocked
+	return status
+}
+
+// Stats returns a snapshot of the state of the stage
+func (a *AsyncOperator) Stats() AsyncStats {
+	stats := AsyncStats{
+		Processed: atomic.LoadUint64(&a.processed),
+		Blocked:   atomic.LoadUint64(&a.blocked),
+	}
+	for _, queue := range a.queues {
+		stats.QueueDepth += len(queue)
+		stats.QueueCapacity += cap(queue)
+	}
+	return stats
+}
+
+// queueFor returns the index of the queue an entry belongs in
+func (a *AsyncOperator) queueFor(e *entry.Entry) int {
+	if len(a.queues) == 1 {
+		return 0
+	}
+
+	hash := fnv.New32a()
+	if value, ok := e.Get(*a.orderBy); ok {
+		fmt.Fprint(hash, value)
+	}
+	return int(hash.Sum32() % uint32(len(a.queues)))
+}
+
+// enqueue adds a batch to a queue, blocking until there is room, the context is cancelled
+// or the stage is stopped
+func (a *AsyncOperator) enqueue(ctx context.Context, i int, entries []*entry.Entry) error {
+	a.mux.RLock()
+	if a.stopped {
+		a.mux.RUnlock()
+		return fmt.Errorf("operator %s is stopped", a.ID())
+	}
+	a.pending.Add(1)
+	a.mux.RUnlock()
+	defer a.pending.Done()
+
+	select {
+	case a.queues[i] <- entries:
+		return nil
+	default:
+	}
+
+	if atomic.AddUint64(&a.blocked, 1) == 1 {
+		a.Logger().Debugw("Async queue is full. Applying backpressure", "queue_capacity", cap(a.queues[i]))
+	}
+
+	select {
+	case a.queues[i] <- entries:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-a.done:
+		return fmt.Errorf("operator %s is stopped", a.ID())
+	}
+}
+
+// work processes batches from a queue until it is closed and empty
+func (a *AsyncOperator) work(queue chan []*entry.Entry) {
+	defer a.wg.Done()
+	ctx := context.Background()
+	for entries := range queue {
+		var err error
+		if len(entries) == 1 {
+			err = a.Operator.Process(ctx, entries[0])
+		} else {
+			err = ProcessBatch(ctx, a.Operator, entries)
+		}
+		if err != nil {
+			a.Logger().Errorw("Async worker failed to process entries", "count", len(entries), zap.Error(err))
+		}
+		atomic.AddUint64(&a.processed, 1)
+	}
+}
@@ -0,0 +1,229 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/observiq/stanza/entry"
+	"github.com/observiq/stanza/testutil"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestAsyncConfigBuild(t *testing.T) {
+	cases := []struct {
+		name        string
+		config      AsyncConfig
+		expectErr   bool
+		queues      int
+		queueLength int
+	}{
+		{"Default", AsyncConfig{}, false, 1, DefaultAsyncQueueSize},
+		{"Workers", AsyncConfig{Workers: 4, QueueSize: 10}, false, 1, 10},
+		{"Ordered", AsyncConfig{Workers: 4, QueueSize: 10, OrderBy: &entry.Field{FieldInterface: entry.NewLabelField("source")}}, false, 4, 3},
+		{"NegativeWorkers", AsyncConfig{Workers: -1}, true, 0, 0},
+		{"NegativeQueueSize", AsyncConfig{QueueSize: -1}, true, 0, 0},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			op, err := tc.config.Build(testutil.NewFakeOutput(t))
+			if tc.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, op.queues, tc.queues)
+			require.Equal(t, tc.queueLength, cap(op.queues[0]))
+		})
+	}
+}
+
+func TestAsyncOperatorProcess(t *testing.T) {
+	output := testutil.NewFakeOutput(t)
+	op, err := AsyncConfig{Workers: 2}.Build(output)
+	require.NoError(t, err)
+	require.NoError(t, op.Start())
+	defer op.Stop()
+
+	e := entry.New()
+	e.Record = "test"
+	require.NoError(t, op.Process(context.Background(), e))
+	output.ExpectRecord(t, "test")
+}
+
+func TestAsyncOperatorProcessBatch(t *testing.T) {
+	output := testutil.NewFakeOutput(t)
+	op, err := AsyncConfig{}.Build(output)
+	require.NoError(t, err)
+	require.NoError(t, op.Start())
+	defer op.Stop()
+
+	entries := []*entry.Entry{entry.New(), entry.New()}
+	entries[0].Record = "first"
+	entries[1].Record = "second"
+	require.NoError(t, op.ProcessBatch(context.Background(), entries))
+	output.ExpectRecord(t, "first")
+	output.ExpectRecord(t, "second")
+}
+
+func TestAsyncOperatorBackpressure(t *testing.T) {
+	unblock := make(chan struct{})
+	processed := make(chan struct{}, 10)
+	output := &testutil.Operator{}
+	output.On("ID").Return("output")
+	output.On("Logger").Return(zaptest.NewLogger(t).Sugar())
+	output.On("Start").Return(nil)
+	output.On("Stop").Return(nil)
+	output.On("Process", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		<-unblock
+		processed <- struct{}{}
+	})
+
+	op, err := AsyncConfig{QueueSize: 1}.Build(output)
+	require.NoError(t, err)
+	require.NoError(t, op.Start())
+
+	// The first entry is taken by the worker, and the second fills the queue
+	require.NoError(t, op.Process(context.Background(), entry.New()))
+	require.Eventually(t, func() bool { return op.Stats().QueueDepth == 0 }, time.Second, time.Millisecond)
+	require.NoError(t, op.Process(context.Background(), entry.New()))
+	require.Equal(t, 1, op.Stats().QueueDepth)
+
+	// The third entry blocks until the context is cancelled
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = op.Process(ctx, entry.New())
+	require.Equal(t, context.DeadlineExceeded, err)
+	require.Equal(t, uint64(1), op.Stats().Blocked)
+
+	// Stopping the operator processes the entries that are left in the queue
+	close(unblock)
+	require.NoError(t, op.Stop())
+	require.Len(t, processed, 2)
+	require.Equal(t, uint64(2), op.Stats().Processed)
+
+	// Entries can't be added once the operator is stopped
+	require.Error(t, op.Process(context.Background(), entry.New()))
+}
+
+func TestAsyncOperatorOrdered(t *testing.T) {
+	var mux sync.Mutex
+	received := map[string][]int{}
+	output := &testutil.Operator{}
+	output.On("ID").Return("output")
+	output.On("Logger").Return(zaptest.NewLogger(t).Sugar())
+	output.On("Start").Return(nil)
+	output.On("Stop").Return(nil)
+	output.On("Process", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		e := args[1].(*entry.Entry)
+		mux.Lock()
+		defer mux.Unlock()
+		source := e.Labels["source"].(string)
+		received[source] = append(received[source], e.Record.(int))
+	})
+
+	op, err := AsyncConfig{
+		Workers:   4,
+		QueueSize: 16,
+		OrderBy:   &entry.Field{FieldInterface: entry.NewLabelField("source")},
+	}.Build(output)
+	require.NoError(t, err)
+	require.NoError(t, op.Start())
+
+	for i := 0; i < 100; i++ {
+		batch := []*entry.Entry{}
+		for source := 0; source < 8; source++ {
+			e := entry.New()
+			e.Labels = map[string]interface{}{"source": fmt.Sprintf("source%d", source)}
+			e.Record = i
+			batch = append(batch, e)
+		}
+		require.NoError(t, op.ProcessBatch(context.Background(), batch))
+	}
+	require.NoError(t, op.Stop())
+
+	require.Len(t, received, 8)
+	for source, records := range received {
+		require.Len(t, records, 100, source)
+		for i, record := range records {
+			require.Equal(t, i, record, source)
+		}
+	}
+}
+
+func TestAsyncOperatorStopReleasesBlockedCallers(t *testing.T) {
+	unblock := make(chan struct{})
+	output := &testutil.Operator{}
+	output.On("ID").Return("output")
+	output.On("Logger").Return(zaptest.NewLogger(t).Sugar())
+	output.On("Start").Return(nil)
+	output.On("Stop").Return(nil)
+	output.On("Process", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		<-unblock
+	})
+
+	op, err := AsyncConfig{QueueSize: 1}.Build(output)
+	require.NoError(t, err)
+	require.NoError(t, op.Start())
+
+	require.NoError(t, op.Process(context.Background(), entry.New()))
+	require.Eventually(t, func() bool { return op.Stats().QueueDepth == 0 }, time.Second, time.Millisecond)
+	require.NoError(t, op.Process(context.Background(), entry.New()))
+
+	// The third entry blocks on the full queue without a deadline
+	blocked := make(chan error, 1)
+	go func() {
+		blocked <- op.Process(context.Background(), entry.New())
+	}()
+	require.Eventually(t, func() bool { return op.Stats().Blocked == 1 }, time.Second, time.Millisecond)
+
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- op.Stop()
+	}()
+
+	select {
+	case err := <-blocked:
+		require.Error(t, err)
+	case <-time.After(time.Second):
+		require.FailNow(t, "Timed out waiting for the blocked caller to be released")
+	}
+
+	close(unblock)
+	select {
+	case err := <-stopped:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		require.FailNow(t, "Timed out waiting for the operator to stop")
+	}
+	require.Equal(t, uint64(2), op.Stats().Processed)
+}
+
+type statusOutput struct {
+	*testutil.FakeOutput
+}
+
+func (o statusOutput) Status() map[string]interface{} {
+	return map[string]interface{}{"circuit_breaker": "closed"}
+}
+
+func TestAsyncOperatorStatus(t *testing.T) {
+	op, err := AsyncConfig{QueueSize: 10}.Build(statusOutput{testutil.NewFakeOutput(t)})
+	require.NoError(t, err)
+	require.NoError(t, op.Start())
+	defer op.Stop()
+
+	require.NoError(t, op.Process(context.Background(), entry.New()))
+	require.Eventually(t, func() bool { return op.Stats().Processed == 1 }, time.Second, time.Millisecond)
+
+	status := op.Status()
+	require.Equal(t, "closed", status["circuit_breaker"])
+	require.Equal(t, 10, status["async_queue_capacity"])
+	require.Equal(t, uint64(1), status["async_processed"])
+	require.Equal(t, uint64(0), status["async_blocked"])
+}
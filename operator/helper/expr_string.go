@@ -123,16 +123,28 @@ var envPool = sync.Pool{
 	},
 }
 
-// GetExprEnv returns a map of key/value pairs that can be be used to evaluate an expression
+// GetExprEnv returns a map of key/value pairs that can be be used to evaluate an expression.
+// If e is nil, the entry's values must be set with SetExprEnv before the map is used.
 func GetExprEnv(e *entry.Entry) map[string]interface{} {
 	env := envPool.Get().(map[string]interface{})
+	if e != nil {
+		SetExprEnv(env, e)
+	}
+	return env
+}
+
+// SetExprEnv replaces the values of an entry in an env returned by GetExprEnv. This allows
+// one env to be reused to evaluate expressions against many entries.
+func SetExprEnv(env map[string]interface{}, e *entry.Entry) {
 	env["$"] = e.Record
 	env["$record"] = e.Record
 	env["$labels"] = e.Labels
 	env["$resource"] = e.Resource
 	env["$timestamp"] = e.Timestamp
-
-	return env
+	env["$observed_timestamp"] = e.ObservedTimestamp
+	env["$trace_id"] = e.TraceID.String()
+	env["$span_id"] = e.SpanID.String()
+	env["$trace_flags"] = e.TraceFlags.String()
 }
 
 // PutExprEnv adds a key/value pair that will can be used to evaluate an expression
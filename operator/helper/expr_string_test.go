@@ -4,6 +4,7 @@ import (
 	"os"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/observiq/stanza/entry"
 	"github.com/stretchr/testify/require"
@@ -18,9 +19,11 @@ func TestExprString(t *testing.T) {
 		e.Record = map[string]interface{}{
 			"test": "value",
 		}
-		e.Resource = map[string]string{
+		e.Resource = map[string]interface{}{
 			"id": "value",
 		}
+		e.SpanID = entry.SpanID{0xb7, 0xad, 0x6b, 0x71, 0x69, 0x20, 0x33, 0x31}
+		e.ObservedTimestamp = time.Unix(1591042865, 0).UTC()
 		return e
 	}
 
@@ -84,6 +87,18 @@ func TestExprString(t *testing.T) {
 			"EXPR( $resource.id )",
 			"value",
 		},
+		{
+			"span-EXPR( $span_id )",
+			"span-b7ad6b7169203331",
+		},
+		{
+			"trace-EXPR( $trace_id )",
+			"trace-",
+		},
+		{
+			"EXPR( $observed_timestamp.Format('2006-01-02T15:04:05Z07:00') )",
+			"2020-06-01T20:21:05Z",
+		},
 	}
 
 	for i, tc := range cases {
@@ -0,0 +1,323 @@
+package helper
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+)
+
+// These are the ways a network input can split a stream into messages
+const (
+	// NewlineFraming splits messages on newlines, trimming a trailing carriage return
+	NewlineFraming = "newline"
+
+	// OctetCountingFraming reads messages prefixed by their length and a space, as in RFC 6587
+	OctetCountingFraming = "octet_counting"
+
+	// NULFraming splits messages on NUL bytes
+	NULFraming = "nul"
+
+	// DelimiterFraming splits messages on matches of the delimiter regex
+	DelimiterFraming = "delimiter"
+
+	// MultilineFraming splits messages with a multiline config
+	MultilineFraming = "multiline"
+
+	// NoFraming treats each datagram as a single message
+	NoFraming = "none"
+)
+
+// These are the actions taken on a message longer than the max log size
+const (
+	// TruncateOversized keeps the first max log size bytes of the message
+	TruncateOversized = "truncate"
+
+	// DropOversized drops the message
+	DropOversized = "drop"
+)
+
+// DefaultMaxLogSize is the default max log size of a network input
+const DefaultMaxLogSize = 1024 * 1024
+
+// maxOctetCountDigits is the longest length prefix accepted when octet counting
+const maxOctetCountDigits = 10
+
+// NewFramingConfig creates a new framing config with default values
+func NewFramingConfig(framing string) FramingConfig {
+	return FramingConfig{
+		Framing:       framing,
+		MaxLogSize:    DefaultMaxLogSize,
+		OversizedLogs: TruncateOversized,
+	}
+}
+
+// FramingConfig is the configuration of how a network input splits messages
+type FramingConfig struct {
+	Framing       string           `json:"framing,omitempty"        yaml:"framing,omitempty"`
+	Delimiter     string           `json:"delimiter,omitempty"      yaml:"delimiter,omitempty"`
+	Multiline     *MultilineConfig `json:"multiline,omitempty"      yaml:"multiline,omitempty"`
+	MaxLogSize    ByteSize         `json:"max_log_size,omitempty"   yaml:"max_log_size,omitempty"`
+	OversizedLogs string           `json:"oversized_logs,omitempty" yaml:"oversized_logs,omitempty"`
+}
+
+// Build will build a framer from the config. Modes other than the given ones are rejected.
+func (c FramingConfig) Build(modes ...string) (*Framer, error) {
+	supported := false
+	for _, mode := range modes {
+		if c.Framing == mode {
+			supported = true
+		}
+	}
+	if !supported {
+		return nil, fmt.Errorf("invalid value '%s' for 'framing'", c.Framing)
+	}
+
+	if c.MaxLogSize <= 0 {
+		return nil, fmt.Errorf("`max_log_size` must be positive")
+	}
+
+	switch c.OversizedLogs {
+	case TruncateOversized, DropOversized:
+	default:
+		return nil, fmt.Errorf("invalid value '%s' for 'oversized_logs'", c.OversizedLogs)
+	}
+
+	if c.Delimiter != "" && c.Framing != DelimiterFraming {
+		return nil, fmt.Errorf("`delimiter` can only be set with the delimiter framing")
+	}
+	if c.Multiline != nil && c.Framing != MultilineFraming {
+		return nil, fmt.Errorf("`multiline` can only be set with the multiline framing")
+	}
+
+	framer := &Framer{
+		mode:       c.Framing,
+		maxLogSize: int(c.MaxLogSize),
+		truncate:   c.OversizedLogs == TruncateOversized,
+	}
+
+	switch c.Framing {
+	case NewlineFraming:
+		framer.splitFunc = splitNewline
+	case NULFraming:
+		framer.splitFunc = splitNUL
+	case DelimiterFraming:
+		if c.Delimiter == "" {
+			return nil, fmt.Errorf("missing required parameter 'delimiter'")
+		}
+		re, err := regexp.Compile(c.Delimiter)
+		if err != nil {
+			return nil, fmt.Errorf("compile delimiter regex: %s", err)
+		}
+		if re.MatchString("") {
+			return nil, fmt.Errorf("delimiter regex must not match an empty string")
+		}
+		framer.splitFunc = newDelimiterSplitFunc(re)
+	case MultilineFraming:
+		if c.Multiline == nil {
+			return nil, fmt.Errorf("missing required parameter 'multiline'")
+		}
+		splitFunc, err := c.Multiline.Build()
+		if err != nil {
+			return nil, err
+		}
+		framer.splitFunc = splitFunc
+	}
+
+	return framer, nil
+}
+
+// Framer splits streams and datagrams into messages
+type Framer struct {
+	mode       string
+	maxLogSize int
+	truncate   bool
+	splitFunc  bufio.SplitFunc
+}
+
+// NewScanner returns a scanner of the messages in a stream. A message that is not
+// complete at the end of the stream is returned as it is, except when octet counting.
+func (f *Framer) NewScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+
+	// Leave room for the length prefix of an octet counted message
+	scanner.Buffer(make([]byte, 0, min(f.maxLogSize, 64*1024)), f.maxLogSize+maxOctetCountDigits+1)
+
+	s := &messageSplitter{Framer: f}
+	if f.mode == OctetCountingFraming {
+		scanner.Split(s.splitOctetCounted)
+	} else {
+		scanner.Split(s.split)
+	}
+	return scanner
+}
+
+// Split returns the messages in a datagram
+func (f *Framer) Split(datagram []byte) ([]string, error) {
+	if f.mode == NoFraming {
+		if len(datagram) <= f.maxLogSize {
+			return []string{string(datagram)}, nil
+		}
+		if f.truncate {
+			return []string{string(datagram[:f.maxLogSize])}, nil
+		}
+		return nil, nil
+	}
+
+	var messages []string
+	scanner := f.NewScanner(bytes.NewReader(datagram))
+	for scanner.Scan() {
+		messages = append(messages, scanner.Text())
+	}
+	return messages, scanner.Err()
+}
+
+// messageSplitter splits a single stream, keeping track of the rest of an oversized
+// message that is being skipped
+type messageSplitter struct {
+	*Framer
+	skipping  bool
+	skipBytes int
+}
+
+// split splits a stream with the framer's split function, truncating or dropping
+// messages that are longer than the max log size
+func (s *messageSplitter) split(data []byte, atEOF bool) (int, []byte, error) {
+	advance, token, err := s.splitFunc(data, atEOF)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if advance == 0 && token == nil {
+		if atEOF && len(data) > 0 {
+			advance, token = len(data), data
+		} else if len(data) > s.maxLogSize {
+			// There is no end to the message within the max log size
+			if s.skipping {
+				return len(data), nil, nil
+			}
+			s.skipping = true
+			if s.truncate {
+				return len(data), data[:s.maxLogSize], nil
+			}
+			return len(data), nil, nil
+		} else {
+			return 0, nil, nil
+		}
+	}
+
+	if s.skipping {
+		// This token is the rest of a message that was already truncated or dropped
+		s.skipping = false
+		return advance, nil, nil
+	}
+
+	if len(token) > s.maxLogSize {
+		if s.truncate {
+			return advance, token[:s.maxLogSize], nil
+		}
+		return advance, nil, nil
+	}
+	return advance, token, nil
+}
+
+// splitOctetCounted splits a stream of messages that are prefixed by their length
+// and a space
+func (s *messageSplitter) splitOctetCounted(data []byte, atEOF bool) (int, []byte, error) {
+	if s.skipBytes > 0 {
+		n := min(s.skipBytes, len(data))
+		s.skipBytes -= n
+		return n, nil, nil
+	}
+
+	if len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	space := bytes.IndexByte(data, ' ')
+	if space < 0 {
+		if len(data) > maxOctetCountDigits || atEOF {
+			return 0, nil, fmt.Errorf("invalid octet count '%s'", truncateString(data, maxOctetCountDigits))
+		}
+		return 0, nil, nil
+	}
+
+	length, err := strconv.Atoi(string(data[:space]))
+	if err != nil || length < 0 || space > maxOctetCountDigits || data[0] == '+' {
+		return 0, nil, fmt.Errorf("invalid octet count '%s'", truncateString(data[:space], maxOctetCountDigits))
+	}
+	start := space + 1
+
+	if length > s.maxLogSize {
+		if !s.truncate {
+			s.skipBytes = length
+			return start, nil, nil
+		}
+		if len(data) < start+s.maxLogSize {
+			if atEOF {
+				return len(data), nil, nil
+			}
+			return 0, nil, nil
+		}
+		s.skipBytes = length - s.maxLogSize
+		return start + s.maxLogSize, data[start : start+s.maxLogSize], nil
+	}
+
+	if len(data) < start+length {
+		if atEOF {
+			// The stream ended in the middle of a message
+			return len(data), nil, nil
+		}
+		return 0, nil, nil
+	}
+	return start + length, data[start : start+length], nil
+}
+
+// splitNewline splits messages on newlines, trimming a trailing carriage return
+func splitNewline(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return i + 1, bytes.TrimSuffix(data[:i], []byte{'\r'}), nil
+	}
+	return 0, nil, nil
+}
+
+// splitNUL splits messages on NUL bytes
+func splitNUL(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	return 0, nil, nil
+}
+
+// newDelimiterSplitFunc creates a split function that splits messages on matches of
+// the regex, leaving the delimiters out of the messages
+func newDelimiterSplitFunc(re *regexp.Regexp) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		loc := re.FindIndex(data)
+		if loc == nil {
+			return 0, nil, nil
+		}
+
+		// The match may continue in data that has not been read yet
+		if loc[1] == len(data) && !atEOF {
+			return 0, nil, nil
+		}
+		return loc[1], data[:loc[0]], nil
+	}
+}
+
+func truncateString(data []byte, n int) string {
+	if len(data) > n {
+		return string(data[:n]) + "..."
+	}
+	return string(data)
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
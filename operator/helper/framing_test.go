@@ -0,0 +1,231 @@
+package helper
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestFramer(t *testing.T, modify func(*FramingConfig)) *Framer {
+	cfg := NewFramingConfig(NewlineFraming)
+	if modify != nil {
+		modify(&cfg)
+	}
+	framer, err := cfg.Build(NewlineFraming, OctetCountingFraming, NULFraming, DelimiterFraming, MultilineFraming, NoFraming)
+	require.NoError(t, err)
+	return framer
+}
+
+// scanAll returns the messages in the input, making sure they are the same when the
+// input is read a byte at a time
+func scanAll(t *testing.T, framer *Framer, input string) ([]string, error) {
+	scan := func(r io.Reader) ([]string, error) {
+		var messages []string
+		scanner := framer.NewScanner(r)
+		for scanner.Scan() {
+			messages = append(messages, scanner.Text())
+		}
+		return messages, scanner.Err()
+	}
+
+	messages, err := scan(strings.NewReader(input))
+	oneByteMessages, oneByteErr := scan(iotest.OneByteReader(strings.NewReader(input)))
+	require.Equal(t, messages, oneByteMessages, "messages differ when read a byte at a time")
+	require.Equal(t, err == nil, oneByteErr == nil, "errors differ when read a byte at a time")
+	return messages, err
+}
+
+func TestFramingConfigBuild(t *testing.T) {
+	cases := []struct {
+		name      string
+		modify    func(*FramingConfig)
+		expectErr bool
+	}{
+		{"Default", func(cfg *FramingConfig) {}, false},
+		{"OctetCounting", func(cfg *FramingConfig) { cfg.Framing = OctetCountingFraming }, false},
+		{"Delimiter", func(cfg *FramingConfig) { cfg.Framing = DelimiterFraming; cfg.Delimiter = `\|\|` }, false},
+		{"Multiline", func(cfg *FramingConfig) {
+			cfg.Framing = MultilineFraming
+			cfg.Multiline = &MultilineConfig{LineStartPattern: `^\d`}
+		}, false},
+		{"Drop", func(cfg *FramingConfig) { cfg.OversizedLogs = DropOversized }, false},
+		{"UnsupportedFraming", func(cfg *FramingConfig) { cfg.Framing = NoFraming }, true},
+		{"InvalidFraming", func(cfg *FramingConfig) { cfg.Framing = "invalid" }, true},
+		{"ZeroMaxLogSize", func(cfg *FramingConfig) { cfg.MaxLogSize = 0 }, true},
+		{"InvalidOversizedLogs", func(cfg *FramingConfig) { cfg.OversizedLogs = "invalid" }, true},
+		{"MissingDelimiter", func(cfg *FramingConfig) { cfg.Framing = DelimiterFraming }, true},
+		{"InvalidDelimiter", func(cfg *FramingConfig) { cfg.Framing = DelimiterFraming; cfg.Delimiter = `(` }, true},
+		{"EmptyDelimiterMatch", func(cfg *FramingConfig) { cfg.Framing = DelimiterFraming; cfg.Delimiter = `,*` }, true},
+		{"DelimiterWithoutDelimiterFraming", func(cfg *FramingConfig) { cfg.Delimiter = `,` }, true},
+		{"MissingMultiline", func(cfg *FramingConfig) { cfg.Framing = MultilineFraming }, true},
+		{"InvalidMultiline", func(cfg *FramingConfig) {
+			cfg.Framing = MultilineFraming
+			cfg.Multiline = &MultilineConfig{}
+		}, true},
+		{"MultilineWithoutMultilineFraming", func(cfg *FramingConfig) { cfg.Multiline = &MultilineConfig{LineStartPattern: `^\d`} }, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := NewFramingConfig(NewlineFraming)
+			tc.modify(&cfg)
+			_, err := cfg.Build(NewlineFraming, OctetCountingFraming, NULFraming, DelimiterFraming, MultilineFraming)
+			if tc.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestFramerScanner(t *testing.T) {
+	long := strings.Repeat("a", 20)
+
+	cases := []struct {
+		name      string
+		modify    func(*FramingConfig)
+		input     string
+		expected  []string
+		expectErr bool
+	}{
+		{"Newline", nil, "message1\nmessage2\r\nmessage3", []string{"message1", "message2", "message3"}, false},
+		{"NewlineEmpty", nil, "", nil, false},
+		{"NUL", func(cfg *FramingConfig) { cfg.Framing = NULFraming }, "message1\x00message\n2\x00", []string{"message1", "message\n2"}, false},
+		{
+			"Delimiter",
+			func(cfg *FramingConfig) { cfg.Framing = DelimiterFraming; cfg.Delimiter = `\|+` },
+			"message1||message2|||message3",
+			[]string{"message1", "message2", "message3"},
+			false,
+		},
+		{
+			"Multiline",
+			func(cfg *FramingConfig) {
+				cfg.Framing = MultilineFraming
+				cfg.Multiline = &MultilineConfig{LineStartPattern: `^LOG `}
+			},
+			"LOG message1\n  detail\nLOG message2\n",
+			[]string{"LOG message1\n  detail\n", "LOG message2\n"},
+			false,
+		},
+		{
+			"OctetCounting",
+			func(cfg *FramingConfig) { cfg.Framing = OctetCountingFraming },
+			"8 message110 message\n2 0 8 message3",
+			[]string{"message1", "message\n2 ", "", "message3"},
+			false,
+		},
+		{
+			"OctetCountingIncomplete",
+			func(cfg *FramingConfig) { cfg.Framing = OctetCountingFraming },
+			"8 message110 message",
+			[]string{"message1"},
+			false,
+		},
+		{
+			"OctetCountingInvalid",
+			func(cfg *FramingConfig) { cfg.Framing = OctetCountingFraming },
+			"8 message1message2\n",
+			[]string{"message1"},
+			true,
+		},
+		{
+			"OctetCountingNegative",
+			func(cfg *FramingConfig) { cfg.Framing = OctetCountingFraming },
+			"-8 message1",
+			nil,
+			true,
+		},
+		{
+			"NewlineTruncate",
+			func(cfg *FramingConfig) { cfg.MaxLogSize = 10 },
+			"short\n" + long + "\nshort2\n",
+			[]string{"short", long[:10], "short2"},
+			false,
+		},
+		{
+			"NewlineDrop",
+			func(cfg *FramingConfig) { cfg.MaxLogSize = 10; cfg.OversizedLogs = DropOversized },
+			"short\n" + long + "\nshort2\n",
+			[]string{"short", "short2"},
+			false,
+		},
+		{
+			"NewlineMaxLogSize",
+			func(cfg *FramingConfig) { cfg.MaxLogSize = 8 },
+			"message1\r\nmessage2\nmessage33\n",
+			[]string{"message1", "message2", "message3"},
+			false,
+		},
+		{
+			"NewlineTruncateAtEOF",
+			func(cfg *FramingConfig) { cfg.MaxLogSize = 10 },
+			"short\n" + long,
+			[]string{"short", long[:10]},
+			false,
+		},
+		{
+			"OctetCountingTruncate",
+			func(cfg *FramingConfig) { cfg.Framing = OctetCountingFraming; cfg.MaxLogSize = 10 },
+			"5 short20 " + long + "6 short2",
+			[]string{"short", long[:10], "short2"},
+			false,
+		},
+		{
+			"OctetCountingDrop",
+			func(cfg *FramingConfig) {
+				cfg.Framing = OctetCountingFraming
+				cfg.MaxLogSize = 10
+				cfg.OversizedLogs = DropOversized
+			},
+			"5 short20 " + long + "6 short2",
+			[]string{"short", "short2"},
+			false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			framer := newTestFramer(t, tc.modify)
+			messages, err := scanAll(t, framer, tc.input)
+			if tc.expectErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.expected, messages)
+		})
+	}
+}
+
+func TestFramerSplit(t *testing.T) {
+	cases := []struct {
+		name     string
+		modify   func(*FramingConfig)
+		input    string
+		expected []string
+	}{
+		{"None", func(cfg *FramingConfig) { cfg.Framing = NoFraming }, "message1\nmessage2", []string{"message1\nmessage2"}},
+		{"NoneTruncate", func(cfg *FramingConfig) { cfg.Framing = NoFraming; cfg.MaxLogSize = 4 }, "message1", []string{"mess"}},
+		{"NoneDrop", func(cfg *FramingConfig) {
+			cfg.Framing = NoFraming
+			cfg.MaxLogSize = 4
+			cfg.OversizedLogs = DropOversized
+		}, "message1", nil},
+		{"Newline", nil, "message1\nmessage2\n", []string{"message1", "message2"}},
+		{"OctetCounting", func(cfg *FramingConfig) { cfg.Framing = OctetCountingFraming }, "8 message18 message2", []string{"message1", "message2"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			framer := newTestFramer(t, tc.modify)
+			messages, err := framer.Split([]byte(tc.input))
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, messages)
+		})
+	}
+}
@@ -20,12 +20,12 @@ func TestHostLabeler(t *testing.T) {
 	cases := []struct {
 		name             string
 		config           HostIdentifierConfig
-		expectedResource map[string]string
+		expectedResource map[string]interface{}
 	}{
 		{
 			"HostnameAndIP",
 			MockHostIdentifierConfig(true, true, "ip", "hostname"),
-			map[string]string{
+			map[string]interface{}{
 				"host.name": "hostname",
 				"host.ip":   "ip",
 			},
@@ -33,14 +33,14 @@ func TestHostLabeler(t *testing.T) {
 		{
 			"HostnameNoIP",
 			MockHostIdentifierConfig(false, true, "ip", "hostname"),
-			map[string]string{
+			map[string]interface{}{
 				"host.name": "hostname",
 			},
 		},
 		{
 			"IPNoHostname",
 			MockHostIdentifierConfig(true, false, "ip", "hostname"),
-			map[string]string{
+			map[string]interface{}{
 				"host.ip": "ip",
 			},
 		},
@@ -65,6 +65,7 @@ type InputOperator struct {
 // NewEntry will create a new entry using the `write_to`, `labels`, and `resource` configuration.
 func (i *InputOperator) NewEntry(value interface{}) (*entry.Entry, error) {
 	entry := entry.New()
+	entry.ObservedTimestamp = entry.Timestamp
 	if err := entry.Set(i.WriteTo, value); err != nil {
 		return nil, errors.Wrap(err, "add record to entry")
 	}
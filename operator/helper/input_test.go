@@ -118,6 +118,8 @@ func TestInputOperatorNewEntry(t *testing.T) {
 
 	entry, err := input.NewEntry("test")
 	require.NoError(t, err)
+	require.False(t, entry.ObservedTimestamp.IsZero())
+	require.Equal(t, entry.Timestamp, entry.ObservedTimestamp)
 
 	value, exists := entry.Get(writeTo)
 	require.True(t, exists)
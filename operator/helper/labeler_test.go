@@ -30,7 +30,7 @@ func TestLabeler(t *testing.T) {
 			entry.New(),
 			func() *entry.Entry {
 				e := entry.New()
-				e.Labels = map[string]string{
+				e.Labels = map[string]interface{}{
 					"label1": "value1",
 				}
 				return e
@@ -48,7 +48,7 @@ func TestLabeler(t *testing.T) {
 			entry.New(),
 			func() *entry.Entry {
 				e := entry.New()
-				e.Labels = map[string]string{
+				e.Labels = map[string]interface{}{
 					"label1": "startend",
 				}
 				return e
@@ -66,7 +66,7 @@ func TestLabeler(t *testing.T) {
 			entry.New(),
 			func() *entry.Entry {
 				e := entry.New()
-				e.Labels = map[string]string{
+				e.Labels = map[string]interface{}{
 					"label1": "foo",
 				}
 				return e
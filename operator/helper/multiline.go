@@ -0,0 +1,99 @@
+package helper
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+)
+
+// MultilineConfig is the configuration a multiline operation
+type MultilineConfig struct {
+	LineStartPattern string `json:"line_start_pattern" yaml:"line_start_pattern"`
+	LineEndPattern   string `json:"line_end_pattern"   yaml:"line_end_pattern"`
+}
+
+// Build will build a split function that splits a stream into multiline entries
+func (c MultilineConfig) Build() (bufio.SplitFunc, error) {
+	endPattern := c.LineEndPattern
+	startPattern := c.LineStartPattern
+
+	switch {
+	case endPattern != "" && startPattern != "":
+		return nil, fmt.Errorf("only one of line_start_pattern or line_end_pattern can be set")
+	case endPattern == "" && startPattern == "":
+		return nil, fmt.Errorf("one of line_start_pattern or line_end_pattern must be set")
+	case endPattern != "":
+		re, err := regexp.Compile("(?m)" + c.LineEndPattern)
+		if err != nil {
+			return nil, fmt.Errorf("compile line end regex: %s", err)
+		}
+		return NewLineEndSplitFunc(re), nil
+	case startPattern != "":
+		re, err := regexp.Compile("(?m)" + c.LineStartPattern)
+		if err != nil {
+			return nil, fmt.Errorf("compile line start regex: %s", err)
+		}
+		return NewLineStartSplitFunc(re), nil
+	default:
+		return nil, fmt.Errorf("unreachable")
+	}
+}
+
+// NewLineStartSplitFunc creates a bufio.SplitFunc that splits an incoming stream into
+// tokens that start with a match to the regex pattern provided
+func NewLineStartSplitFunc(re *regexp.Regexp) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		firstLoc := re.FindIndex(data)
+		if firstLoc == nil {
+			return 0, nil, nil // read more data and try again.
+		}
+		firstMatchStart := firstLoc[0]
+		firstMatchEnd := firstLoc[1]
+
+		if firstMatchStart != 0 {
+			// the beginning of the file does not match the start pattern, so return a token up to the first match so we don't lose data
+			advance = firstMatchStart
+			token = data[0:firstMatchStart]
+			return
+		}
+
+		if firstMatchEnd == len(data) {
+			// the first match goes to the end of the buffer, so don't look for a second match
+			return 0, nil, nil
+		}
+
+		secondLocOffset := firstMatchEnd + 1
+		secondLoc := re.FindIndex(data[secondLocOffset:])
+		if secondLoc == nil {
+			return 0, nil, nil // read more data and try again
+		}
+		secondMatchStart := secondLoc[0] + secondLocOffset
+
+		advance = secondMatchStart                     // start scanning at the beginning of the second match
+		token = data[firstMatchStart:secondMatchStart] // the token begins at the first match, and ends at the beginning of the second match
+		err = nil
+		return
+	}
+}
+
+// NewLineEndSplitFunc creates a bufio.SplitFunc that splits an incoming stream into
+// tokens that end with a match to the regex pattern provided
+func NewLineEndSplitFunc(re *regexp.Regexp) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		loc := re.FindIndex(data)
+		if loc == nil {
+			return 0, nil, nil // read more data and try again
+		}
+
+		// If the match goes up to the end of the current buffer, do another
+		// read until we can capture the entire match
+		if loc[1] == len(data)-1 && !atEOF {
+			return 0, nil, nil
+		}
+
+		advance = loc[1]
+		token = data[:loc[1]]
+		err = nil
+		return
+	}
+}
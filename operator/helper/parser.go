@@ -27,6 +27,7 @@ type ParserConfig struct {
 	PreserveTo           *entry.Field          `json:"preserve_to"         yaml:"preserve_to"`
 	TimeParser           *TimeParser           `json:"timestamp,omitempty" yaml:"timestamp,omitempty"`
 	SeverityParserConfig *SeverityParserConfig `json:"severity,omitempty"  yaml:"severity,omitempty"`
+	TraceParser          *TraceParser          `json:"trace,omitempty"     yaml:"trace,omitempty"`
 }
 
 // Build will build a parser operator.
@@ -58,6 +59,13 @@ func (c ParserConfig) Build(context operator.BuildContext) (ParserOperator, erro
 		parserOperator.SeverityParser = &severityParser
 	}
 
+	if c.TraceParser != nil {
+		if err := c.TraceParser.Validate(); err != nil {
+			return ParserOperator{}, err
+		}
+		parserOperator.TraceParser = c.TraceParser
+	}
+
 	return parserOperator, nil
 }
 
@@ -69,6 +77,7 @@ type ParserOperator struct {
 	PreserveTo     *entry.Field
 	TimeParser     *TimeParser
 	SeverityParser *SeverityParser
+	TraceParser    *TraceParser
 }
 
 // ProcessWith will run ParseWith on the entry, then forward the entry on to the next operators.
@@ -90,32 +99,47 @@ func (p *ParserOperator) ProcessWith(ctx context.Context, entry *entry.Entry, pa
 	return nil
 }
 
+// ProcessBatchWith will run ParseWith on each entry of a batch, then forward the entries that
+// are not dropped to the next operators in a single batch.
+func (p *ParserOperator) ProcessBatchWith(ctx context.Context, entries []*entry.Entry, parse ParseFunction) error {
+	return p.TransformerOperator.ProcessBatchWith(ctx, entries, func(entry *entry.Entry) error {
+		return p.parse(entry, parse)
+	})
+}
+
 // ParseWith will process an entry's field with a parser function.
 func (p *ParserOperator) ParseWith(ctx context.Context, entry *entry.Entry, parse ParseFunction) error {
+	if err := p.parse(entry, parse); err != nil {
+		return p.HandleEntryError(ctx, entry, err)
+	}
+	return nil
+}
+
+// parse processes an entry's field with a parser function, returning any error without handling it
+func (p *ParserOperator) parse(entry *entry.Entry, parse ParseFunction) error {
 	value, ok := entry.Get(p.ParseFrom)
 	if !ok {
-		err := errors.NewError(
+		return errors.NewError(
 			"Entry is missing the expected parse_from field.",
 			"Ensure that all incoming entries contain the parse_from field.",
 			"parse_from", p.ParseFrom.String(),
 		)
-		return p.HandleEntryError(ctx, entry, err)
 	}
 
 	newValue, err := parse(value)
 	if err != nil {
-		return p.HandleEntryError(ctx, entry, err)
+		return err
 	}
 
 	original, _ := entry.Delete(p.ParseFrom)
 
 	if err := entry.Set(p.ParseTo, newValue); err != nil {
-		return p.HandleEntryError(ctx, entry, errors.Wrap(err, "set parse_to"))
+		return errors.Wrap(err, "set parse_to")
 	}
 
 	if p.PreserveTo != nil {
 		if err := entry.Set(p.PreserveTo, original); err != nil {
-			return p.HandleEntryError(ctx, entry, errors.Wrap(err, "set preserve_to"))
+			return errors.Wrap(err, "set preserve_to")
 		}
 	}
 
@@ -129,12 +153,20 @@ func (p *ParserOperator) ParseWith(ctx context.Context, entry *entry.Entry, pars
 		severityParseErr = p.SeverityParser.Parse(entry)
 	}
 
-	// Handle time or severity parsing errors after attempting to parse both
+	var traceParseErr error
+	if p.TraceParser != nil {
+		traceParseErr = p.TraceParser.Parse(entry)
+	}
+
+	// Return time, severity or trace parsing errors after attempting to parse all of them
 	if timeParseErr != nil {
-		return p.HandleEntryError(ctx, entry, errors.Wrap(timeParseErr, "time parser"))
+		return errors.Wrap(timeParseErr, "time parser")
 	}
 	if severityParseErr != nil {
-		return p.HandleEntryError(ctx, entry, errors.Wrap(severityParseErr, "severity parser"))
+		return errors.Wrap(severityParseErr, "severity parser")
+	}
+	if traceParseErr != nil {
+		return errors.Wrap(traceParseErr, "trace parser")
 	}
 	return nil
 }
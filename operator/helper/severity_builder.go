@@ -141,6 +141,15 @@ func (c *SeverityParserConfig) Build(context operator.BuildContext) (SeverityPar
 	return p, nil
 }
 
+// ParseSeverity returns the severity identified by a name, such as "error",
+// or by a number between 0 and 100
+func ParseSeverity(severity interface{}) (entry.Severity, error) {
+	if f, ok := severity.(float64); ok && f == float64(int(f)) {
+		severity = int(f)
+	}
+	return validateSeverity(severity)
+}
+
 func validateSeverity(severity interface{}) (entry.Severity, error) {
 	if sev, _, err := getBuiltinMapping("aliases").find(severity); err != nil {
 		return entry.Default, err
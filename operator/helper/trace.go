@@ -0,0 +1,118 @@
+package helper
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/observiq/stanza/entry"
+	"github.com/observiq/stanza/errors"
+)
+
+// NewTraceParser creates a new trace parser with default values
+func NewTraceParser() TraceParser {
+	return TraceParser{}
+}
+
+// TraceParser is a helper that parses a W3C traceparent value onto the trace context of an entry
+type TraceParser struct {
+	ParseFrom  *entry.Field `json:"parse_from,omitempty"  yaml:"parse_from,omitempty"`
+	PreserveTo *entry.Field `json:"preserve_to,omitempty" yaml:"preserve_to,omitempty"`
+}
+
+// Validate validates a TraceParser
+func (t *TraceParser) Validate() error {
+	if t.ParseFrom == nil {
+		return fmt.Errorf("missing required parameter 'parse_from'")
+	}
+	return nil
+}
+
+// Parse will parse a traceparent value from a field and attach its trace context to the entry
+func (t *TraceParser) Parse(ent *entry.Entry) error {
+	value, ok := ent.Get(*t.ParseFrom)
+	if !ok {
+		return errors.NewError(
+			"log entry does not have the expected parse_from field",
+			"ensure that all entries forwarded to this parser contain the parse_from field",
+			"parse_from", t.ParseFrom.String(),
+		)
+	}
+
+	var traceparent string
+	switch v := value.(type) {
+	case string:
+		traceparent = v
+	case []byte:
+		traceparent = string(v)
+	default:
+		return fmt.Errorf("type %T cannot be parsed as a traceparent", value)
+	}
+
+	traceID, spanID, flags, err := ParseTraceparent(traceparent)
+	if err != nil {
+		return errors.Wrap(err, "parse traceparent")
+	}
+
+	ent.Delete(*t.ParseFrom)
+	ent.TraceID = traceID
+	ent.SpanID = spanID
+	ent.TraceFlags = flags
+
+	if t.PreserveTo != nil {
+		if err := ent.Set(t.PreserveTo, value); err != nil {
+			return errors.Wrap(err, "set preserve_to")
+		}
+	}
+
+	return nil
+}
+
+// ParseTraceparent parses the trace ID, span ID and trace flags from a W3C traceparent
+// value, such as 00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01
+func ParseTraceparent(traceparent string) (entry.TraceID, entry.SpanID, entry.TraceFlags, error) {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 {
+		return nil, nil, nil, fmt.Errorf("expected 4 parts separated by '-', got %d", len(parts))
+	}
+
+	version := parts[0]
+	if len(version) != 2 {
+		return nil, nil, nil, fmt.Errorf("invalid version '%s'", version)
+	}
+	switch version {
+	case "ff":
+		return nil, nil, nil, fmt.Errorf("version 'ff' is forbidden")
+	case "00":
+		// Later versions may append more parts, but version 00 has exactly 4
+		if len(parts) != 4 {
+			return nil, nil, nil, fmt.Errorf("expected 4 parts for version 00, got %d", len(parts))
+		}
+	}
+
+	var traceID entry.TraceID
+	if err := traceID.UnmarshalText([]byte(parts[1])); err != nil {
+		return nil, nil, nil, errors.Wrap(err, "trace id")
+	}
+	if len(traceID) == 0 || bytes.Equal(traceID, make([]byte, entry.TraceIDLength)) {
+		return nil, nil, nil, fmt.Errorf("trace id must not be empty or all zeros")
+	}
+
+	var spanID entry.SpanID
+	if err := spanID.UnmarshalText([]byte(parts[2])); err != nil {
+		return nil, nil, nil, errors.Wrap(err, "span id")
+	}
+	if len(spanID) == 0 || bytes.Equal(spanID, make([]byte, entry.SpanIDLength)) {
+		return nil, nil, nil, fmt.Errorf("span id must not be empty or all zeros")
+	}
+
+	var flags entry.TraceFlags
+	if err := flags.UnmarshalText([]byte(parts[3])); err != nil {
+		return nil, nil, nil, errors.Wrap(err, "trace flags")
+	}
+	if len(flags) == 0 {
+		return nil, nil, nil, fmt.Errorf("trace flags must not be empty")
+	}
+
+	return traceID, spanID, flags, nil
+}
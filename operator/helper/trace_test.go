@@ -0,0 +1,99 @@
+package helper
+
+import (
+	"testing"
+
+	"github.com/observiq/stanza/entry"
+	"github.com/observiq/stanza/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	cases := []struct {
+		name        string
+		input       string
+		traceID     string
+		spanID      string
+		flags       string
+		expectedErr bool
+	}{
+		{"Sampled", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", "0af7651916cd43dd8448eb211c80319c", "b7ad6b7169203331", "01", false},
+		{"NotSampled", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-00", "0af7651916cd43dd8448eb211c80319c", "b7ad6b7169203331", "00", false},
+		{"Whitespace", " 00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01\n", "0af7651916cd43dd8448eb211c80319c", "b7ad6b7169203331", "01", false},
+		{"FutureVersion", "01-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01-extra", "0af7651916cd43dd8448eb211c80319c", "b7ad6b7169203331", "01", false},
+		{"ExtraPartsVersion00", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01-extra", "", "", "", true},
+		{"ForbiddenVersion", "ff-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", "", "", "", true},
+		{"TooFewParts", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331", "", "", "", true},
+		{"ZeroTraceID", "00-00000000000000000000000000000000-b7ad6b7169203331-01", "", "", "", true},
+		{"ZeroSpanID", "00-0af7651916cd43dd8448eb211c80319c-0000000000000000-01", "", "", "", true},
+		{"ShortTraceID", "00-0af7651916cd43dd-b7ad6b7169203331-01", "", "", "", true},
+		{"InvalidHex", "00-0af7651916cd43dd8448eb211c80319z-b7ad6b7169203331-01", "", "", "", true},
+		{"Empty", "", "", "", "", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			traceID, spanID, flags, err := ParseTraceparent(tc.input)
+			if tc.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.traceID, traceID.String())
+			require.Equal(t, tc.spanID, spanID.String())
+			require.Equal(t, tc.flags, flags.String())
+		})
+	}
+}
+
+func TestTraceParser(t *testing.T) {
+	t.Run("MissingParseFrom", func(t *testing.T) {
+		parser := NewTraceParser()
+		require.Error(t, parser.Validate())
+	})
+
+	t.Run("Preserve", func(t *testing.T) {
+		parseFrom := entry.NewRecordField("traceparent")
+		preserveTo := entry.NewLabelField("traceparent")
+		parser := TraceParser{ParseFrom: &parseFrom, PreserveTo: &preserveTo}
+		require.NoError(t, parser.Validate())
+
+		e := entry.New()
+		e.Record = map[string]interface{}{
+			"traceparent": "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+		}
+		require.NoError(t, parser.Parse(e))
+		require.Equal(t, "0af7651916cd43dd8448eb211c80319c", e.TraceID.String())
+		require.Equal(t, "b7ad6b7169203331", e.SpanID.String())
+		require.True(t, e.TraceFlags.Sampled())
+		require.Equal(t, map[string]interface{}{}, e.Record)
+		require.Equal(t, "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", e.Labels["traceparent"])
+	})
+
+	t.Run("Missing", func(t *testing.T) {
+		parseFrom := entry.NewRecordField("traceparent")
+		parser := TraceParser{ParseFrom: &parseFrom}
+		require.Error(t, parser.Parse(entry.New()))
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		parseFrom := entry.NewRecordField("traceparent")
+		parser := TraceParser{ParseFrom: &parseFrom}
+		e := entry.New()
+		e.Record = map[string]interface{}{"traceparent": 5}
+		require.Error(t, parser.Parse(e))
+	})
+}
+
+func TestParserConfigTraceParser(t *testing.T) {
+	cfg := NewParserConfig("test-id", "test-type")
+	cfg.TraceParser = &TraceParser{}
+	_, err := cfg.Build(testutil.NewBuildContext(t))
+	require.Error(t, err)
+
+	parseFrom := entry.NewRecordField("traceparent")
+	cfg.TraceParser = &TraceParser{ParseFrom: &parseFrom}
+	op, err := cfg.Build(testutil.NewBuildContext(t))
+	require.NoError(t, err)
+	require.NotNil(t, op.TraceParser)
+}
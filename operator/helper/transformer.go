@@ -23,8 +23,14 @@ func NewTransformerConfig(operatorID, operatorType string) TransformerConfig {
 // TransformerConfig provides a basic implementation of a transformer config.
 type TransformerConfig struct {
 	WriterConfig `yaml:",inline"`
-	OnError      string `json:"on_error" yaml:"on_error"`
-	IfExpr       string `json:"if"                  yaml:"if"`
+	OnError      string       `json:"on_error"        yaml:"on_error"`
+	IfExpr       string       `json:"if"              yaml:"if"`
+	Async        *AsyncConfig `json:"async,omitempty" yaml:"async,omitempty"`
+}
+
+// AsyncOptions returns the async config of the transformer, or nil if it runs synchronously
+func (c TransformerConfig) AsyncOptions() *AsyncConfig {
+	return c.Async
 }
 
 // Build will build a transformer operator.
@@ -91,9 +97,39 @@ func (t *TransformerOperator) ProcessWith(ctx context.Context, entry *entry.Entr
 	return nil
 }
 
+// ProcessBatchWith will process a batch of entries with a transform function, then write
+// the entries that are not dropped to the next operators in a single batch.
+func (t *TransformerOperator) ProcessBatchWith(ctx context.Context, entries []*entry.Entry, transform TransformFunction) error {
+	env := GetExprEnv(nil)
+	defer PutExprEnv(env)
+
+	var firstErr error
+	kept := make([]*entry.Entry, 0, len(entries))
+	for _, entry := range entries {
+		skip, err := t.skipWithEnv(env, entry)
+		if err == nil && !skip {
+			err = transform(entry)
+		}
+
+		if err != nil {
+			t.logEntryError(entry, err)
+			if t.OnError != SendOnError {
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+		}
+		kept = append(kept, entry)
+	}
+
+	t.WriteBatch(ctx, kept)
+	return firstErr
+}
+
 // HandleEntryError will handle an entry error using the on_error strategy.
 func (t *TransformerOperator) HandleEntryError(ctx context.Context, entry *entry.Entry, err error) error {
-	t.Errorw("Failed to process entry", zap.Any("error", err), zap.Any("action", t.OnError), zap.Any("entry", entry))
+	t.logEntryError(entry, err)
 	if t.OnError == SendOnError {
 		t.Write(ctx, entry)
 		return nil
@@ -101,6 +137,10 @@ func (t *TransformerOperator) HandleEntryError(ctx context.Context, entry *entry
 	return err
 }
 
+func (t *TransformerOperator) logEntryError(entry *entry.Entry, err error) {
+	t.Errorw("Failed to process entry", zap.Any("error", err), zap.Any("action", t.OnError), zap.Any("entry", entry))
+}
+
 func (t *TransformerOperator) Skip(ctx context.Context, entry *entry.Entry) (bool, error) {
 	if t.IfExpr == nil {
 		return false, nil
@@ -109,6 +149,16 @@ func (t *TransformerOperator) Skip(ctx context.Context, entry *entry.Entry) (boo
 	env := GetExprEnv(entry)
 	defer PutExprEnv(env)
 
+	return t.skipWithEnv(env, entry)
+}
+
+// skipWithEnv is Skip using an expression env that is reused between entries
+func (t *TransformerOperator) skipWithEnv(env map[string]interface{}, entry *entry.Entry) (bool, error) {
+	if t.IfExpr == nil {
+		return false, nil
+	}
+
+	SetExprEnv(env, entry)
 	matches, err := vm.Run(t.IfExpr, env)
 	if err != nil {
 		return false, fmt.Errorf("running if expr: %s", err)
@@ -139,6 +139,91 @@ func TestTransformerProcessWithValid(t *testing.T) {
 	output.AssertCalled(t, "Process", mock.Anything, mock.Anything)
 }
 
+func TestTransformerProcessBatchWith(t *testing.T) {
+	cases := []struct {
+		name        string
+		onError     string
+		ifExpr      string
+		records     []interface{}
+		expectError bool
+		expected    []interface{}
+	}{
+		{
+			"Valid",
+			DropOnError,
+			"",
+			[]interface{}{"test", "test", "test"},
+			false,
+			[]interface{}{"parsed", "parsed", "parsed"},
+		},
+		{
+			"SendOnError",
+			SendOnError,
+			"",
+			[]interface{}{"test", "fail", "test"},
+			false,
+			[]interface{}{"parsed", "fail", "parsed"},
+		},
+		{
+			"DropOnError",
+			DropOnError,
+			"",
+			[]interface{}{"test", "fail", "test"},
+			true,
+			[]interface{}{"parsed", "parsed"},
+		},
+		{
+			"If",
+			DropOnError,
+			"$record != 'fail'",
+			[]interface{}{"test", "fail", "test"},
+			false,
+			[]interface{}{"parsed", "fail", "parsed"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := NewTransformerConfig("test", "test")
+			cfg.OnError = tc.onError
+			cfg.IfExpr = tc.ifExpr
+			transformer, err := cfg.Build(testutil.NewBuildContext(t))
+			require.NoError(t, err)
+
+			output := &batchOutput{}
+			transformer.OutputOperators = []operator.Operator{output}
+
+			entries := make([]*entry.Entry, 0, len(tc.records))
+			for _, record := range tc.records {
+				e := entry.New()
+				e.Record = record
+				entries = append(entries, e)
+			}
+			transform := func(e *entry.Entry) error {
+				if e.Record == "fail" {
+					return fmt.Errorf("failure")
+				}
+				e.Record = "parsed"
+				return nil
+			}
+
+			err = transformer.ProcessBatchWith(context.Background(), entries, transform)
+			if tc.expectError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.Len(t, output.batches, 1)
+			records := []interface{}{}
+			for _, e := range output.batches[0] {
+				records = append(records, e.Record)
+			}
+			require.Equal(t, tc.expected, records)
+		})
+	}
+}
+
 func TestTransformerIf(t *testing.T) {
 	cases := []struct {
 		name        string
@@ -49,17 +49,57 @@ type WriterOperator struct {
 	OutputOperators []operator.Operator
 }
 
-// Write will write an entry to the outputs of the operator.
+// Write will write an entry to the outputs of the operator. When there is more than
+// one output, each output receives an entry that shares its contents with the others
+// until it is modified.
 func (w *WriterOperator) Write(ctx context.Context, e *entry.Entry) {
 	for i, operator := range w.OutputOperators {
 		if i == len(w.OutputOperators)-1 {
 			_ = operator.Process(ctx, e)
 			return
 		}
-		_ = operator.Process(ctx, e.Copy())
+		_ = operator.Process(ctx, e.Share())
 	}
 }
 
+// WriteBatch will write a batch of entries to the outputs of the operator. Outputs that
+// implement operator.BatchProcessor receive the whole batch in one call, and others
+// receive the entries one at a time.
+func (w *WriterOperator) WriteBatch(ctx context.Context, entries []*entry.Entry) {
+	if len(entries) == 0 {
+		return
+	}
+
+	for i, operator := range w.OutputOperators {
+		if i == len(w.OutputOperators)-1 {
+			_ = ProcessBatch(ctx, operator, entries)
+			return
+		}
+
+		shared := make([]*entry.Entry, len(entries))
+		for j, e := range entries {
+			shared[j] = e.Share()
+		}
+		_ = ProcessBatch(ctx, operator, shared)
+	}
+}
+
+// ProcessBatch sends a batch of entries to an operator. If the operator does not implement
+// operator.BatchProcessor, the entries are processed one at a time, and the first error is returned.
+func ProcessBatch(ctx context.Context, op operator.Operator, entries []*entry.Entry) error {
+	if batcher, ok := op.(operator.BatchProcessor); ok {
+		return batcher.ProcessBatch(ctx, entries)
+	}
+
+	var firstErr error
+	for _, e := range entries {
+		if err := op.Process(ctx, e); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
 // CanOutput always returns true for a writer operator.
 func (w *WriterOperator) CanOutput() bool {
 	return true
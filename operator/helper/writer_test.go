@@ -3,6 +3,7 @@ package helper
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"testing"
 
 	"github.com/observiq/stanza/entry"
@@ -53,6 +54,96 @@ func TestWriterOperatorWrite(t *testing.T) {
 	output2.AssertCalled(t, "Process", ctx, mock.Anything)
 }
 
+func TestWriterOperatorWriteIsolatesOutputs(t *testing.T) {
+	received := make([]*entry.Entry, 0, 3)
+	outputs := make([]operator.Operator, 0, 3)
+	for i := 0; i < 3; i++ {
+		output := &testutil.Operator{}
+		output.On("Process", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+			received = append(received, args[1].(*entry.Entry))
+		})
+		outputs = append(outputs, output)
+	}
+	writer := WriterOperator{
+		OutputOperators: outputs,
+	}
+
+	testEntry := entry.New()
+	testEntry.Record = map[string]interface{}{"key": "value"}
+	writer.Write(context.Background(), testEntry)
+	require.Len(t, received, 3)
+
+	// Each output can modify its entry without affecting the others
+	for i, e := range received {
+		require.NoError(t, e.Set(entry.NewRecordField("key"), i))
+		e.AddLabel("output", "modified")
+	}
+	for i, e := range received {
+		require.Equal(t, map[string]interface{}{"key": i}, e.Record)
+		require.Equal(t, map[string]interface{}{"output": "modified"}, e.Labels)
+	}
+}
+
+// batchOutput is a mock operator that records the batches it receives
+type batchOutput struct {
+	testutil.Operator
+	batches [][]*entry.Entry
+}
+
+func (b *batchOutput) ProcessBatch(ctx context.Context, entries []*entry.Entry) error {
+	b.batches = append(b.batches, entries)
+	return nil
+}
+
+func TestWriterOperatorWriteBatch(t *testing.T) {
+	batcher := &batchOutput{}
+	single := &testutil.Operator{}
+	var received []*entry.Entry
+	single.On("Process", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		received = append(received, args[1].(*entry.Entry))
+	})
+	writer := WriterOperator{
+		OutputOperators: []operator.Operator{batcher, single},
+	}
+
+	entries := []*entry.Entry{entry.New(), entry.New(), entry.New()}
+	for i, e := range entries {
+		e.Record = map[string]interface{}{"index": i}
+	}
+	writer.WriteBatch(context.Background(), entries)
+
+	// The batch output receives the whole batch in one call
+	require.Len(t, batcher.batches, 1)
+	require.Len(t, batcher.batches[0], 3)
+
+	// Other outputs receive the entries one at a time, in order
+	require.Equal(t, entries, received)
+
+	// Each output can modify its entries without affecting the other
+	require.NoError(t, batcher.batches[0][0].Set(entry.NewRecordField("index"), "modified"))
+	require.Equal(t, map[string]interface{}{"index": 0}, received[0].Record)
+}
+
+func TestWriterOperatorWriteEmptyBatch(t *testing.T) {
+	batcher := &batchOutput{}
+	writer := WriterOperator{
+		OutputOperators: []operator.Operator{batcher},
+	}
+
+	writer.WriteBatch(context.Background(), nil)
+	require.Len(t, batcher.batches, 0)
+}
+
+func TestProcessBatchReturnsFirstError(t *testing.T) {
+	output := &testutil.Operator{}
+	output.On("Process", mock.Anything, mock.Anything).Return(fmt.Errorf("failure")).Once()
+	output.On("Process", mock.Anything, mock.Anything).Return(nil)
+
+	err := ProcessBatch(context.Background(), output, []*entry.Entry{entry.New(), entry.New()})
+	require.Error(t, err)
+	output.AssertNumberOfCalls(t, "Process", 2)
+}
+
 func TestWriterOperatorCanOutput(t *testing.T) {
 	writer := WriterOperator{}
 	require.True(t, writer.CanOutput())
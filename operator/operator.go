@@ -35,3 +35,18 @@ type Operator interface {
 	// Logger returns the operator's logger
 	Logger() *zap.SugaredLogger
 }
+
+// BatchProcessor is an operator that can process many entries in a single call. Operators
+// that do not implement it are sent entries one at a time. The receiver owns the batch's
+// slice once it is sent, and may hold on to it after ProcessBatch returns.
+type BatchProcessor interface {
+	// ProcessBatch will process a batch of entries from an operator.
+	ProcessBatch(context.Context, []*entry.Entry) error
+}
+
+// StatusReporter is an operator that can report its current state, such as the depth of its
+// queues or whether it is able to send entries. Operators that do not implement it have no status.
+type StatusReporter interface {
+	// Status returns a snapshot of the state of the operator, keyed by name
+	Status() map[string]interface{}
+}
@@ -1,7 +1,9 @@
 package pipeline
 
 import (
+	"github.com/observiq/stanza/errors"
 	"github.com/observiq/stanza/operator"
+	"github.com/observiq/stanza/operator/helper"
 )
 
 // Config is the configuration of a pipeline.
@@ -16,6 +18,9 @@ func (c Config) BuildOperators(bc operator.BuildContext) ([]operator.Operator, e
 		if err != nil {
 			return nil, err
 		}
+		if op, err = wrapAsync(builder, op); err != nil {
+			return nil, err
+		}
 		operators = append(operators, op...)
 	}
 	return operators, nil
@@ -39,6 +44,24 @@ func (c Config) BuildPipeline(bc operator.BuildContext, defaultOperator operator
 	return NewDirectedPipeline(operators)
 }
 
+// wrapAsync wraps the operators built by a config in async stages if the config enables it
+func wrapAsync(builder operator.Config, operators []operator.Operator) ([]operator.Operator, error) {
+	asyncBuilder, ok := builder.Builder.(helper.AsyncBuilder)
+	if !ok || asyncBuilder.AsyncOptions() == nil {
+		return operators, nil
+	}
+
+	wrapped := make([]operator.Operator, 0, len(operators))
+	for _, op := range operators {
+		asyncOp, err := asyncBuilder.AsyncOptions().Build(op)
+		if err != nil {
+			return nil, errors.WithDetails(err, "operator_id", op.ID())
+		}
+		wrapped = append(wrapped, asyncOp)
+	}
+	return wrapped, nil
+}
+
 func getBuildContextWithDefaultOutput(configs []operator.Config, i int, bc operator.BuildContext) operator.BuildContext {
 	if i+1 >= len(configs) {
 		return bc
@@ -0,0 +1,64 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/observiq/stanza/entry"
+	"github.com/observiq/stanza/operator/builtin/parser/json"
+	"github.com/observiq/stanza/operator/helper"
+	"github.com/observiq/stanza/testutil"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestBuildPipelineAsync(t *testing.T) {
+	raw := `
+- id: parser
+  type: json_parser
+  async:
+    workers: 2
+    queue_size: 10
+    order_by: $labels.source
+`
+	var config Config
+	require.NoError(t, yaml.Unmarshal([]byte(raw), &config))
+
+	output := testutil.NewFakeOutput(t)
+	pipeline, err := config.BuildPipeline(testutil.NewBuildContext(t), output)
+	require.NoError(t, err)
+
+	var parser *helper.AsyncOperator
+	for _, op := range pipeline.Operators() {
+		if op.ID() == "$.parser" {
+			var ok bool
+			parser, ok = op.(*helper.AsyncOperator)
+			require.True(t, ok)
+			require.IsType(t, &json.JSONParser{}, parser.Operator)
+		}
+	}
+	require.NotNil(t, parser)
+
+	require.NoError(t, pipeline.Start())
+	defer pipeline.Stop()
+
+	e := entry.New()
+	e.Record = `{"key":"value"}`
+	require.NoError(t, parser.Process(context.Background(), e))
+	output.ExpectRecord(t, map[string]interface{}{"key": "value"})
+}
+
+func TestBuildPipelineAsyncInvalid(t *testing.T) {
+	raw := `
+- id: parser
+  type: json_parser
+  async:
+    workers: -1
+`
+	var config Config
+	require.NoError(t, yaml.Unmarshal([]byte(raw), &config))
+
+	_, err := config.BuildPipeline(testutil.NewBuildContext(t), testutil.NewFakeOutput(t))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "workers")
+}